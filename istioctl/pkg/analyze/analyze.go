@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -81,6 +82,11 @@ var (
 	ignoreUnknown     bool
 	revisionSpecified string
 	remoteContexts    []string
+	severityOverrides string
+	fix               bool
+	fixDryRun         bool
+	watch             bool
+	optionalAnalyzers bool
 
 	fileExtensions = []string{".json", ".yaml", ".yml"}
 )
@@ -112,6 +118,15 @@ func Analyze(ctx cli.Context) *cobra.Command {
   # and suppress MisplacedAnnotation on deployment foobar in namespace default.
   istioctl analyze -S "IST0103=Pod *.testing" -S "IST0107=Deployment foobar.default"
 
+  # Analyze yaml files without connecting to a live cluster, and fix the issues found in the files
+  istioctl analyze --use-kube=false --fix a.yaml b.yaml
+
+  # Show the fixes for the issues found in the current live cluster, without applying them
+  istioctl analyze --fix-dry-run
+
+  # Analyze the current live cluster and local files, then print the issues added or resolved as they change
+  istioctl analyze --watch my-app-config/
+
   # List available analyzers
   istioctl analyze -L`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -123,11 +138,41 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				}
 			}
 
+			var levelOverrides map[string]diag.Level
+			if severityOverrides != "" {
+				var err error
+				if levelOverrides, err = formatting.LoadSeverityOverrides(severityOverrides); err != nil {
+					return util.CommandParseError{Err: err}
+				}
+				for code := range levelOverrides {
+					if !isKnownCode(code) {
+						fmt.Fprintf(cmd.ErrOrStderr(), "Warning: Supplied message code '%s' is an unknown message code and will not have any effect.\n", code)
+					}
+				}
+			}
+
+			selectedAnalyzers := analyzers.All()
+			if optionalAnalyzers {
+				selectedAnalyzers = append(selectedAnalyzers, analyzers.Optional()...)
+			}
+			combinedAnalyzer := analysis.Combine("all", selectedAnalyzers...)
+
 			if listAnalyzers {
-				fmt.Print(AnalyzersAsString(analyzers.All()))
+				fmt.Print(AnalyzersAsString(selectedAnalyzers))
 				return nil
 			}
 
+			if fix && fixDryRun {
+				return util.CommandParseError{Err: fmt.Errorf("--fix and --fix-dry-run cannot be used together")}
+			}
+
+			if watch && fix {
+				return util.CommandParseError{Err: fmt.Errorf("--fix cannot be used with --watch")}
+			}
+			if watch && msgOutputFormat != formatting.LogFormat {
+				return util.CommandParseError{Err: fmt.Errorf("--watch only supports the %s output format", formatting.LogFormat)}
+			}
+
 			if recursive {
 				fmt.Println("The recursive flag has been removed and is hardcoded to true without explicitly specifying it.")
 				return nil
@@ -168,7 +213,7 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				selectedNamespace = metav1.NamespaceDefault
 			}
 
-			sa := local.NewIstiodAnalyzer(analyzers.AllCombined(),
+			sa := local.NewIstiodAnalyzer(combinedAnalyzer,
 				resource.Namespace(selectedNamespace),
 				resource.Namespace(ctx.IstioNamespace()), nil)
 
@@ -181,15 +226,7 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				}
 				// Check to see if the supplied code is valid. If not, emit a
 				// warning but continue.
-				codeIsValid := false
-				for _, at := range msg.All() {
-					if at.Code() == parts[0] {
-						codeIsValid = true
-						break
-					}
-				}
-
-				if !codeIsValid {
+				if !isKnownCode(parts[0]) {
 					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: Supplied message code '%s' is an unknown message code and will not have any effect.\n", parts[0])
 				}
 				suppressions = append(suppressions, local.AnalysisSuppression{
@@ -200,8 +237,9 @@ func Analyze(ctx cli.Context) *cobra.Command {
 			sa.SetSuppressions(suppressions)
 
 			// If we're using kube, use that as a base source.
+			var clients []*Client
 			if useKube {
-				clients, err := getClients(ctx)
+				clients, err = getClients(ctx)
 				if err != nil {
 					return err
 				}
@@ -259,7 +297,18 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				fmt.Fprintln(cmd.ErrOrStderr())
 			}
 
+			// Apply severity overrides before filtering, so that they affect both the output and the exit code
+			if len(levelOverrides) > 0 {
+				result.Messages = result.Messages.OverrideLevels(levelOverrides)
+			}
+
 			// Get messages for output
+			filterOutput := func(msgs diag.Messages) diag.Messages {
+				if len(levelOverrides) > 0 {
+					msgs = msgs.OverrideLevels(levelOverrides)
+				}
+				return msgs.SetDocRef("istioctl-analyze").FilterOutLowerThan(outputThreshold.Level)
+			}
 			outputMessages := result.Messages.SetDocRef("istioctl-analyze").FilterOutLowerThan(outputThreshold.Level)
 
 			// Print all the messages to stdout in the specified format
@@ -269,6 +318,12 @@ func Analyze(ctx cli.Context) *cobra.Command {
 			}
 			fmt.Fprintln(cmd.OutOrStdout(), output)
 
+			if fix || fixDryRun {
+				if err := applyFixes(cmd.ErrOrStderr(), outputMessages, clients, fixDryRun); err != nil {
+					return err
+				}
+			}
+
 			// An extra message on success
 			if len(outputMessages) == 0 {
 				if parseErrors == 0 {
@@ -299,10 +354,23 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				}
 			}
 
+			if watch {
+				stop := make(chan struct{})
+				go func() {
+					signals := make(chan os.Signal, 1)
+					signal.Notify(signals, os.Interrupt)
+					defer signal.Stop(signals)
+					<-signals
+					close(stop)
+				}()
+				w := newAnalysisWatcher(sa, combinedAnalyzer, result, cmd.OutOrStdout(), cmd.ErrOrStderr(), colorize, filterOutput)
+				return w.run(args, stop)
+			}
+
 			// Return code is based on the unfiltered validation message list/parse errors
 			// We're intentionally keeping failure threshold and output threshold decoupled for now
 			var returnError error
-			if msgOutputFormat == formatting.LogFormat {
+			if !isJSONorYAMLOutputFormat() {
 				returnError = errorIfMessagesExceedThreshold(result.Messages)
 				if returnError == nil && parseErrors > 0 && !ignoreUnknown {
 					returnError = FileParseError{}
@@ -326,6 +394,9 @@ func Analyze(ctx cli.Context) *cobra.Command {
 		fmt.Sprintf("The severity level of analysis at which to display messages. Valid values: %v", diag.GetAllLevelStrings()))
 	analysisCmd.PersistentFlags().StringVarP(&msgOutputFormat, "output", "o", formatting.LogFormat,
 		fmt.Sprintf("Output format: one of %v", formatting.MsgOutputFormatKeys))
+	analysisCmd.PersistentFlags().StringVar(&severityOverrides, "severity-overrides", "",
+		"Path to a YAML file mapping message codes to the level to report them at (e.g. 'IST0102: Error'). "+
+			"Overrides apply to both the output and the exit code.")
 	analysisCmd.PersistentFlags().StringVar(&meshCfgFile, "meshConfigFile", "",
 		"Overrides the mesh config values to use for analysis.")
 	analysisCmd.PersistentFlags().BoolVarP(&allNamespaces, "all-namespaces", "A", false,
@@ -342,6 +413,17 @@ func Analyze(ctx cli.Context) *cobra.Command {
 		"Don't complain about un-parseable input documents, for cases where analyze should run only on k8s compliant inputs.")
 	analysisCmd.PersistentFlags().StringVarP(&revisionSpecified, "revision", "r", "default",
 		"analyze a specific revision deployed.")
+	analysisCmd.PersistentFlags().BoolVar(&fix, "fix", false,
+		"Apply the fixes suggested for the issues found, to the files or the clusters the resources were read from. "+
+			"Comments in the modified YAML documents are not preserved.")
+	analysisCmd.PersistentFlags().BoolVar(&fixDryRun, "fix-dry-run", false,
+		"Print the fixes suggested for the issues found, without applying them.")
+	analysisCmd.PersistentFlags().BoolVar(&watch, "watch", false,
+		"Keep watching the clusters and the files after the analysis, re-running the analyzers affected by changes and "+
+			"printing the issues added (+) or resolved (-).")
+	analysisCmd.PersistentFlags().BoolVar(&optionalAnalyzers, "optional-analyzers", false,
+		"Also run the analyzers which are not run by default as they are expensive, such as envoyfilter.DryRunAnalyzer, "+
+			"which generates the proxy configuration of the workloads selected by each EnvoyFilter.")
 	analysisCmd.PersistentFlags().StringArrayVar(&remoteContexts, "remote-contexts", []string{},
 		`Kubernetes configuration contexts for remote clusters to be used in multi-cluster analysis. Not to be confused with '--context'. `+
 			"If unspecified, contexts are read from the remote secrets in the cluster.")
@@ -355,7 +437,7 @@ func gatherFiles(cmd *cobra.Command, args []string) ([]local.ReaderSource, error
 
 		// Handle "-" as stdin as a special case.
 		if f == "-" {
-			if isatty.IsTerminal(os.Stdin.Fd()) && !isJSONorYAMLOutputFormat() {
+			if isatty.IsTerminal(os.Stdin.Fd()) && !isStructuredOutputFormat() {
 				fmt.Fprint(cmd.OutOrStdout(), "Reading from stdin:\n")
 			}
 			r = os.Stdin
@@ -493,6 +575,20 @@ func isJSONorYAMLOutputFormat() bool {
 	return msgOutputFormat == formatting.JSONFormat || msgOutputFormat == formatting.YAMLFormat
 }
 
+// isStructuredOutputFormat returns true if the output is meant to be consumed by tools, rather than read.
+func isStructuredOutputFormat() bool {
+	return msgOutputFormat != formatting.LogFormat
+}
+
+func isKnownCode(code string) bool {
+	for _, at := range msg.All() {
+		if at.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
 type Client struct {
 	client kube.Client
 	remote bool
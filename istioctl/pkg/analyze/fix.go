@@ -0,0 +1,247 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pilot/pkg/config/file/util/kubeyaml"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/analysis/diag"
+	legacykube "istio.io/istio/pkg/config/analysis/legacy/source/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/kube"
+)
+
+// defaultCluster is the cluster of the resources read from the primary cluster, and of remote clusters without ID.
+const defaultCluster cluster.ID = "default"
+
+// fixer applies the fixes of analysis messages to the files or clusters the resources were read from.
+type fixer struct {
+	out     io.Writer
+	dryRun  bool
+	clients map[cluster.ID]kube.Client
+
+	files     map[string]*fixFile
+	fileOrder []string
+}
+
+// fixFile is a file containing resources to fix, split in YAML documents.
+type fixFile struct {
+	leadingSeparator bool
+	docs             []fixDoc
+	modified         bool
+}
+
+type fixDoc struct {
+	// line is the first line of the document in the file, as recorded in the origin of the resources.
+	line    int
+	content []byte
+}
+
+func newFixer(out io.Writer, dryRun bool, clients []*Client) *fixer {
+	f := &fixer{
+		out:     out,
+		dryRun:  dryRun,
+		clients: map[cluster.ID]kube.Client{},
+		files:   map[string]*fixFile{},
+	}
+	for _, c := range clients {
+		id := defaultCluster
+		if c.remote && c.client.ClusterID() != "" {
+			id = c.client.ClusterID()
+		}
+		f.clients[id] = c.client
+	}
+	return f
+}
+
+// applyFixes applies the fixes of the messages. Fixes which cannot be applied are reported, and do not prevent the
+// others from being applied.
+func applyFixes(out io.Writer, messages diag.Messages, clients []*Client, dryRun bool) error {
+	f := newFixer(out, dryRun, clients)
+	failed := 0
+	for _, m := range messages {
+		for _, fix := range m.Fixes {
+			if err := f.apply(m, fix); err != nil {
+				fmt.Fprintf(out, "Failed to fix %s on %s: %v\n", m.Type.Code(), m.Resource.Origin.FriendlyName(), err)
+				failed++
+			}
+		}
+	}
+	if err := f.writeFiles(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d fixes could not be applied", failed)
+	}
+	return nil
+}
+
+func (f *fixer) apply(m diag.Message, fix diag.Fix) error {
+	patch, err := json.Marshal(fix.Patch)
+	if err != nil {
+		return err
+	}
+	verb := "Fixing"
+	if f.dryRun {
+		verb = "Would fix"
+	}
+	fmt.Fprintf(f.out, "%s %s on %s: %s\n", verb, m.Type.Code(), m.Resource.Origin.FriendlyName(), fix.Description)
+	if f.dryRun {
+		indented := &bytes.Buffer{}
+		if err := json.Indent(indented, patch, "  ", "  "); err != nil {
+			return err
+		}
+		fmt.Fprintf(f.out, "  %s\n", indented.String())
+	}
+
+	if pos, ok := m.Resource.Origin.Reference().(*legacykube.Position); ok && pos.Filename != "" {
+		return f.applyToFile(pos, patch)
+	}
+	return f.applyToCluster(m.Resource, patch)
+}
+
+func (f *fixer) applyToFile(pos *legacykube.Position, patch []byte) error {
+	if pos.Filename == "-" {
+		return fmt.Errorf("resources read from stdin cannot be fixed")
+	}
+	file, err := f.loadFile(pos.Filename)
+	if err != nil {
+		return err
+	}
+	for i, doc := range file.docs {
+		if doc.line != pos.Line {
+			continue
+		}
+		js, err := yaml.YAMLToJSON(doc.content)
+		if err != nil {
+			return err
+		}
+		patched, err := applyPatch(js, patch)
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(pos.Filename) == ".json" {
+			out := &bytes.Buffer{}
+			if err := json.Indent(out, patched, "", "  "); err != nil {
+				return err
+			}
+			out.WriteString("\n")
+			file.docs[i].content = out.Bytes()
+		} else if file.docs[i].content, err = yaml.JSONToYAML(patched); err != nil {
+			return err
+		}
+		file.modified = true
+		return nil
+	}
+	return fmt.Errorf("resource not found at line %d of %s", pos.Line, pos.Filename)
+}
+
+func (f *fixer) loadFile(name string) (*fixFile, error) {
+	if file, ok := f.files[name]; ok {
+		return file, nil
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	file := &fixFile{leadingSeparator: bytes.HasPrefix(content, []byte("---"))}
+	reader := kubeyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		doc, line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		file.docs = append(file.docs, fixDoc{line: line, content: doc})
+	}
+	f.files[name] = file
+	f.fileOrder = append(f.fileOrder, name)
+	return file, nil
+}
+
+func (f *fixer) writeFiles() error {
+	if f.dryRun {
+		return nil
+	}
+	for _, name := range f.fileOrder {
+		file := f.files[name]
+		if !file.modified {
+			continue
+		}
+		parts := make([][]byte, 0, len(file.docs))
+		for _, doc := range file.docs {
+			parts = append(parts, doc.content)
+		}
+		content := kubeyaml.Join(parts...)
+		if file.leadingSeparator {
+			content = append([]byte("---\n"), content...)
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, content, info.Mode()); err != nil {
+			return err
+		}
+		fmt.Fprintf(f.out, "Updated %s\n", name)
+	}
+	return nil
+}
+
+func (f *fixer) applyToCluster(r *resource.Instance, patch []byte) error {
+	origin, ok := r.Origin.(*legacykube.Origin)
+	if !ok || r.Metadata.Schema == nil {
+		return fmt.Errorf("the resource was not read from a file or a cluster")
+	}
+	id := origin.Cluster
+	if id == "" {
+		id = defaultCluster
+	}
+	client, ok := f.clients[id]
+	if !ok {
+		return fmt.Errorf("no client for cluster %s", id)
+	}
+	if f.dryRun {
+		return nil
+	}
+	gvr := r.Metadata.Schema.GroupVersionResource()
+	_, err := client.Dynamic().Resource(gvr).Namespace(r.Metadata.FullName.Namespace.String()).
+		Patch(context.TODO(), r.Metadata.FullName.Name.String(), types.JSONPatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func applyPatch(doc, patch []byte) ([]byte, error) {
+	p, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	return p.Apply(doc)
+}
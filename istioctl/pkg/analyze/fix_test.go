@@ -0,0 +1,75 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/pkg/config/analysis/analyzers/service"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/file"
+)
+
+func analyzeFile(t *testing.T, name string) diag.Messages {
+	t.Helper()
+	sa := local.NewSourceAnalyzer(analysis.Combine("fix",
+		&gateway.ConflictingGatewayAnalyzer{},
+		&injection.Analyzer{},
+		&service.PortNameAnalyzer{},
+	), "", "istio-system", nil)
+	f, err := os.Open(name)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, sa.AddTestReaderKubeSource([]local.ReaderSource{{Name: name, Reader: f}}))
+	result, err := sa.Analyze(make(chan struct{}))
+	assert.NoError(t, err)
+	return result.Messages
+}
+
+func TestApplyFixes(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "input.yaml")
+	input := file.AsStringOrFail(t, "testdata/fix/input.yaml")
+	assert.NoError(t, os.WriteFile(name, []byte(input), 0o644))
+
+	messages := analyzeFile(t, name)
+	out := &bytes.Buffer{}
+	assert.NoError(t, applyFixes(out, messages, nil, true))
+	assert.Equal(t, file.AsStringOrFail(t, name), input)
+	assert.Equal(t, strings.Count(out.String(), "Would fix"), 3)
+
+	out.Reset()
+	assert.NoError(t, applyFixes(out, messages, nil, false))
+	util.CompareContent(t, file.AsBytesOrFail(t, name), "testdata/fix/fixed.yaml")
+
+	// The fixed issues are not found anymore.
+	for _, m := range analyzeFile(t, name) {
+		assert.Equal(t, len(m.Fixes), 0)
+	}
+
+	// Fixes are not applied twice.
+	out.Reset()
+	assert.Equal(t, applyFixes(out, messages, nil, false) != nil, true)
+	util.CompareContent(t, file.AsBytesOrFail(t, name), "testdata/fix/fixed.yaml")
+}
@@ -0,0 +1,297 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"istio.io/istio/istioctl/pkg/util/formatting"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/filewatcher"
+	"istio.io/istio/pkg/util/concurrent"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	watchDebounceMin = 500 * time.Millisecond
+	watchDebounceMax = 5 * time.Second
+)
+
+// analysisWatcher re-runs the analyzers whose inputs changed, and prints the messages added or resolved since the
+// previous analysis.
+type analysisWatcher struct {
+	sa *local.IstiodAnalyzer
+	// inputs are the kinds read by the analyzers.
+	inputs   []config.GroupVersionKind
+	out      io.Writer
+	errOut   io.Writer
+	colorize bool
+	// filter selects the messages to print, as done for the initial analysis.
+	filter func(diag.Messages) diag.Messages
+
+	// mu serializes the reloads of the files with the analysis, which reads the files loaded.
+	mu sync.Mutex
+	// messages are the messages printed so far, by analyzer.
+	messages map[string]diag.Messages
+	// files are the files found in the watched directories. It is only accessed by the goroutine watching them, once
+	// the directories are added.
+	files sets.String
+}
+
+func newAnalysisWatcher(sa *local.IstiodAnalyzer, analyzer analysis.CombinedAnalyzer, result local.AnalysisResult, out, errOut io.Writer,
+	colorize bool, filter func(diag.Messages) diag.Messages,
+) *analysisWatcher {
+	w := &analysisWatcher{
+		sa:       sa,
+		inputs:   analyzer.Metadata().Inputs,
+		out:      out,
+		errOut:   errOut,
+		colorize: colorize,
+		filter:   filter,
+		messages: map[string]diag.Messages{},
+		files:    sets.New[string](),
+	}
+	for name, msgs := range result.MappedMessages {
+		w.messages[name] = filter(msgs)
+	}
+	return w
+}
+
+// run watches the clusters and the given files and directories until stop is closed.
+func (w *analysisWatcher) run(paths []string, stop <-chan struct{}) error {
+	kinds := make(chan config.GroupVersionKind, 100)
+	for _, k := range w.inputs {
+		w.sa.RegisterEventHandler(k, func(old config.Config, cur config.Config, _ model.Event) {
+			kind := cur.GroupVersionKind
+			if (kind == config.GroupVersionKind{}) {
+				kind = old.GroupVersionKind
+			}
+			kinds <- kind
+		})
+	}
+
+	fw := filewatcher.NewWatcher()
+	defer fw.Close()
+	var dw *fsnotify.Watcher
+	for _, f := range paths {
+		if f == "-" {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if dw == nil {
+				if dw, err = fsnotify.NewWatcher(); err != nil {
+					return fmt.Errorf("failed to watch %s: %v", f, err)
+				}
+				defer dw.Close()
+			}
+			if err := w.addDirectory(dw, f, false); err != nil {
+				return fmt.Errorf("failed to watch %s: %v", f, err)
+			}
+			continue
+		}
+		if err := fw.Add(f); err != nil {
+			return fmt.Errorf("failed to watch %s: %v", f, err)
+		}
+		go w.watchFile(f, fw.Events(f), fw.Errors(f), stop)
+	}
+	if dw != nil {
+		go w.watchDirectories(dw, stop)
+	}
+
+	fmt.Fprintln(w.errOut, "Watching for changes, press Ctrl+C to stop.")
+	db := concurrent.Debouncer[config.GroupVersionKind]{}
+	db.Run(kinds, stop, watchDebounceMin, watchDebounceMax, func(changed sets.Set[config.GroupVersionKind]) {
+		if err := w.reanalyze(changed, stop); err != nil {
+			fmt.Fprintf(w.errOut, "Analysis failed: %v\n", err)
+		}
+	})
+	return nil
+}
+
+// watchFile reloads the resources of a file whenever it changes. The event handlers registered on the analyzer are
+// notified of the resources changed by the reload.
+func (w *analysisWatcher) watchFile(name string, events chan fsnotify.Event, errs chan error, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case err := <-errs:
+			fmt.Fprintf(w.errOut, "Error watching %s: %v\n", name, err)
+		case <-events:
+			w.reloadFile(name)
+		}
+	}
+}
+
+// addDirectory watches the directory and its subdirectories, as fsnotify does not watch directories recursively. If load
+// is set, the files found are loaded, for directories created after the analysis.
+func (w *analysisWatcher) addDirectory(dw *fsnotify.Watcher, dir string, load bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return dw.Add(path)
+		}
+		if isValidFile(path) {
+			w.files.Insert(path)
+			if load {
+				w.reloadFile(path)
+			}
+		}
+		return nil
+	})
+}
+
+// watchDirectories reloads the files of the watched directories whenever they are created, changed or removed, and
+// watches the directories created in them.
+func (w *analysisWatcher) watchDirectories(dw *fsnotify.Watcher, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case err := <-dw.Errors:
+			fmt.Fprintf(w.errOut, "Error watching directories: %v\n", err)
+		case ev := <-dw.Events:
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					if err := w.addDirectory(dw, ev.Name, true); err != nil {
+						fmt.Fprintf(w.errOut, "Error watching %s: %v\n", ev.Name, err)
+					}
+					continue
+				}
+			}
+			if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				w.removeDirectory(ev.Name)
+			}
+			if isValidFile(ev.Name) {
+				w.files.Insert(ev.Name)
+				w.reloadFile(ev.Name)
+			}
+		}
+	}
+}
+
+// removeDirectory removes the resources of the files loaded from a directory which was removed or moved away. Nothing is
+// removed if the path was not a loaded directory.
+func (w *analysisWatcher) removeDirectory(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for name := range w.files {
+		if strings.HasPrefix(name, prefix) {
+			w.mu.Lock()
+			w.sa.RemoveReaderKubeSource(name)
+			w.mu.Unlock()
+			w.files.Delete(name)
+		}
+	}
+}
+
+func (w *analysisWatcher) reloadFile(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	content, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		w.sa.RemoveReaderKubeSource(name)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(w.errOut, "Error reading %s: %v\n", name, err)
+		return
+	}
+	if err := w.sa.AddReaderKubeSource([]local.ReaderSource{{Name: name, Reader: bytes.NewReader(content)}}); err != nil {
+		fmt.Fprintf(w.errOut, "Error(s) adding file %s: %v\n", name, err)
+	}
+}
+
+// reanalyze runs the analyzers depending on the changed kinds, and prints the messages they added or resolved.
+func (w *analysisWatcher) reanalyze(changed sets.Set[config.GroupVersionKind], stop <-chan struct{}) error {
+	w.mu.Lock()
+	result, err := w.sa.ReAnalyzeSubset(changed, stop)
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	var added, resolved diag.Messages
+	for _, name := range result.ExecutedAnalyzers {
+		msgs := w.filter(result.MappedMessages[name])
+		a, r := diffMessages(w.messages[name], msgs)
+		added = append(added, a...)
+		resolved = append(resolved, r...)
+		w.messages[name] = msgs
+	}
+	added = added.SortedDedupedCopy()
+	resolved = resolved.SortedDedupedCopy()
+	w.print("-", resolved)
+	w.print("+", added)
+	return nil
+}
+
+func (w *analysisWatcher) print(prefix string, msgs diag.Messages) {
+	if len(msgs) == 0 {
+		return
+	}
+	output, _ := formatting.Print(msgs, formatting.LogFormat, w.colorize)
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Fprintf(w.out, "%s %s\n", prefix, line)
+	}
+}
+
+// diffMessages returns the messages of cur not in prev, and the messages of prev not in cur. Messages are compared
+// without their line, which changes when unrelated parts of a file are modified.
+func diffMessages(prev, cur diag.Messages) (added, resolved diag.Messages) {
+	prevKeys := sets.New[string]()
+	for _, m := range prev {
+		prevKeys.Insert(messageKey(m))
+	}
+	curKeys := sets.New[string]()
+	for _, m := range cur {
+		curKeys.Insert(messageKey(m))
+		if !prevKeys.Contains(messageKey(m)) {
+			added = append(added, m)
+		}
+	}
+	for _, m := range prev {
+		if !curKeys.Contains(messageKey(m)) {
+			resolved = append(resolved, m)
+		}
+	}
+	return added, resolved
+}
+
+func messageKey(m diag.Message) string {
+	resource := ""
+	if m.Resource != nil {
+		resource = m.Resource.Origin.FriendlyName()
+	}
+	return fmt.Sprintf("%s/%s/%s", m.Type.Code(), resource, fmt.Sprintf(m.Type.Template(), m.Parameters...))
+}
@@ -0,0 +1,158 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/local"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	watchVirtualService = `apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - reviews
+  http:
+  - route:
+    - destination:
+        host: reviews
+`
+	watchService = `apiVersion: v1
+kind: Service
+metadata:
+  name: reviews
+  namespace: default
+spec:
+  ports:
+  - name: http
+    port: 80
+`
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// take returns the content written so far, and resets the buffer.
+func (b *syncBuffer) take() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.buf.Reset()
+	return b.buf.String()
+}
+
+// startWatch analyzes the file and watches the given paths, returning the output of the watcher.
+func startWatch(t *testing.T, name string, paths []string) *syncBuffer {
+	analyzer := analysis.Combine("watch", &virtualservice.DestinationHostAnalyzer{})
+	sa := local.NewSourceAnalyzer(analyzer, "", "istio-system", nil)
+	f, err := os.Open(name)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, sa.AddTestReaderKubeSource([]local.ReaderSource{{Name: name, Reader: f}}))
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	result, err := sa.Analyze(stop)
+	assert.NoError(t, err)
+	assert.Equal(t, len(result.Messages), 1)
+
+	out, errOut := &syncBuffer{}, &syncBuffer{}
+	w := newAnalysisWatcher(sa, analyzer, result, out, errOut, false, func(msgs diag.Messages) diag.Messages { return msgs })
+	go func() {
+		_ = w.run(paths, stop)
+	}()
+	retry.UntilOrFail(t, func() bool {
+		return strings.Contains(errOut.take(), "Watching for changes")
+	}, retry.Timeout(10*time.Second))
+	return out
+}
+
+// waitForOutput waits until the watcher prints the IST0101 message with the prefix.
+func waitForOutput(t *testing.T, out *syncBuffer, prefix string) {
+	t.Helper()
+	var lines []string
+	retry.UntilSuccessOrFail(t, func() error {
+		lines = append(lines, strings.Split(strings.TrimSpace(out.take()), "\n")...)
+		for _, l := range lines {
+			if strings.HasPrefix(l, prefix) && strings.Contains(l, "[IST0101]") {
+				return nil
+			}
+		}
+		return fmt.Errorf("output: %v", lines)
+	}, retry.Timeout(20*time.Second), retry.Delay(time.Second))
+}
+
+func TestWatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(name, []byte(watchVirtualService), 0o644))
+	out := startWatch(t, name, []string{name})
+
+	expectOutput := func(prefix string, content string) {
+		t.Helper()
+		assert.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+		waitForOutput(t, out, prefix)
+	}
+
+	// Adding the Service resolves the message.
+	expectOutput("- Error [IST0101] (VirtualService default/reviews", watchVirtualService+"---\n"+watchService)
+	// Removing it adds the message back.
+	expectOutput("+ Error [IST0101] (VirtualService default/reviews", watchVirtualService)
+}
+
+func TestWatchDirectory(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(name, []byte(watchVirtualService), 0o644))
+	out := startWatch(t, name, []string{dir})
+
+	// Files created in the directory are analyzed.
+	service := filepath.Join(dir, "service.yaml")
+	assert.NoError(t, os.WriteFile(service, []byte(watchService), 0o644))
+	waitForOutput(t, out, "- Error [IST0101] (VirtualService default/reviews")
+	assert.NoError(t, os.Remove(service))
+	waitForOutput(t, out, "+ Error [IST0101] (VirtualService default/reviews")
+
+	// So are the files of the directories created in it.
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(sub, "service.yaml"), []byte(watchService), 0o644))
+	waitForOutput(t, out, "- Error [IST0101] (VirtualService default/reviews")
+	// Moving the directory away removes its files.
+	assert.NoError(t, os.Rename(sub, filepath.Join(t.TempDir(), "sub")))
+	waitForOutput(t, out, "+ Error [IST0101] (VirtualService default/reviews")
+}
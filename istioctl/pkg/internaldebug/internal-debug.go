@@ -79,6 +79,8 @@ func HandlerForDebugErrors(kubeClient kube.CLIClient,
 func DebugCommand(ctx cli.Context) *cobra.Command {
 	var opts clioptions.ControlPlaneOptions
 	var centralOpts clioptions.CentralControlPlaneOptions
+	var outputFormat string
+	var filename string
 
 	debugCommand := &cobra.Command{
 		Use:   "internal-debug [<type>/]<name>[.<namespace>]",
@@ -93,6 +95,15 @@ By default it will use the default serviceAccount from (istio-system) namespace
   # Retrieve sync diff for a single Envoy and Istiod
   istioctl x internal-debug syncz istio-egressgateway-59585c5b9c-ndc59.istio-system
 
+  # Retrieve the recent pushes of Istiod, with their triggers and outcome
+  istioctl x internal-debug push-history
+
+  # Retrieve the recent pushes sent to a single Envoy, in JSON
+  istioctl x internal-debug push-history istio-egressgateway-59585c5b9c-ndc59.istio-system -o json
+
+  # Retrieve the proxies which would be pushed if the configs of a file were applied
+  istioctl x internal-debug push-impact -f virtual-service.yaml -n default
+
   # SECURITY OPTIONS
 
   # Retrieve syncz debug information directly from the control plane, using token security
@@ -124,8 +135,28 @@ By default it will use the default serviceAccount from (istio-system) namespace
 			var xdsRequest discovery.DiscoveryRequest
 			var namespace, serviceAccount string
 
+			resourceName := args[0]
+			switch args[0] {
+			case pushHistoryCommand:
+				if len(args) > 1 {
+					resourceName = pushHistoryResource(args[1])
+				} else {
+					resourceName = pushHistoryResource("")
+				}
+			case pushImpactCommand:
+				if filename == "" {
+					return util.CommandParseError{
+						Err: fmt.Errorf("the candidate configs are required, use --filename"),
+					}
+				}
+				candidates, err := readCandidates(c.InOrStdin(), filename)
+				if err != nil {
+					return err
+				}
+				resourceName = pushImpactResource(ctx.NamespaceOrDefault(ctx.Namespace()), candidates)
+			}
 			xdsRequest = discovery.DiscoveryRequest{
-				ResourceNames: []string{args[0]},
+				ResourceNames: []string{resourceName},
 				Node: &core.Node{
 					Id: "debug~0.0.0.0~istioctl~cluster.local",
 				},
@@ -148,6 +179,16 @@ By default it will use the default serviceAccount from (istio-system) namespace
 			if newResponse != nil {
 				return sw.PrintAll(newResponse)
 			}
+			if outputFormat != jsonOutput {
+				switch args[0] {
+				case pushHistoryCommand:
+					hw := PushHistoryWriter{Writer: c.OutOrStdout()}
+					return hw.PrintAll(xdsResponses)
+				case pushImpactCommand:
+					iw := PushImpactWriter{Writer: c.OutOrStdout()}
+					return iw.PrintAll(xdsResponses)
+				}
+			}
 
 			return sw.PrintAll(xdsResponses)
 		},
@@ -158,11 +199,20 @@ By default it will use the default serviceAccount from (istio-system) namespace
 	debugCommand.Long += "\n\n" + util.ExperimentalMsg
 	debugCommand.PersistentFlags().BoolVar(&internalDebugAllIstiod, "all", false,
 		"Send the same request to all instances of Istiod. Only applicable for in-cluster deployment.")
+	debugCommand.PersistentFlags().StringVarP(&outputFormat, "output", "o", tableOutput,
+		"Output format of push-history and push-impact: one of table|json")
+	debugCommand.PersistentFlags().StringVarP(&filename, "filename", "f", "",
+		"Candidate configs of push-impact, or - to read them from the standard input")
 	return debugCommand
 }
 
 var internalDebugAllIstiod bool
 
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
 type DebugWriter struct {
 	Writer                 io.Writer
 	Namespace              string
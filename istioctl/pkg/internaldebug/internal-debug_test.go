@@ -71,6 +71,12 @@ func TestInternalDebug(t *testing.T) {
 			args:           []string{"adsz"},
 			expectedString: "",
 		},
+		{ // case 3, push impact without candidate configs
+			args:           []string{"push-impact"},
+			noIstiod:       true,
+			expectedOutput: "Error: the candidate configs are required, use --filename\n",
+			wantException:  true,
+		},
 	}
 	multixds.GetXdsResponse = func(_ *discovery.DiscoveryRequest, _ string, _ string, _ clioptions.CentralControlPlaneOptions, _ []grpc.DialOption,
 	) (*discovery.DiscoveryResponse, error) {
@@ -0,0 +1,104 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internaldebug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+)
+
+const (
+	// pushHistoryCommand is the internal-debug type showing the push history as a table.
+	pushHistoryCommand = "push-history"
+	// maxPrintedConfigs is the number of updated configs printed for a push.
+	maxPrintedConfigs = 3
+)
+
+// pushHistoryResource returns the debug resource of the push history, of the given proxy if any.
+func pushHistoryResource(proxyID string) string {
+	if proxyID == "" {
+		return "push_history"
+	}
+	return "push_history?proxyID=" + proxyID
+}
+
+// PushHistoryWriter prints the push histories of Istiod instances as a table.
+type PushHistoryWriter struct {
+	Writer io.Writer
+}
+
+// PrintAll prints the push histories of the Istiod responses, oldest push first. Responses which are not a push
+// history, such as errors, are printed as is.
+func (s *PushHistoryWriter) PrintAll(drs map[string]*discovery.DiscoveryResponse) error {
+	w := new(tabwriter.Writer).Init(s.Writer, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISTIOD\tID\tSTART\tVERSION\tFULL\tEVENTS\tREASONS\tCONFIGS\tQUEUED\tPUSHED\tSKIPPED\tTYPES")
+	for _, id := range slices.Sort(maps.Keys(drs)) {
+		dr := drs[id]
+		istiod := multixds.CpInfo(dr).ID
+		for _, resource := range dr.Resources {
+			var records []xds.PushRecord
+			if err := json.Unmarshal(resource.Value, &records); err != nil {
+				_, _ = fmt.Fprintf(s.Writer, "%s: %s\n", istiod, strings.TrimSpace(string(resource.Value)))
+				continue
+			}
+			for _, r := range records {
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%t\t%d\t%s\t%s\t%d\t%d\t%d\t%s\n",
+					istiod, r.ID, r.Start.Format(time.RFC3339), r.Version, r.Full, r.DebouncedEvents, formatReasons(r),
+					formatConfigs(r), r.ProxiesQueued, r.ProxiesPushed, r.ProxiesSkipped, formatTypes(r))
+			}
+		}
+	}
+	return w.Flush()
+}
+
+func formatReasons(r xds.PushRecord) string {
+	reasons := make([]string, 0, len(r.Reasons))
+	for _, reason := range slices.Sort(maps.Keys(r.Reasons)) {
+		reasons = append(reasons, fmt.Sprintf("%s:%d", reason, r.Reasons[reason]))
+	}
+	return strings.Join(reasons, ",")
+}
+
+func formatConfigs(r xds.PushRecord) string {
+	if r.ConfigsUpdatedCount == 0 {
+		return "all"
+	}
+	configs := r.ConfigsUpdated[:min(len(r.ConfigsUpdated), maxPrintedConfigs)]
+	out := strings.Join(configs, ",")
+	if more := r.ConfigsUpdatedCount - len(configs); more > 0 {
+		out += fmt.Sprintf(" (+%d more)", more)
+	}
+	return out
+}
+
+func formatTypes(r xds.PushRecord) string {
+	types := make([]string, 0, len(r.Types))
+	for _, t := range slices.Sort(maps.Keys(r.Types)) {
+		stats := r.Types[t]
+		types = append(types, fmt.Sprintf("%s:%d/%dB/%s", t, stats.Resources, stats.Bytes, stats.GenerationTime.Round(time.Microsecond)))
+	}
+	return strings.Join(types, ",")
+}
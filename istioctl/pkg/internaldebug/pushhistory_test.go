@@ -0,0 +1,95 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internaldebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func debugResponse(t *testing.T, istiodID string, value []byte) *discovery.DiscoveryResponse {
+	identifier, err := json.Marshal(xds.IstioControlPlaneInstance{Component: "istiod", ID: istiodID})
+	assert.NoError(t, err)
+	return &discovery.DiscoveryResponse{
+		Resources:    []*anypb.Any{{Value: value}},
+		ControlPlane: &core.ControlPlane{Identifier: string(identifier)},
+	}
+}
+
+func TestPushHistoryWriter(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	records, err := json.Marshal([]xds.PushRecord{
+		{
+			ID:      1,
+			Version: "v1",
+			Full:    true,
+			Reasons: model.NewReasonStats(model.ConfigUpdate, model.ConfigUpdate, model.ServiceUpdate),
+			ConfigsUpdated: []string{
+				"DestinationRule/default/a", "ServiceEntry/default/b", "VirtualService/default/c", "VirtualService/default/d",
+			},
+			ConfigsUpdatedCount: 5,
+			DebouncedEvents:     3,
+			Start:               start,
+			ProxiesQueued:       3,
+			ProxiesPushed:       2,
+			ProxiesSkipped:      1,
+			Types: map[string]*xds.PushTypeStats{
+				"LDS": {Pushes: 2, Resources: 4, Bytes: 2048, GenerationTime: 1500 * time.Microsecond},
+				"CDS": {Pushes: 2, Resources: 6, Bytes: 4096, GenerationTime: 2 * time.Millisecond},
+			},
+		},
+		{
+			ID:              2,
+			Version:         "v2",
+			Full:            true,
+			Reasons:         model.NewReasonStats(model.GlobalUpdate),
+			DebouncedEvents: 1,
+			Start:           start.Add(time.Second),
+			ProxiesQueued:   3,
+		},
+	})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	w := PushHistoryWriter{Writer: &out}
+	assert.NoError(t, w.PrintAll(map[string]*discovery.DiscoveryResponse{
+		"istiod2": debugResponse(t, "istiod2", []byte("Proxy not connected to this Pilot instance.\n")),
+		"istiod1": debugResponse(t, "istiod1", records),
+	}))
+	// Columns are aligned by the tabwriter, so lines are compared by their fields.
+	got := slices.Map(strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"), strings.Fields)
+	assert.Equal(t, got, [][]string{
+		{"istiod2:", "Proxy", "not", "connected", "to", "this", "Pilot", "instance."},
+		{"ISTIOD", "ID", "START", "VERSION", "FULL", "EVENTS", "REASONS", "CONFIGS", "QUEUED", "PUSHED", "SKIPPED", "TYPES"},
+		{
+			"istiod1", "1", "2024-01-02T03:04:05Z", "v1", "true", "3", "config:2,service:1",
+			"DestinationRule/default/a,ServiceEntry/default/b,VirtualService/default/c", "(+2", "more)",
+			"3", "2", "1", "CDS:6/4096B/2ms,LDS:4/2048B/1.5ms",
+		},
+		{"istiod1", "2", "2024-01-02T03:04:06Z", "v2", "true", "1", "global:1", "all", "3", "0", "0"},
+	})
+}
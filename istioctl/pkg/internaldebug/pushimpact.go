@@ -0,0 +1,96 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internaldebug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+)
+
+// pushImpactCommand is the internal-debug type showing the proxies which would be pushed for candidate configs.
+const pushImpactCommand = "push-impact"
+
+// pushImpactResource returns the debug resource of the push impact of the candidate configs, created in the
+// namespace if they have none.
+func pushImpactResource(namespace, candidates string) string {
+	return "push_impact?" + url.Values{"namespace": {namespace}, "config": {candidates}}.Encode()
+}
+
+// readCandidates reads the candidate configs from the file, or from in if the file is "-".
+func readCandidates(in io.Reader, filename string) (string, error) {
+	var b []byte
+	var err error
+	if filename == "-" {
+		b, err = io.ReadAll(in)
+	} else {
+		b, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the candidate configs: %v", err)
+	}
+	return string(b), nil
+}
+
+// PushImpactWriter prints the push impacts computed by Istiod instances as a table.
+type PushImpactWriter struct {
+	Writer io.Writer
+}
+
+// PrintAll prints the proxies which would be pushed by each Istiod, followed by a summary. Responses which are not a
+// push impact, such as errors, are printed as is.
+func (s *PushImpactWriter) PrintAll(drs map[string]*discovery.DiscoveryResponse) error {
+	var summaries []string
+	w := new(tabwriter.Writer).Init(s.Writer, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISTIOD\tPROXY\tTYPES")
+	for _, id := range slices.Sort(maps.Keys(drs)) {
+		dr := drs[id]
+		istiod := multixds.CpInfo(dr).ID
+		for _, resource := range dr.Resources {
+			var impact xds.PushImpact
+			if err := json.Unmarshal(resource.Value, &impact); err != nil {
+				_, _ = fmt.Fprintf(s.Writer, "%s: %s\n", istiod, strings.TrimSpace(string(resource.Value)))
+				continue
+			}
+			for _, p := range impact.Proxies {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", istiod, p.ID, strings.Join(p.Types, ","))
+			}
+			summary := fmt.Sprintf("%s: %d proxies would be pushed and %d skipped for %s", istiod,
+				len(impact.Proxies), impact.ProxiesSkipped, strings.Join(impact.ConfigsUpdated, ","))
+			if len(impact.Ignored) > 0 {
+				summary += fmt.Sprintf(" (ignored %s)", strings.Join(impact.Ignored, ","))
+			}
+			summaries = append(summaries, summary)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, summary := range summaries {
+		_, _ = fmt.Fprintln(s.Writer, summary)
+	}
+	return nil
+}
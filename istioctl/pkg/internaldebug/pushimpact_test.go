@@ -0,0 +1,60 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internaldebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestPushImpactResource(t *testing.T) {
+	candidates := "kind: VirtualService\nmetadata:\n  name: a&b=c\n"
+	u, err := url.Parse("/debug/" + pushImpactResource("default", candidates))
+	assert.NoError(t, err)
+	assert.Equal(t, u.Path, "/debug/push_impact")
+	assert.Equal(t, u.Query().Get("namespace"), "default")
+	assert.Equal(t, u.Query().Get("config"), candidates)
+}
+
+func TestPushImpactWriter(t *testing.T) {
+	impact, err := json.Marshal(xds.PushImpact{
+		ConfigsUpdated: []string{"VirtualService/default/reviews"},
+		Proxies: []xds.ProxyPushImpact{
+			{ID: "productpage-v1-1.default-1", Types: []string{"CDS", "LDS", "RDS"}},
+			{ID: "reviews-v1-1.default-2", Types: []string{"RDS"}},
+		},
+		ProxiesSkipped: 3,
+		Ignored:        []string{"ConfigMap/settings"},
+	})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	w := PushImpactWriter{Writer: &out}
+	assert.NoError(t, w.PrintAll(map[string]*discovery.DiscoveryResponse{
+		"istiod1": debugResponse(t, "istiod1", impact),
+	}))
+	assert.Equal(t, out.String(), `ISTIOD    PROXY                        TYPES
+istiod1   productpage-v1-1.default-1   CDS,LDS,RDS
+istiod1   reviews-v1-1.default-2       RDS
+istiod1: 2 proxies would be pushed and 3 skipped for VirtualService/default/reviews (ignored ConfigMap/settings)
+`)
+}
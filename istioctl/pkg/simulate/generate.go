@@ -0,0 +1,116 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	configaggregate "istio.io/istio/pilot/pkg/config/aggregate"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/kube/gateway"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/version"
+)
+
+// newGenerator returns a generator of the configuration of proxies from the inputs, with the Kubernetes and
+// ServiceEntry service registries and the Gateway API controller of istiod running against an in-memory copy of them.
+func newGenerator(in *inputs) (*simulation.Generator, error) {
+	configs, _, err := crd.ParseInputs(strings.Join(in.configs, "\n---\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	now := time.Now()
+	for i := range configs {
+		if configs[i].Namespace == "" {
+			configs[i].Namespace = "default"
+		}
+		if configs[i].CreationTimestamp.IsZero() {
+			configs[i].CreationTimestamp = now
+		}
+	}
+
+	client := kube.NewFakeClient(in.kubeObjects...)
+	store := memory.NewSyncController(memory.MakeSkipValidation(collections.PilotGatewayAPI()))
+	gwc := gateway.NewController(client, store, func(schema.GroupVersionResource, <-chan struct{}) bool {
+		return true
+	}, nil, kubecontroller.Options{DomainSuffix: constants.DefaultClusterLocalDomain})
+	configStore, err := configaggregate.MakeWriteableCache([]model.ConfigStoreController{store, gwc}, store)
+	if err != nil {
+		return nil, err
+	}
+
+	g := simulation.NewGenerator(simulation.GeneratorOptions{
+		ClusterID:            constants.DefaultClusterName,
+		ConfigStore:          configStore,
+		GatewayAPIController: gwc,
+	})
+	env := g.Env
+	kubeRegistry := kubecontroller.NewController(client, kubecontroller.Options{
+		DomainSuffix:          constants.DefaultClusterLocalDomain,
+		XDSUpdater:            g.XDSUpdater,
+		Metrics:               env,
+		MeshNetworksWatcher:   env.NetworksWatcher,
+		MeshWatcher:           env.Watcher,
+		ClusterID:             constants.DefaultClusterName,
+		MeshServiceController: g.Discovery,
+		ConfigCluster:         true,
+	})
+	g.ServiceEntry.AppendWorkloadHandler(kubeRegistry.WorkloadInstanceHandler)
+	kubeRegistry.AppendWorkloadHandler(g.ServiceEntry.WorkloadInstanceHandler)
+	g.Discovery.AddRegistry(kubeRegistry)
+
+	g.Run()
+	client.RunAndWait(g.Stop())
+	for _, cfg := range configs {
+		if _, err := store.Create(cfg); err != nil {
+			g.Close()
+			return nil, fmt.Errorf("failed to create config %s/%s: %v", cfg.Namespace, cfg.Name, err)
+		}
+	}
+	if err := g.Sync(); err != nil {
+		g.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// setupProxy fills in the defaults of the proxy and initializes it for the push context.
+func setupProxy(g *simulation.Generator, proxy *model.Proxy, push *model.PushContext) *model.Proxy {
+	if proxy.Metadata.Namespace == "" {
+		proxy.Metadata.Namespace = proxy.ConfigNamespace
+	}
+	if proxy.Metadata.IstioVersion == "" {
+		proxy.Metadata.IstioVersion = version.Info.Version
+	}
+	proxy.IstioVersion = model.ParseIstioVersion(proxy.Metadata.IstioVersion)
+	if proxy.DNSDomain == "" {
+		proxy.DNSDomain = proxy.ConfigNamespace + ".svc." + constants.DefaultClusterLocalDomain
+	}
+	if len(proxy.IPAddresses) == 0 {
+		proxy.IPAddresses = []string{"1.1.1.1"}
+	}
+	g.InitProxy(proxy, push)
+	return proxy
+}
@@ -0,0 +1,524 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbachttp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	rbactcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/resource"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/wellknown"
+)
+
+type options struct {
+	files     []string
+	pod       string
+	labels    map[string]string
+	proxyType string
+	output    string
+
+	host     string
+	address  string
+	port     int
+	path     string
+	headers  []string
+	protocol string
+	tls      string
+	sni      string
+	mode     string
+}
+
+// Report is the outcome of a simulated request.
+type Report struct {
+	Proxy              string   `json:"proxy"`
+	Listener           string   `json:"listener,omitempty"`
+	FilterChain        string   `json:"filterChain,omitempty"`
+	RouteConfiguration string   `json:"routeConfiguration,omitempty"`
+	VirtualHost        string   `json:"virtualHost,omitempty"`
+	Route              string   `json:"route,omitempty"`
+	Cluster            string   `json:"cluster,omitempty"`
+	RBAC               []string `json:"rbac,omitempty"`
+	Error              string   `json:"error,omitempty"`
+}
+
+func Cmd(ctx cli.Context) *cobra.Command {
+	o := options{}
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate a request against the configuration generated for a workload",
+		Long: `Simulate generates the Envoy configuration for a source workload, either from a set of local
+Istio and Kubernetes YAML files or from the current cluster, and walks it the way Envoy would for the
+described request. The matched listener, filter chain, route, cluster and any RBAC policies or TLS
+errors are reported. Nothing is deployed and no proxy is contacted.`,
+		Example: `  # Simulate an HTTP request from a pod defined in local files
+  istioctl x simulate -f ./manifests --pod sleep-1234.default --host reviews.default.svc.cluster.local --port 9080 --path /reviews
+
+  # Simulate a request through a gateway from the live cluster
+  istioctl x simulate --pod istio-ingressgateway-5c6d8f7b9-abcde.istio-system --type router --host bookinfo.example.com --port 8080
+
+  # Simulate a TCP connection from a workload selected by labels
+  istioctl x simulate -f ./manifests -l app=sleep -n default --host db.default.svc.cluster.local --port 3306 --protocol tcp`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("simulate takes no positional arguments")
+			}
+			if o.pod == "" && len(o.labels) == 0 {
+				return fmt.Errorf("one of --pod or --labels must be specified")
+			}
+			if o.port == 0 {
+				return fmt.Errorf("--port must be specified")
+			}
+			if o.output != "short" && o.output != "json" {
+				return fmt.Errorf("unknown output format %q, must be one of short|json", o.output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, err := o.loadInputs(ctx)
+			if err != nil {
+				return err
+			}
+			report, err := o.run(ctx.NamespaceOrDefault(ctx.Namespace()), in)
+			if err != nil {
+				return err
+			}
+			return o.print(cmd.OutOrStdout(), report)
+		},
+	}
+	cmd.Flags().StringSliceVarP(&o.files, "filename", "f", nil,
+		"Istio and Kubernetes YAML files or directories to simulate against. If unset, the current cluster is used")
+	cmd.Flags().StringVar(&o.pod, "pod", "", "Source pod, in the form <name>[.<namespace>]")
+	cmd.Flags().StringToStringVarP(&o.labels, "labels", "l", nil,
+		"Labels of the source workload, used instead of --pod to describe a workload that does not exist yet")
+	cmd.Flags().StringVar(&o.proxyType, "type", string(model.SidecarProxy), "Proxy type of the source workload, one of sidecar|router")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "short", "Output format: one of short|json")
+	cmd.Flags().StringVar(&o.host, "host", "", "Host header (or SNI, for TLS) of the request")
+	cmd.Flags().StringVar(&o.address, "address", "",
+		"Destination IP of the request. Defaults to the address of the service matching --host")
+	cmd.Flags().IntVar(&o.port, "port", 0, "Destination port of the request")
+	cmd.Flags().StringVar(&o.path, "path", "/", "Path of the request")
+	cmd.Flags().StringArrayVarP(&o.headers, "header", "H", nil, "Request header in the form <name>:<value>. May be repeated")
+	cmd.Flags().StringVar(&o.protocol, "protocol", string(simulation.HTTP), "Protocol of the request, one of http|http2|tcp")
+	cmd.Flags().StringVar(&o.tls, "tls", string(simulation.Plaintext), "TLS mode of the request, one of plaintext|tls|mtls")
+	cmd.Flags().StringVar(&o.sni, "sni", "", "SNI of the request. Defaults to --host for TLS requests")
+	cmd.Flags().StringVar(&o.mode, "mode", "",
+		"How the request reaches the proxy, one of outbound|inbound|gateway. Defaults to gateway for routers and outbound otherwise")
+	return cmd
+}
+
+// inputs holds the state that will be used to build the simulated control plane.
+type inputs struct {
+	configs     []string
+	kubeObjects []runtime.Object
+}
+
+func (o *options) loadInputs(ctx cli.Context) (*inputs, error) {
+	if len(o.files) > 0 {
+		return loadFiles(o.files)
+	}
+	return loadCluster(ctx)
+}
+
+func loadFiles(paths []string) (*inputs, error) {
+	in := &inputs{}
+	for _, p := range paths {
+		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			switch filepath.Ext(path) {
+			case ".yaml", ".yml", ".json":
+			default:
+				return nil
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := in.addDocuments(b); err != nil {
+				return fmt.Errorf("failed to read %v: %v", path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+func loadCluster(ctx cli.Context) (*inputs, error) {
+	client, err := ctx.CLIClient()
+	if err != nil {
+		return nil, err
+	}
+	in := &inputs{}
+	c := context.Background()
+	namespaces, err := client.Kube().CoreV1().Namespaces().List(c, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range namespaces.Items {
+		in.kubeObjects = append(in.kubeObjects, &namespaces.Items[i])
+	}
+	services, err := client.Kube().CoreV1().Services(metav1.NamespaceAll).List(c, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range services.Items {
+		in.kubeObjects = append(in.kubeObjects, &services.Items[i])
+	}
+	pods, err := client.Kube().CoreV1().Pods(metav1.NamespaceAll).List(c, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		in.kubeObjects = append(in.kubeObjects, &pods.Items[i])
+	}
+	endpointSlices, err := client.Kube().DiscoveryV1().EndpointSlices(metav1.NamespaceAll).List(c, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range endpointSlices.Items {
+		in.kubeObjects = append(in.kubeObjects, &endpointSlices.Items[i])
+	}
+	for _, s := range collections.PilotGatewayAPI().All() {
+		if s.IsBuiltin() {
+			continue
+		}
+		l, err := client.Dynamic().Resource(s.GroupVersionResource()).Namespace(metav1.NamespaceAll).List(c, metav1.ListOptions{})
+		if err != nil {
+			// The CRD may not be installed; there is nothing to simulate for it.
+			continue
+		}
+		for _, u := range l.Items {
+			b, err := yaml.Marshal(u.Object)
+			if err != nil {
+				return nil, err
+			}
+			in.configs = append(in.configs, string(b))
+		}
+	}
+	return in, nil
+}
+
+// addDocuments splits a YAML stream into Istio configs, handled by the config store, and Kubernetes
+// objects, handled by the fake Kubernetes registry.
+func (in *inputs) addDocuments(b []byte) error {
+	decoder := kubeyaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 512*1024)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		gvk := u.GroupVersionKind()
+		if s, f := collections.PilotGatewayAPI().FindByGroupVersionAliasesKind(resource.FromKubernetesGVK(&gvk)); f && !s.IsBuiltin() {
+			y, err := yaml.Marshal(u.Object)
+			if err != nil {
+				return err
+			}
+			in.configs = append(in.configs, string(y))
+			continue
+		}
+		js, err := u.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		obj, _, err := kube.IstioCodec.UniversalDeserializer().Decode(js, nil, nil)
+		if err != nil {
+			// Not a type the control plane reads, such as a Deployment. Ignore it.
+			continue
+		}
+		in.kubeObjects = append(in.kubeObjects, obj)
+	}
+}
+
+func (o *options) call(proxy *model.Proxy, pc *model.PushContext) (simulation.Call, error) {
+	headers := http.Header{}
+	for _, h := range o.headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			return simulation.Call{}, fmt.Errorf("invalid header %q, expected <name>:<value>", h)
+		}
+		headers.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	mode := simulation.CallMode(o.mode)
+	if mode == "" {
+		mode = simulation.CallModeOutbound
+		if proxy.Type == model.Router {
+			mode = simulation.CallModeGateway
+		}
+	}
+	address := o.address
+	if address == "" && mode == simulation.CallModeOutbound && o.host != "" {
+		hostname := o.host
+		if h, _, err := net.SplitHostPort(hostname); err == nil {
+			hostname = h
+		}
+		if svc := pc.ServiceForHostname(proxy, host.Name(hostname)); svc != nil {
+			if addr := svc.GetAddressForProxy(proxy); addr != constants.UnspecifiedIP {
+				address = addr
+			}
+		}
+	}
+	if address == "" && mode == simulation.CallModeInbound {
+		address = proxy.IPAddresses[0]
+	}
+	return simulation.Call{
+		Address:    address,
+		Port:       o.port,
+		Path:       o.path,
+		Protocol:   simulation.Protocol(o.protocol),
+		TLS:        simulation.TLSMode(o.tls),
+		HostHeader: o.host,
+		Headers:    headers,
+		Sni:        o.sni,
+		CallMode:   mode,
+	}, nil
+}
+
+func (o *options) proxy(namespace string, in *inputs) (*model.Proxy, error) {
+	proxy := &model.Proxy{
+		Type:     model.NodeType(o.proxyType),
+		Metadata: &model.NodeMetadata{ClusterID: constants.DefaultClusterName},
+	}
+	if proxy.Type != model.SidecarProxy && proxy.Type != model.Router {
+		return nil, fmt.Errorf("unsupported proxy type %q, must be one of sidecar|router", o.proxyType)
+	}
+	if o.pod == "" {
+		proxy.ConfigNamespace = namespace
+		proxy.Labels = o.labels
+		proxy.Metadata.Labels = o.labels
+		proxy.ID = "simulated." + namespace
+		return proxy, nil
+	}
+	name, ns, _ := strings.Cut(o.pod, ".")
+	if ns == "" {
+		ns = namespace
+	}
+	for _, obj := range in.kubeObjects {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Name != name || pod.Namespace != ns {
+			continue
+		}
+		proxy.ID = name + "." + ns
+		proxy.ConfigNamespace = ns
+		proxy.Labels = pod.Labels
+		proxy.Metadata.Labels = pod.Labels
+		proxy.Metadata.ServiceAccount = pod.Spec.ServiceAccountName
+		if pod.Status.PodIP != "" {
+			proxy.IPAddresses = []string{pod.Status.PodIP}
+		}
+		return proxy, nil
+	}
+	return nil, fmt.Errorf("pod %s.%s not found", name, ns)
+}
+
+func (o *options) run(namespace string, in *inputs) (*Report, error) {
+	proxy, err := o.proxy(namespace, in)
+	if err != nil {
+		return nil, err
+	}
+	g, err := newGenerator(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate configuration: %v", err)
+	}
+	defer g.Close()
+	push, err := g.Push()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate configuration: %v", err)
+	}
+	proxy = setupProxy(g, proxy, push)
+	call, err := o.call(proxy, push)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := g.Generate(proxy, push)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate configuration: %v", err)
+	}
+	sim := simulation.NewSimulationFromResources(generated.Listeners, generated.Clusters, generated.Routes)
+	res := sim.Run(call)
+	report := &Report{
+		Proxy:              proxy.ID,
+		Listener:           res.ListenerMatched,
+		FilterChain:        res.FilterChainMatched,
+		RouteConfiguration: res.RouteConfigMatched,
+		VirtualHost:        res.VirtualHostMatched,
+		Route:              res.RouteMatched,
+		Cluster:            res.ClusterMatched,
+	}
+	if res.Error != nil {
+		report.Error = res.Error.Error()
+	}
+	if fc := matchedFilterChain(sim.Listeners, res); fc != nil {
+		if report.RBAC, err = rbacPolicies(fc); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func matchedFilterChain(listeners []*listener.Listener, res simulation.Result) *listener.FilterChain {
+	l := slices.FindFunc(listeners, func(l *listener.Listener) bool {
+		return l.GetName() == res.ListenerMatched
+	})
+	if l == nil {
+		return nil
+	}
+	if fc := slices.FindFunc((*l).GetFilterChains(), func(fc *listener.FilterChain) bool {
+		return fc.GetName() == res.FilterChainMatched
+	}); fc != nil {
+		return *fc
+	}
+	if (*l).GetDefaultFilterChain().GetName() == res.FilterChainMatched {
+		return (*l).GetDefaultFilterChain()
+	}
+	return nil
+}
+
+// rbacPolicies summarizes the RBAC filters that will evaluate the request on the matched filter chain.
+func rbacPolicies(fc *listener.FilterChain) ([]string, error) {
+	var res []string
+	for _, f := range fc.GetFilters() {
+		tc := f.GetTypedConfig()
+		switch {
+		case tc.MessageIs(&rbactcp.RBAC{}):
+			r := &rbactcp.RBAC{}
+			if err := unmarshal(tc, r); err != nil {
+				return nil, err
+			}
+			res = append(res, describeRules(f.GetName(), r.GetRules().GetAction().String(), maps.Keys(r.GetRules().GetPolicies())))
+		case f.GetName() == wellknown.HTTPConnectionManager:
+			h := &hcm.HttpConnectionManager{}
+			if err := unmarshal(tc, h); err != nil {
+				return nil, err
+			}
+			for _, f := range h.GetHttpFilters() {
+				tc, ok := f.GetConfigType().(*hcm.HttpFilter_TypedConfig)
+				if !ok || !tc.TypedConfig.MessageIs(&rbachttp.RBAC{}) {
+					continue
+				}
+				r := &rbachttp.RBAC{}
+				if err := unmarshal(tc.TypedConfig, r); err != nil {
+					return nil, err
+				}
+				if r.GetRules() == nil {
+					continue
+				}
+				res = append(res, describeRules(f.GetName(), r.GetRules().GetAction().String(), maps.Keys(r.GetRules().GetPolicies())))
+			}
+		}
+	}
+	return res, nil
+}
+
+func describeRules(filter, action string, policies []string) string {
+	if len(policies) == 0 {
+		if action == "ALLOW" {
+			return fmt.Sprintf("%s: %s (no policies, all requests are denied)", filter, action)
+		}
+		return fmt.Sprintf("%s: %s (no policies)", filter, action)
+	}
+	sort.Strings(policies)
+	return fmt.Sprintf("%s: %s %s", filter, action, strings.Join(policies, ","))
+}
+
+func unmarshal(a *anypb.Any, m proto.Message) error {
+	if err := a.UnmarshalTo(m); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %v", a.GetTypeUrl(), err)
+	}
+	return nil
+}
+
+func (o *options) print(w io.Writer, r *Report) error {
+	if o.output == "json" {
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	row := func(k, v string) {
+		if v != "" {
+			_, _ = fmt.Fprintf(tw, "%s:\t%s\n", k, v)
+		}
+	}
+	row("Proxy", r.Proxy)
+	row("Listener", r.Listener)
+	row("Filter Chain", r.FilterChain)
+	row("Route Configuration", r.RouteConfiguration)
+	row("Virtual Host", r.VirtualHost)
+	row("Route", r.Route)
+	row("Cluster", r.Cluster)
+	for i, p := range r.RBAC {
+		if i == 0 {
+			_, _ = fmt.Fprintf(tw, "RBAC:\t%s\n", p)
+		} else {
+			_, _ = fmt.Fprintf(tw, "\t%s\n", p)
+		}
+	}
+	if r.Error != "" {
+		row("Error", r.Error)
+	} else {
+		row("Result", "OK")
+	}
+	return tw.Flush()
+}
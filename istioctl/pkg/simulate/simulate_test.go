@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	in, err := loadFiles([]string{"testdata"})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		opts    options
+		want    *Report
+		wantErr string
+	}{
+		{
+			name: "header match",
+			opts: options{
+				pod: "sleep.default", host: "reviews.default.svc.cluster.local", port: 9080,
+				headers: []string{"end-user: jason"},
+			},
+			want: &Report{
+				Proxy:              "sleep.default",
+				Listener:           "0.0.0.0_9080",
+				RouteConfiguration: "9080",
+				VirtualHost:        "reviews.default.svc.cluster.local:9080",
+				Route:              "v2-for-jason",
+				Cluster:            "outbound|9080|v2|reviews.default.svc.cluster.local",
+			},
+		},
+		{
+			name: "default route",
+			opts: options{pod: "sleep.default", host: "reviews.default.svc.cluster.local", port: 9080},
+			want: &Report{
+				Proxy:              "sleep.default",
+				Listener:           "0.0.0.0_9080",
+				RouteConfiguration: "9080",
+				VirtualHost:        "reviews.default.svc.cluster.local:9080",
+				Route:              "default",
+				Cluster:            "outbound|9080|v1|reviews.default.svc.cluster.local",
+			},
+		},
+		{
+			name: "tcp",
+			opts: options{pod: "sleep.default", host: "db.default.svc.cluster.local", port: 3306, protocol: "tcp"},
+			want: &Report{
+				Proxy:    "sleep.default",
+				Listener: "10.96.0.20_3306",
+				Cluster:  "outbound|3306||db.default.svc.cluster.local",
+			},
+		},
+		{
+			name: "inbound with authorization policy",
+			opts: options{pod: "reviews-v1.default", port: 9080, mode: "inbound", tls: "mtls"},
+			want: &Report{
+				Proxy:       "reviews-v1.default",
+				Listener:    "virtualInbound",
+				FilterChain: "0.0.0.0_9080",
+				VirtualHost: "inbound|http|9080",
+				Route:       "default",
+				Cluster:     "inbound|9080||",
+				RBAC:        []string{"envoy.filters.http.rbac: ALLOW ns[default]-policy[reviews-viewer]-rule[0]"},
+			},
+		},
+		{
+			name: "inbound tls without mtls",
+			opts: options{pod: "reviews-v1.default", port: 9080, mode: "inbound", tls: "tls"},
+			want: &Report{
+				Proxy:    "reviews-v1.default",
+				Listener: "virtualInbound",
+				Error:    "no filter chains matched",
+			},
+		},
+		{
+			name:    "unknown pod",
+			opts:    options{pod: "missing.default", port: 9080},
+			wantErr: "pod missing.default not found",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.protocol == "" {
+				tt.opts.protocol = "http"
+			}
+			if tt.opts.tls == "" {
+				tt.opts.tls = "plaintext"
+			}
+			if tt.opts.path == "" {
+				tt.opts.path = "/"
+			}
+			if tt.opts.proxyType == "" {
+				tt.opts.proxyType = "sidecar"
+			}
+			got, err := tt.opts.run("default", in)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("want error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	r := &Report{
+		Proxy:    "sleep.default",
+		Listener: "0.0.0.0_9080",
+		Cluster:  "outbound|9080||reviews.default.svc.cluster.local",
+		RBAC:     []string{"a: ALLOW x", "b: DENY y"},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, (&options{output: "short"}).print(&out, r))
+	want := `Proxy:    sleep.default
+Listener: 0.0.0.0_9080
+Cluster:  outbound|9080||reviews.default.svc.cluster.local
+RBAC:     a: ALLOW x
+          b: DENY y
+Result:   OK
+`
+	assert.Equal(t, out.String(), want)
+}
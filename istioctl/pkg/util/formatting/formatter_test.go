@@ -20,6 +20,9 @@ import (
 	. "github.com/onsi/gomega"
 
 	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/legacy/source/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/url"
 )
 
@@ -151,4 +154,179 @@ func TestFormatter_PrintEmpty(t *testing.T) {
 
 	yamlOutput, _ := Print(msgs, YAMLFormat, false)
 	g.Expect(yamlOutput).To(Equal("[]\n"))
+
+	junitOutput, _ := Print(msgs, JUnitFormat, false)
+	g.Expect(junitOutput).To(ContainSubstring(`<testsuite name="istioctl analyze" tests="0" failures="0"></testsuite>`))
+}
+
+func fileResource(name, file string, line int) *resource.Instance {
+	return &resource.Instance{
+		Metadata: resource.Metadata{
+			FullName: resource.NewShortOrFullName("default", name),
+		},
+		Origin: &kube.Origin{
+			Type:     gvk.VirtualService,
+			FullName: resource.NewShortOrFullName("default", name),
+			Ref:      &kube.Position{Filename: file, Line: line},
+		},
+	}
+}
+
+func TestFormatter_PrintSARIF(t *testing.T) {
+	g := NewWithT(t)
+
+	firstMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v"),
+		fileResource("bubble", "config/bubble.yaml", 3),
+		"the bubble is too big",
+	)
+	// The line of the offending field takes precedence over the line of the resource
+	firstMsg.Line = 12
+	secondMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Info, "C1", "Collapse danger: %v"),
+		diag.MockResource("GrandCastle"),
+		"the castle is too old",
+	)
+	thirdMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v"),
+		nil,
+		"the bubble is gone",
+	)
+
+	output, err := Print(diag.Messages{firstMsg, secondMsg, thirdMsg}, SARIFFormat, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedOutput := `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "istioctl analyze",
+          "informationUri": "` + url.ConfigAnalysis + `",
+          "rules": [
+            {
+              "id": "B1",
+              "helpUri": "` + url.ConfigAnalysis + `/b1/",
+              "defaultConfiguration": {
+                "level": "error"
+              }
+            },
+            {
+              "id": "C1",
+              "helpUri": "` + url.ConfigAnalysis + `/c1/",
+              "defaultConfiguration": {
+                "level": "note"
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "B1",
+          "ruleIndex": 0,
+          "level": "error",
+          "message": {
+            "text": "Explosion accident: the bubble is too big"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "config/bubble.yaml"
+                },
+                "region": {
+                  "startLine": 12
+                }
+              },
+              "logicalLocations": [
+                {
+                  "fullyQualifiedName": "VirtualService default/bubble",
+                  "kind": "resource"
+                }
+              ]
+            }
+          ]
+        },
+        {
+          "ruleId": "C1",
+          "ruleIndex": 1,
+          "level": "note",
+          "message": {
+            "text": "Collapse danger: the castle is too old"
+          },
+          "locations": [
+            {
+              "logicalLocations": [
+                {
+                  "fullyQualifiedName": "GrandCastle",
+                  "kind": "resource"
+                }
+              ]
+            }
+          ]
+        },
+        {
+          "ruleId": "B1",
+          "ruleIndex": 0,
+          "level": "error",
+          "message": {
+            "text": "Explosion accident: the bubble is gone"
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+	g.Expect(output).To(Equal(expectedOutput))
+}
+
+func TestFormatter_PrintJUnit(t *testing.T) {
+	g := NewWithT(t)
+
+	firstMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Error, "B1", "Explosion accident: %v"),
+		fileResource("bubble", "config/bubble.yaml", 3),
+		"the bubble is too big",
+	)
+	secondMsg := diag.NewMessage(
+		diag.NewMessageType(diag.Info, "C1", "Collapse danger: %v"),
+		diag.MockResource("GrandCastle"),
+		"the castle is <old>",
+	)
+
+	output, err := Print(diag.Messages{firstMsg, secondMsg}, JUnitFormat, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expectedOutput := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="istioctl analyze" tests="2" failures="1">
+  <testsuite name="istioctl analyze" tests="2" failures="1">
+    <testcase name="[B1] (VirtualService default/bubble config/bubble.yaml:3)" classname="B1" file="config/bubble.yaml" line="3">
+      <failure message="Explosion accident: the bubble is too big" type="Error">(VirtualService default/bubble config/bubble.yaml:3) Explosion accident: the bubble is too big&#xA;See ` +
+		url.ConfigAnalysis + `/b1/ for more information.</failure>
+    </testcase>
+    <testcase name="[C1] (GrandCastle)" classname="C1">
+      <system-out>(GrandCastle) Collapse danger: the castle is &lt;old&gt;&#xA;See ` + url.ConfigAnalysis + `/c1/ for more information.</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+	g.Expect(output).To(Equal(expectedOutput))
+}
+
+func TestParseSeverityOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	overrides, err := ParseSeverityOverrides([]byte("IST0102: error\nist0118: Info\n"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(overrides).To(Equal(map[string]diag.Level{"IST0102": diag.Error, "IST0118": diag.Info}))
+
+	_, err = ParseSeverityOverrides([]byte("IST0102: Critical\n"))
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = ParseSeverityOverrides([]byte("- IST0102\n"))
+	g.Expect(err).To(HaveOccurred())
 }
@@ -0,0 +1,196 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/url"
+)
+
+// SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) types. Only the subset of the
+// format used for analysis messages is modeled.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	HelpURI              string             `json:"helpUri"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevel maps message levels to SARIF result levels.
+func sarifLevel(l diag.Level) string {
+	switch l {
+	case diag.Error:
+		return "error"
+	case diag.Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func printSARIF(ms diag.Messages) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "istioctl analyze",
+			InformationURI: url.ConfigAnalysis,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := map[string]int{}
+	for _, m := range ms {
+		code := m.Type.Code()
+		idx, ok := ruleIndex[code]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[code] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:                   code,
+				HelpURI:              documentationURL(m),
+				DefaultConfiguration: sarifConfiguration{Level: sarifLevel(m.Type.Level())},
+			})
+		}
+		result := sarifResult{
+			RuleID:    code,
+			RuleIndex: idx,
+			Level:     sarifLevel(m.Type.Level()),
+			Message:   sarifMessage{Text: fmt.Sprintf(m.Type.Template(), m.Parameters...)},
+		}
+		if loc, ok := sarifMessageLocation(m); ok {
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+	out, err := json.MarshalIndent(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}}, "", "  ")
+	return string(out), err
+}
+
+// sarifMessageLocation returns the location of the resource the message is about. Resources read from files point
+// at the file and line; resources read from a cluster only have a logical location.
+func sarifMessageLocation(m diag.Message) (sarifLocation, bool) {
+	if m.Resource == nil {
+		return sarifLocation{}, false
+	}
+	loc := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: m.Resource.Origin.FriendlyName(), Kind: "resource"}},
+	}
+	if file, line := messageFileLocation(m); file != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)}}
+		if line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+		}
+	}
+	return loc, true
+}
+
+// messageFileLocation returns the file and line of the resource the message is about, if it was read from a file.
+// The line of the message, pointing at the offending field, takes precedence over the line of the resource.
+func messageFileLocation(m diag.Message) (string, int) {
+	if m.Resource == nil || m.Resource.Origin.Reference() == nil {
+		return "", 0
+	}
+	ref := m.Resource.Origin.Reference().String()
+	if ref == "" {
+		return "", 0
+	}
+	file, line := ref, 0
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		if l, err := strconv.Atoi(ref[i+1:]); err == nil {
+			file, line = ref[:i], l
+		}
+	}
+	if m.Line != 0 {
+		line = m.Line
+	}
+	return file, line
+}
+
+func documentationURL(m diag.Message) string {
+	docQueryString := ""
+	if m.DocRef != "" {
+		docQueryString = fmt.Sprintf("?ref=%s", m.DocRef)
+	}
+	return fmt.Sprintf("%s/%s/%s", url.ConfigAnalysis, strings.ToLower(m.Type.Code()), docQueryString)
+}
@@ -0,0 +1,55 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package formatting
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/config/analysis/diag"
+)
+
+// LoadSeverityOverrides reads a YAML (or JSON) file mapping message codes to levels, for example:
+//
+//	IST0102: Error
+//	IST0118: Info
+func LoadSeverityOverrides(file string) (map[string]diag.Level, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read severity overrides: %v", err)
+	}
+	return ParseSeverityOverrides(b)
+}
+
+// ParseSeverityOverrides parses a mapping of message codes to levels.
+func ParseSeverityOverrides(b []byte) (map[string]diag.Level, error) {
+	raw := map[string]string{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse severity overrides: %v", err)
+	}
+	levelMap := diag.GetUppercaseStringToLevelMap()
+	overrides := make(map[string]diag.Level, len(raw))
+	for code, level := range raw {
+		l, ok := levelMap[strings.ToUpper(level)]
+		if !ok {
+			return nil, fmt.Errorf("invalid level %q for %s, expected one of %v", level, code, diag.GetAllLevelStrings())
+		}
+		overrides[strings.ToUpper(code)] = l
+	}
+	return overrides, nil
+}
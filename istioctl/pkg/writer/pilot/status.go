@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	xdsresource "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/slices"
 )
 
 // XdsStatusWriter enables printing of sync status using multiple xdsapi.DiscoveryResponse Istiod responses
@@ -49,6 +51,14 @@ type xdsWriterStatus struct {
 	routeStatus           string
 	endpointStatus        string
 	extensionconfigStatus string
+	rejected              []rejectedResource
+}
+
+// rejectedResource is a resource rejected by a proxy, or not sent to it as it failed validation.
+type rejectedResource struct {
+	typeURL string
+	name    string
+	details string
 }
 
 const ignoredStatus = "IGNORED"
@@ -65,9 +75,29 @@ func (s *XdsStatusWriter) PrintAll(statuses map[string]*discovery.DiscoveryRespo
 		}
 	}
 	if w != nil {
-		return w.Flush()
+		if err := w.Flush(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return s.printRejected(fullStatus)
+}
+
+// printRejected prints the resources rejected by the proxies, if any, after the sync status.
+func (s *XdsStatusWriter) printRejected(fullStatus []*xdsWriterStatus) error {
+	if slices.FindFunc(fullStatus, func(status *xdsWriterStatus) bool {
+		return len(status.rejected) > 0
+	}) == nil {
+		return nil
+	}
+	w := new(tabwriter.Writer).Init(s.Writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "\nNAME\tTYPE\tREJECTED RESOURCE\tERROR")
+	for _, status := range fullStatus {
+		for _, r := range status.rejected {
+			_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", status.proxyID, xdsresource.GetShortType(r.typeURL), r.name,
+				strings.ReplaceAll(r.details, "\n", " "))
+		}
+	}
+	return w.Flush()
 }
 
 func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*discovery.DiscoveryResponse) (*tabwriter.Writer, []*xdsWriterStatus, error) {
@@ -102,6 +132,7 @@ func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*discovery.DiscoveryRe
 				routeStatus:           rds,
 				endpointStatus:        eds,
 				extensionconfigStatus: ecds,
+				rejected:              getRejectedResources(&clientConfig),
 			})
 			if len(fullStatus) == 0 {
 				return nil, nil, fmt.Errorf("no proxies found (checked %d istiods)", len(drs))
@@ -157,6 +188,10 @@ func getSyncStatus(clientConfig *xdsstatus.ClientConfig) (cds, lds, eds, rds, ec
 	ecds = ignoredStatus
 	configs := handleAndGetXdsConfigs(clientConfig)
 	for _, config := range configs {
+		if config.GetName() != "" {
+			// Named configs are the rejected resources of the type.
+			continue
+		}
 		cfgType := config.GetTypeUrl()
 		switch cfgType {
 		case xdsresource.ListenerType:
@@ -176,6 +211,20 @@ func getSyncStatus(clientConfig *xdsstatus.ClientConfig) (cds, lds, eds, rds, ec
 	return
 }
 
+func getRejectedResources(clientConfig *xdsstatus.ClientConfig) []rejectedResource {
+	var rejected []rejectedResource
+	for _, config := range clientConfig.GetGenericXdsConfigs() {
+		if config.GetName() != "" && config.GetConfigStatus() == xdsstatus.ConfigStatus_ERROR {
+			rejected = append(rejected, rejectedResource{
+				typeURL: config.GetTypeUrl(),
+				name:    config.GetName(),
+				details: config.GetErrorState().GetDetails(),
+			})
+		}
+	}
+	return rejected
+}
+
 func handleAndGetXdsConfigs(clientConfig *xdsstatus.ClientConfig) []*xdsstatus.ClientConfig_GenericXdsConfig {
 	configs := make([]*xdsstatus.ClientConfig_GenericXdsConfig, 0)
 	if clientConfig.GetGenericXdsConfigs() != nil {
@@ -20,6 +20,7 @@ import (
 	"os"
 	"testing"
 
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	status "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
@@ -124,6 +125,44 @@ func TestXdsStatusWriter_PrintAll(t *testing.T) {
 			},
 			want: "testdata/multiXdsStatusSinglePilot.txt",
 		},
+		{
+			name: "prints rejected resources after the sync status",
+			input: map[string]*discovery.DiscoveryResponse{
+				"istiod1": xdsResponseInput("istiod1", []clientConfigInput{
+					{
+						proxyID:        "proxy1",
+						clusterID:      "cluster1",
+						version:        "1.20",
+						cdsSyncStatus:  status.ConfigStatus_ERROR,
+						ldsSyncStatus:  status.ConfigStatus_SYNCED,
+						rdsSyncStatus:  status.ConfigStatus_SYNCED,
+						edsSyncStatus:  status.ConfigStatus_SYNCED,
+						ecdsSyncStatus: status.ConfigStatus_NOT_SENT,
+						rejected: []*status.ClientConfig_GenericXdsConfig{
+							{
+								TypeUrl:      v3.ClusterType,
+								Name:         "outbound|80||a.default.svc.cluster.local",
+								ConfigStatus: status.ConfigStatus_ERROR,
+								ErrorState: &admin.UpdateFailureState{
+									Details: "Error adding/updating cluster(s) outbound|80||a.default.svc.cluster.local: bad\ntimeout",
+								},
+							},
+						},
+					},
+					{
+						proxyID:        "proxy2",
+						clusterID:      "cluster1",
+						version:        "1.20",
+						cdsSyncStatus:  status.ConfigStatus_SYNCED,
+						ldsSyncStatus:  status.ConfigStatus_SYNCED,
+						rdsSyncStatus:  status.ConfigStatus_SYNCED,
+						edsSyncStatus:  status.ConfigStatus_SYNCED,
+						ecdsSyncStatus: status.ConfigStatus_NOT_SENT,
+					},
+				}),
+			},
+			want: "testdata/multiXdsStatusRejected.txt",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -160,6 +199,8 @@ type clientConfigInput struct {
 	rdsSyncStatus  status.ConfigStatus
 	edsSyncStatus  status.ConfigStatus
 	ecdsSyncStatus status.ConfigStatus
+
+	rejected []*status.ClientConfig_GenericXdsConfig
 }
 
 func newXdsClientConfig(config clientConfigInput) *status.ClientConfig {
@@ -172,7 +213,7 @@ func newXdsClientConfig(config clientConfigInput) *status.ClientConfig {
 			Id:       config.proxyID,
 			Metadata: meta.ToStruct(),
 		},
-		GenericXdsConfigs: []*status.ClientConfig_GenericXdsConfig{
+		GenericXdsConfigs: append([]*status.ClientConfig_GenericXdsConfig{
 			{
 				TypeUrl:      v3.ClusterType,
 				ConfigStatus: config.cdsSyncStatus,
@@ -193,7 +234,7 @@ func newXdsClientConfig(config clientConfigInput) *status.ClientConfig {
 				TypeUrl:      v3.ExtensionConfigurationType,
 				ConfigStatus: config.ecdsSyncStatus,
 			},
-		},
+		}, config.rejected...),
 	}
 }
 
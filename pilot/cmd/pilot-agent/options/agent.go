@@ -21,6 +21,7 @@ import (
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pkg/bootstrap/platform"
+	dnsClient "istio.io/istio/pkg/dns/client"
 	istioagent "istio.io/istio/pkg/istio-agent"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/wasm"
@@ -43,11 +44,13 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 		ProxyType:                proxy.Type,
 		EnableDynamicProxyConfig: enableProxyConfigXdsEnv,
 		WASMOptions: wasm.Options{
-			InsecureRegistries:    sets.New(insecureRegistries...),
-			ModuleExpiry:          wasmModuleExpiry,
-			PurgeInterval:         wasmPurgeInterval,
-			HTTPRequestTimeout:    wasmHTTPRequestTimeout,
-			HTTPRequestMaxRetries: wasmHTTPRequestMaxRetries,
+			InsecureRegistries:      sets.New(insecureRegistries...),
+			ModuleExpiry:            wasmModuleExpiry,
+			PurgeInterval:           wasmPurgeInterval,
+			HTTPRequestTimeout:      wasmHTTPRequestTimeout,
+			HTTPRequestMaxRetries:   wasmHTTPRequestMaxRetries,
+			SignaturePublicKeysFile: wasmSignaturePublicKeys,
+			MaxCacheSize:            int64(wasmCacheMaxSizeMB) * 1024 * 1024,
 		},
 		ProxyIPAddresses:            proxy.IPAddresses,
 		ServiceNode:                 proxy.ServiceNode(),
@@ -63,6 +66,8 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 		DNSCapture:                  DNSCaptureByAgent.Get(),
 		DNSAtGateway:                EnableDNSAtGateway.Get(),
 		DNSForwardParallel:          DNSForwardParallel.Get(),
+		DNSCacheSize:                DNSCacheSize.Get(),
+		DNSUpstreamTLS:              dnsUpstreamTLS(),
 		DNSAddr:                     DNSCaptureAddr.Get(),
 		ProxyNamespace:              PodNamespaceVar.Get(),
 		ProxyDomain:                 proxy.DNSDomain,
@@ -75,6 +80,19 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 	return o
 }
 
+// dnsUpstreamTLS returns the DNS-over-TLS upstream configuration, or nil if not configured.
+func dnsUpstreamTLS() *dnsClient.UpstreamTLS {
+	servers := DNSUpstreamTLSServers.Get()
+	if servers == "" {
+		return nil
+	}
+	return &dnsClient.UpstreamTLS{
+		Servers:    strings.Split(servers, ","),
+		CACertFile: DNSUpstreamTLSCACert.Get(),
+		ServerName: DNSUpstreamTLSServerName.Get(),
+	}
+}
+
 // Simplified extraction of gRPC headers from environment.
 // Unlike ISTIO_META, where we need JSON and advanced features - this is just for small string headers.
 func extractXDSHeadersFromEnv(o *istioagent.AgentOptions) {
@@ -92,9 +92,17 @@ var (
 	eccCurvEnv          = env.Register("ECC_CURVE", "P256", "The elliptic curve to use when ECC_SIGNATURE_ALGORITHM is set to ECDSA").Get()
 	fileMountedCertsEnv = env.Register("FILE_MOUNTED_CERTS", false, "").Get()
 	credFetcherTypeEnv  = env.Register("CREDENTIAL_FETCHER_TYPE", security.JWT,
-		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine").Get()
-	credIdentityProvider = env.Register("CREDENTIAL_IDENTITY_PROVIDER", "GoogleComputeEngine",
-		"The identity provider for credential. Currently default supported identity provider is GoogleComputeEngine").Get()
+		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine, AWS and Azure. "+
+			"The istiod CA verifies the AWS and Azure credentials when configured with AWS_IDENTITY_RULE or AZURE_IDENTITY_RULE").Get()
+	credIdentityProvider = env.Register("CREDENTIAL_IDENTITY_PROVIDER", "",
+		"The identity provider for credential, reported with the credential fetched by CREDENTIAL_FETCHER_TYPE. "+
+			"Supported identity providers are GoogleComputeEngine, AWS and Azure. If unset, it defaults to the credential fetcher type").Get()
+	credAudience = env.Register("CREDENTIAL_AUDIENCE", "",
+		"The audience (resource) the Azure managed identity token is requested for. "+
+			"Required when CREDENTIAL_FETCHER_TYPE is Azure").Get()
+	credMetadataEndpoint = env.Register("CREDENTIAL_METADATA_ENDPOINT", "",
+		"The address of the instance metadata service used by the AWS and Azure credential fetchers. "+
+			"If unset, the platform default link-local address is used").Get()
 	proxyXDSDebugViaAgent = env.Register("PROXY_XDS_DEBUG_VIA_AGENT", true,
 		"If set to true, the agent will listen on tap port and offer pilot's XDS istio.io/debug debug API there.").Get()
 	proxyXDSDebugViaAgentPort = env.Register("PROXY_XDS_DEBUG_VIA_AGENT_PORT", 15004,
@@ -114,6 +122,20 @@ var (
 	DNSForwardParallel = env.Register("DNS_FORWARD_PARALLEL", false,
 		"If set to true, agent will send parallel DNS queries to all upstream nameservers")
 
+	DNSCacheSize = env.Register("DNS_PROXY_CACHE_SIZE", 0,
+		"Maximum number of upstream DNS responses cached by the DNS proxy, honoring their TTLs. "+
+			"Negative responses are cached according to their SOA record. If 0, responses are not cached")
+
+	DNSUpstreamTLSServers = env.Register("DNS_UPSTREAM_TLS_SERVERS", "",
+		"Comma separated list of DNS-over-TLS servers, as host or host:port, to forward DNS queries to instead of the "+
+			"servers in resolv.conf. The port defaults to 853")
+
+	DNSUpstreamTLSCACert = env.Register("DNS_UPSTREAM_TLS_CA_CERT", "",
+		"Path to the PEM encoded CA certificates used to verify DNS-over-TLS servers. If unset, the system roots are used")
+
+	DNSUpstreamTLSServerName = env.Register("DNS_UPSTREAM_TLS_SERVER_NAME", "",
+		"Name used to verify the certificates of DNS-over-TLS servers. If unset, the host of the server address is used")
+
 	// Ability of istio-agent to retrieve proxyConfig via XDS for dynamic configuration updates
 	enableProxyConfigXdsEnv = env.Register("PROXY_CONFIG_XDS_AGENT", false,
 		"If set to true, agent retrieves dynamic proxy-config updates via xds channel").Get()
@@ -133,6 +155,14 @@ var (
 	wasmHTTPRequestMaxRetries = env.Register("WASM_HTTP_REQUEST_MAX_RETRIES", wasm.DefaultHTTPRequestMaxRetries,
 		"maximum number of HTTP/HTTPS request retries for pulling a Wasm module via http/https").Get()
 
+	wasmCacheMaxSizeMB = env.Register("WASM_CACHE_MAX_SIZE_MB", 0,
+		"maximum disk space in megabytes used to cache Wasm modules. When exceeded, the least recently used modules are "+
+			"removed. 0 means no limit").Get()
+
+	wasmSignaturePublicKeys = env.Register("WASM_SIGNATURE_PUBLIC_KEYS", "",
+		"path to a PEM file with the public keys trusted to sign Wasm modules. If set, Wasm modules must be OCI images "+
+			"with a valid cosign signature from one of the keys").Get()
+
 	enableWDSEnv = env.Register("PEER_METADATA_DISCOVERY", false,
 		"If set to true, enable the peer metadata discovery extension in Envoy").Get()
 
@@ -90,7 +90,8 @@ func SetupSecurityOptions(proxyConfig *meshconfig.ProxyConfig, secOpt *security.
 	}
 
 	o.CredIdentityProvider = credIdentityProvider
-	credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, o.TrustDomain, jwtPath, o.CredIdentityProvider)
+	credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, o.TrustDomain, jwtPath, o.CredIdentityProvider,
+		credAudience, credMetadataEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create credential fetcher: %v", err)
 	}
@@ -37,6 +37,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/incluster"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/config/schema/gvr"
+	"istio.io/istio/pkg/kube/krt"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/revisions"
 )
@@ -189,6 +190,15 @@ func (s *Server) initK8SConfigStore(args *PilotArgs) error {
 			})
 		}
 	}
+	if features.AmbientSnapshotDir != "" {
+		snapshot := krt.NewSnapshotStore(features.AmbientSnapshotDir)
+		args.RegistryOptions.KubeOptions.KrtSnapshot = snapshot
+		s.addTerminatingStartFunc("ambient snapshot", func(stop <-chan struct{}) error {
+			<-stop
+			log.Infof("Writing ambient snapshot to %v", features.AmbientSnapshotDir)
+			return snapshot.Save()
+		})
+	}
 	if features.EnableAmbientStatus {
 		statusWritingEnabled := activenotifier.New(false)
 		args.RegistryOptions.KubeOptions.StatusWritingEnabled = statusWritingEnabled
@@ -206,6 +216,17 @@ func (s *Server) initK8SConfigStore(args *PilotArgs) error {
 			return nil
 		})
 	}
+	if features.EnableXDSValidation {
+		if s.statusManager == nil {
+			s.initStatusManager(args)
+		}
+		// Rejections are observed for the proxies connected to each instance, so rather than a leader writing the
+		// status, each instance merges the rejections of its own proxies into the condition, identified by its name.
+		s.addStartFunc("xds rejection status", func(stop <-chan struct{}) error {
+			s.XDSServer.SetRejectionStatusWrite(s.statusManager, args.PodName)
+			return nil
+		})
+	}
 	if features.EnableAnalysis {
 		if err := s.initInprocessAnalysisController(args); err != nil {
 			return err
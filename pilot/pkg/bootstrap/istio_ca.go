@@ -129,11 +129,23 @@ var (
 
 	// TODO: Likely to be removed and added to mesh config
 	externalCaType = env.Register("EXTERNAL_CA", "",
-		"External CA Integration Type. Permitted value is ISTIOD_RA_KUBERNETES_API.").Get()
+		"External CA Integration Type. Permitted values are ISTIOD_RA_KUBERNETES_API and ISTIOD_RA_EST.").Get()
 
 	// TODO: Likely to be removed and added to mesh config
 	k8sSigner = env.Register("K8S_SIGNER", "",
 		"Kubernetes CA Signer type. Valid from Kubernetes 1.18").Get()
+
+	estServerURL = env.Register("EXTERNAL_CA_EST_URL", "",
+		"URL of the EST server used when EXTERNAL_CA is ISTIOD_RA_EST, e.g. https://est.example.com/.well-known/est.").Get()
+
+	estServerCAFile = env.Register("EXTERNAL_CA_EST_SERVER_CA", "",
+		"Path to the PEM encoded CA certificate used to verify the EST server. If unset, the system roots are used.").Get()
+
+	estClientCertFile = env.Register("EXTERNAL_CA_EST_CLIENT_CERT", "",
+		"Path to the PEM encoded client certificate Istiod presents to the EST server.").Get()
+
+	estClientKeyFile = env.Register("EXTERNAL_CA_EST_CLIENT_KEY", "",
+		"Path to the PEM encoded private key of the EST client certificate.").Get()
 )
 
 // initCAServer create a CA Server. The CA API uses cert with the max workload cert TTL.
@@ -523,6 +535,8 @@ func (s *Server) createSelfSignedCACertificateOptions(fileBundle *ca.SigningCAFi
 //	kubernetes built-in `kubernetes.io/legacy-unknown" signer
 //
 // 3. Extract from the cert-chain signed by other CSR signer.
+//
+// For the EST RA, the ca cert is fetched from the EST server if it is not mounted.
 func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error) {
 	caCertFile := path.Join(ra.DefaultExtCACertDir, constants.CACertNamespaceConfigMapDataName)
 	certSignerDomain := opts.CertSignerDomain
@@ -533,7 +547,10 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 		}
 
 		// File does not exist.
-		if certSignerDomain == "" {
+		if opts.ExternalCAType == ra.ExtCAEST {
+			log.Infof("CA cert file %q not found, fetching it from the EST server.", caCertFile)
+			caCertFile = ""
+		} else if certSignerDomain == "" {
 			log.Infof("CA cert file %q not found, using %q.", caCertFile, defaultCACertPath)
 			caCertFile = defaultCACertPath
 		} else {
@@ -542,19 +559,25 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 		}
 	}
 
-	if s.kubeClient == nil {
-		return nil, fmt.Errorf("kubeClient is nil")
-	}
 	raOpts := &ra.IstioRAOptions{
-		ExternalCAType:   opts.ExternalCAType,
-		DefaultCertTTL:   workloadCertTTL.Get(),
-		MaxCertTTL:       maxWorkloadCertTTL.Get(),
-		CaSigner:         opts.ExternalCASigner,
-		CaCertFile:       caCertFile,
-		VerifyAppendCA:   true,
-		K8sClient:        s.kubeClient.Kube(),
-		TrustDomain:      opts.TrustDomain,
-		CertSignerDomain: opts.CertSignerDomain,
+		ExternalCAType:    opts.ExternalCAType,
+		DefaultCertTTL:    workloadCertTTL.Get(),
+		MaxCertTTL:        maxWorkloadCertTTL.Get(),
+		CaSigner:          opts.ExternalCASigner,
+		CaCertFile:        caCertFile,
+		VerifyAppendCA:    true,
+		TrustDomain:       opts.TrustDomain,
+		CertSignerDomain:  opts.CertSignerDomain,
+		ESTServerURL:      estServerURL,
+		ESTServerCAFile:   estServerCAFile,
+		ESTClientCertFile: estClientCertFile,
+		ESTClientKeyFile:  estClientKeyFile,
+	}
+	if opts.ExternalCAType != ra.ExtCAEST {
+		if s.kubeClient == nil {
+			return nil, fmt.Errorf("kubeClient is nil")
+		}
+		raOpts.K8sClient = s.kubeClient.Kube()
 	}
 	raServer, err := ra.NewIstioRA(raOpts)
 	if err != nil {
@@ -57,6 +57,8 @@ type PilotArgs struct {
 	KeepaliveOptions   *keepalive.Options
 	ShutdownDuration   time.Duration
 	JwtRule            string
+	AWSIdentityRule    string
+	AzureIdentityRule  string
 }
 
 // DiscoveryServerOptions contains options for create a new discovery server instance.
@@ -110,6 +112,12 @@ var (
 	PodName      = env.Register("POD_NAME", "", "").Get()
 	JwtRule      = env.Register("JWT_RULE", "",
 		"The JWT rule used by istiod authentication").Get()
+	AWSIdentityRule = env.Register("AWS_IDENTITY_RULE", "",
+		"The rule used by istiod to authenticate EC2 instance identity documents, as JSON. For example: "+
+			`{"certificates_file": "/etc/aws/certs.pem", "accounts": {"123456789012": "vm-ns/vm-sa"}}`).Get()
+	AzureIdentityRule = env.Register("AZURE_IDENTITY_RULE", "",
+		"The rule used by istiod to authenticate Azure managed identity tokens, as JSON. For example: "+
+			`{"issuer": "https://sts.windows.net/<tenant ID>/", "audiences": ["api://istio"], "identities": {"<object ID>": "vm-ns/vm-sa"}}`).Get()
 )
 
 // Revision is the value of the Istio control plane revision, e.g. "canary",
@@ -138,6 +146,8 @@ func (p *PilotArgs) applyDefaults() {
 	p.PodName = PodName
 	p.Revision = Revision
 	p.JwtRule = JwtRule
+	p.AWSIdentityRule = AWSIdentityRule
+	p.AzureIdentityRule = AzureIdentityRule
 	p.KeepaliveOptions = keepalive.DefaultOption()
 	p.RegistryOptions.ClusterRegistriesNamespace = p.Namespace
 }
@@ -371,6 +371,20 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 		}
 		authenticators = append(authenticators, jwtAuthn)
 	}
+	if args.AWSIdentityRule != "" {
+		awsAuthn, err := initAWSAuthenticator(args, s.environment.Watcher)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing AWS authenticator: %v", err)
+		}
+		authenticators = append(authenticators, awsAuthn)
+	}
+	if args.AzureIdentityRule != "" {
+		azureAuthn, err := initAzureAuthenticator(args, s.environment.Watcher)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing Azure authenticator: %v", err)
+		}
+		authenticators = append(authenticators, azureAuthn)
+	}
 	// The k8s JWT authenticator requires the multicluster registry to be initialized,
 	// so we build it later.
 	if s.kubeClient != nil {
@@ -421,6 +435,34 @@ func initOIDC(args *PilotArgs, meshWatcher mesh.Watcher) (security.Authenticator
 	return jwtAuthn, nil
 }
 
+func initAWSAuthenticator(args *PilotArgs, meshWatcher mesh.Watcher) (security.Authenticator, error) {
+	// AWSIdentityRule is from the AWS_IDENTITY_RULE environment variable.
+	rule := &authenticate.AWSIdentityRule{}
+	if err := json.Unmarshal([]byte(args.AWSIdentityRule), rule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AWS identity rule: %v", err)
+	}
+	log.Infof("Istiod authenticating AWS accounts: %v", rule.Accounts)
+	awsAuthn, err := authenticate.NewAWSAuthenticator(rule, meshWatcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the AWS authenticator: %v", err)
+	}
+	return awsAuthn, nil
+}
+
+func initAzureAuthenticator(args *PilotArgs, meshWatcher mesh.Watcher) (security.Authenticator, error) {
+	// AzureIdentityRule is from the AZURE_IDENTITY_RULE environment variable.
+	rule := &authenticate.AzureIdentityRule{}
+	if err := json.Unmarshal([]byte(args.AzureIdentityRule), rule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Azure identity rule: %v", err)
+	}
+	log.Infof("Istiod authenticating Azure managed identities of %s: %v", rule.Issuer, rule.Identities)
+	azureAuthn, err := authenticate.NewAzureAuthenticator(rule, meshWatcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Azure authenticator: %v", err)
+	}
+	return azureAuthn, nil
+}
+
 func getClusterID(args *PilotArgs) cluster.ID {
 	clusterID := args.RegistryOptions.KubeOptions.ClusterID
 	if clusterID == "" {
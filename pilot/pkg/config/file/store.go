@@ -69,6 +69,15 @@ type KubeSource struct {
 
 	// If meshConfig.DiscoverySelectors are specified, the namespacesFilter tracks the namespaces this controller watches.
 	namespacesFilter func(obj interface{}) bool
+
+	handlers map[config.GroupVersionKind][]model.EventHandler
+}
+
+// kubeEvent is a change applied to the source, notified to the event handlers once the lock is released.
+type kubeEvent struct {
+	old   config.Config
+	new   config.Config
+	event model.Event
 }
 
 func (s *KubeSource) Schemas() collection.Schemas {
@@ -109,8 +118,27 @@ func (s *KubeSource) Delete(typ config.GroupVersionKind, name, namespace string,
 	return s.inner.Delete(typ, name, namespace, resourceVersion)
 }
 
+// RegisterEventHandler adds a handler called when the content applied to or removed from the source changes resources of
+// the given kind.
 func (s *KubeSource) RegisterEventHandler(kind config.GroupVersionKind, handler model.EventHandler) {
-	panic("implement me")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = append(s.handlers[kind], handler)
+}
+
+func (s *KubeSource) notify(events []kubeEvent) {
+	s.mu.Lock()
+	handlers := s.handlers
+	s.mu.Unlock()
+	for _, e := range events {
+		kind := e.new.GroupVersionKind
+		if e.event == model.EventDelete {
+			kind = e.old.GroupVersionKind
+		}
+		for _, h := range handlers[kind] {
+			h(e.old, e.new, e.event)
+		}
+	}
 }
 
 func (s *KubeSource) Run(stop <-chan struct{}) {
@@ -154,11 +182,12 @@ func NewKubeSource(schemas collection.Schemas) *KubeSource {
 	inMemoryKubeNameDiscriminator++
 
 	return &KubeSource{
-		name:    name,
-		schemas: &schemas,
-		inner:   memory.MakeSkipValidation(schemas),
-		shas:    make(map[kubeResourceKey]resourceSha),
-		byFile:  make(map[string]map[kubeResourceKey]config.GroupVersionKind),
+		name:     name,
+		schemas:  &schemas,
+		inner:    memory.MakeSkipValidation(schemas),
+		shas:     make(map[kubeResourceKey]resourceSha),
+		byFile:   make(map[string]map[kubeResourceKey]config.GroupVersionKind),
+		handlers: make(map[config.GroupVersionKind][]model.EventHandler),
 	}
 }
 
@@ -197,6 +226,12 @@ func (s *KubeSource) ContentNames() map[string]struct{} {
 // or removed, depending on the new content.
 // Returns an error if any were encountered, but that still may represent a partial success
 func (s *KubeSource) ApplyContent(name, yamlText string) error {
+	events, err := s.applyContent(name, yamlText)
+	s.notify(events)
+	return err
+}
+
+func (s *KubeSource) applyContent(name, yamlText string) ([]kubeEvent, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -206,6 +241,7 @@ func (s *KubeSource) ApplyContent(name, yamlText string) error {
 	oldKeys := s.byFile[name]
 	newKeys := make(map[kubeResourceKey]config.GroupVersionKind)
 
+	var events []kubeEvent
 	for _, r := range resources {
 		key := r.newKey()
 
@@ -213,15 +249,21 @@ func (s *KubeSource) ApplyContent(name, yamlText string) error {
 		if !found || oldSha != r.sha {
 			scope.Debugf("KubeSource.ApplyContent: Set: %v/%v", r.schema.GroupVersionKind(), r.fullName())
 			// apply is idempotent, but configstore is not, thus the odd logic here
+			event := kubeEvent{new: *r.config, event: model.EventUpdate}
+			if old := s.inner.Get(r.schema.GroupVersionKind(), r.config.Name, r.config.Namespace); old != nil {
+				event.old = *old
+			}
 			_, err := s.inner.Update(*r.config)
 			if err != nil {
+				event.event = model.EventAdd
 				_, err = s.inner.Create(*r.config)
 				if err != nil {
-					return fmt.Errorf("cannot store config %s/%s %s from reader: %s",
+					return events, fmt.Errorf("cannot store config %s/%s %s from reader: %s",
 						r.schema.Version(), r.schema.Kind(), r.fullName(), err)
 				}
 			}
 			s.shas[key] = r.sha
+			events = append(events, event)
 		}
 		newKeys[key] = r.schema.GroupVersionKind()
 		if oldKeys != nil {
@@ -231,38 +273,48 @@ func (s *KubeSource) ApplyContent(name, yamlText string) error {
 	}
 
 	for k, col := range oldKeys {
-		empty := ""
-		err := s.inner.Delete(col, k.fullName.Name.String(), k.fullName.Namespace.String(), &empty)
-		if err != nil {
-			scope.Errorf("encountered unexpected error removing resource from filestore: %s", err)
-		}
+		events = append(events, s.delete(k, col)...)
+		delete(s.shas, k)
 	}
 	s.byFile[name] = newKeys
 
 	if parseErrs != nil {
-		return fmt.Errorf("errors parsing content %q: %v", name, parseErrs)
+		return events, fmt.Errorf("errors parsing content %q: %v", name, parseErrs)
 	}
-	return nil
+	return events, nil
 }
 
 // RemoveContent removes the content for the given name
 func (s *KubeSource) RemoveContent(name string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
+	var events []kubeEvent
 	keys := s.byFile[name]
 	if keys != nil {
 		for key, col := range keys {
-			empty := ""
-			err := s.inner.Delete(col, key.fullName.Name.String(), key.fullName.Namespace.String(), &empty)
-			if err != nil {
-				scope.Errorf("encountered unexpected error removing resource from filestore: %s", err)
-			}
+			events = append(events, s.delete(key, col)...)
 			delete(s.shas, key)
 		}
 
 		delete(s.byFile, name)
 	}
+	s.mu.Unlock()
+
+	s.notify(events)
+}
+
+// delete removes a resource from the inner store, returning the corresponding event if it was found.
+func (s *KubeSource) delete(key kubeResourceKey, col config.GroupVersionKind) []kubeEvent {
+	name, namespace := key.fullName.Name.String(), key.fullName.Namespace.String()
+	old := s.inner.Get(col, name, namespace)
+	empty := ""
+	if err := s.inner.Delete(col, name, namespace, &empty); err != nil {
+		scope.Errorf("encountered unexpected error removing resource from filestore: %s", err)
+		return nil
+	}
+	if old == nil {
+		return nil
+	}
+	return []kubeEvent{{old: *old, event: model.EventDelete}}
 }
 
 func (s *KubeSource) parseContent(r *collection.Schemas, name, yamlText string) ([]kubeResource, error) {
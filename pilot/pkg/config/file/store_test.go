@@ -22,6 +22,8 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/config/schema/gvk"
 )
@@ -58,3 +60,49 @@ spec:
 	// Apply v2 config and validate overwrite
 	applyAndValidate("v2")
 }
+
+func TestEventHandlers(t *testing.T) {
+	g := NewWithT(t)
+	src := NewKubeSource(collections.Istio)
+
+	var events []string
+	src.RegisterEventHandler(gvk.DestinationRule, func(old config.Config, cur config.Config, ev model.Event) {
+		name := cur.Name
+		if ev == model.EventDelete {
+			name = old.Name
+		}
+		events = append(events, fmt.Sprintf("%s %s", ev, name))
+	})
+
+	dr := func(name, host string) string {
+		return fmt.Sprintf(`apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: %s
+spec:
+  host: %s
+`, name, host)
+	}
+
+	g.Expect(src.ApplyContent("test", dr("a", "a")+"---\n"+dr("b", "b"))).To(Succeed())
+	g.Expect(events).To(ConsistOf("add a", "add b"))
+
+	// Unchanged resources do not trigger events.
+	events = nil
+	g.Expect(src.ApplyContent("test", dr("a", "a2")+"---\n"+dr("b", "b"))).To(Succeed())
+	g.Expect(events).To(ConsistOf("update a"))
+
+	events = nil
+	g.Expect(src.ApplyContent("test", dr("a", "a2"))).To(Succeed())
+	g.Expect(events).To(ConsistOf("delete b"))
+
+	// Resources removed and applied again are added back.
+	events = nil
+	g.Expect(src.ApplyContent("test", dr("a", "a2")+"---\n"+dr("b", "b"))).To(Succeed())
+	g.Expect(events).To(ConsistOf("add b"))
+	g.Expect(src.Get(gvk.DestinationRule, "b", "")).NotTo(BeNil())
+
+	events = nil
+	src.RemoveContent("test")
+	g.Expect(events).To(ConsistOf("delete a", "delete b"))
+}
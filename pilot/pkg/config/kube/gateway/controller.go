@@ -222,7 +222,7 @@ func (c *Controller) Reconcile(ps *model.PushContext) error {
 		input.Credentials = credentials
 	}
 
-	output := convertResources(input)
+	output := ConvertResources(input)
 
 	// Handle all status updates
 	c.QueueStatusUpdates(input)
@@ -63,9 +63,9 @@ func sortConfigByCreationTime(configs []config.Config) {
 	})
 }
 
-// convertResources is the top level entrypoint to our conversion logic, computing the full state based
-// on KubernetesResources inputs.
-func convertResources(r GatewayResources) IstioResources {
+// ConvertResources is the top level entrypoint to our conversion logic, computing the full state based
+// on KubernetesResources inputs. Statuses are written to the inputs, which must have a kstatus.WrappedStatus.
+func ConvertResources(r GatewayResources) IstioResources {
 	// sort HTTPRoutes by creation timestamp and namespace/name
 	sortConfigByCreationTime(r.HTTPRoute)
 	sortConfigByCreationTime(r.GRPCRoute)
@@ -443,7 +443,7 @@ func TestConvertResources(t *testing.T) {
 			})
 			kr := splitInput(t, input)
 			kr.Context = NewGatewayContext(cg.PushContext(), "Kubernetes")
-			output := convertResources(kr)
+			output := ConvertResources(kr)
 			output.AllowedReferences = AllowedReferences{} // Not tested here
 			output.ReferencedNamespaceKeys = nil           // Not tested here
 			output.ResourceReferences = nil                // Not tested here
@@ -1118,7 +1118,7 @@ spec:
 			cg := core.NewConfigGenTest(t, core.TestOptions{})
 			kr := splitInput(t, input)
 			kr.Context = NewGatewayContext(cg.PushContext(), "Kubernetes")
-			output := convertResources(kr)
+			output := ConvertResources(kr)
 			c := &Controller{
 				state: output,
 			}
@@ -23,6 +23,6 @@ import (
 func FuzzConvertResources(f *testing.F) {
 	fuzz.Fuzz(f, func(fg fuzz.Helper) {
 		r := fuzz.Struct[GatewayResources](fg)
-		convertResources(r)
+		ConvertResources(r)
 	})
 }
@@ -59,6 +59,10 @@ var (
 
 	EnableIngressWaypointRouting = registerAmbient("ENABLE_INGRESS_WAYPOINT_ROUTING", true, false,
 		"If true, Gateways will call service waypoints if the 'istio.io/ingress-use-waypoint' label set on the Service.")
+
+	AmbientSnapshotDir = registerAmbient("PILOT_AMBIENT_SNAPSHOT_DIR", "", "",
+		"If set, the ambient workload index is written to this directory on shutdown, and restored from it on startup. "+
+			"This allows serving the previous state while the index is rebuilt, which can take a long time in large clusters.")
 )
 
 // registerAmbient registers a variable that is allowed only if EnableAmbient is set
@@ -178,6 +178,16 @@ var (
 			"These checks are extremely expensive, so this should be used only for testing, not production.",
 	).Get()
 
+	// EnableXDSValidation enables the validation of the generated xDS resources before they are pushed.
+	EnableXDSValidation = env.Register(
+		"PILOT_ENABLE_XDS_VALIDATION",
+		false,
+		"If enabled, the generated listeners, clusters and routes are validated against the constraints of their protos "+
+			"before being pushed, and the invalid ones are not pushed. With state of the world xDS, the last version of the invalid "+
+			"listeners and clusters accepted by the proxy is pushed instead. Resources rejected by the validation or by the proxies are "+
+			"reported as a Rejected status condition on the Istio configs they are generated from.",
+	).Get()
+
 	SharedMeshConfig = env.Register("SHARED_MESH_CONFIG", "",
 		"Additional config map to load for shared MeshConfig settings. The standard mesh config will take precedence.").Get()
 
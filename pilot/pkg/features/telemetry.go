@@ -60,6 +60,9 @@ var (
 	EnableControllerQueueMetrics = env.Register("ISTIO_ENABLE_CONTROLLER_QUEUE_METRICS", false,
 		"If enabled, publishes metrics for queue depth, latency and processing times.").Get()
 
+	EnableKrtMetrics = env.Register("PILOT_ENABLE_KRT_METRICS", false,
+		"If enabled, publishes per-collection metrics for krt recomputations, events, and transformation latency.").Get()
+
 	EnableDelimitedStatsTagRegex = env.Register("ENABLE_DELIMITED_STATS_TAG_REGEX", true,
 		"If true, pilot will use the new delimited stat tag regex to generate Envoy stats tags.").Get()
 )
@@ -96,6 +96,26 @@ var (
 			" EDS pushes may be delayed, but there will be fewer pushes. By default this is enabled",
 	).Get()
 
+	PushHistorySize = env.Register(
+		"PILOT_PUSH_HISTORY_SIZE",
+		100,
+		"The number of pushes recorded in the push history exposed on /debug/push_history. The history is disabled if 0.",
+	).Get()
+
+	PushQueuePriority = env.Register(
+		"PILOT_ENABLE_PUSH_QUEUE_PRIORITY",
+		false,
+		"If enabled, queued pushes are sent to gateways, waypoints and ztunnels first, then as incremental pushes, "+
+			"then as full pushes. Proxies of different namespaces with the same priority are pushed in turn.",
+	).Get()
+
+	PushQueueMaxWait = env.Register(
+		"PILOT_PUSH_QUEUE_MAX_WAIT",
+		5*time.Second,
+		"If PILOT_ENABLE_PUSH_QUEUE_PRIORITY is enabled, proxies queued for longer than this are pushed first, "+
+			"regardless of their priority.",
+	).Get()
+
 	ConvertSidecarScopeConcurrency = env.Register(
 		"PILOT_CONVERT_SIDECAR_SCOPE_CONCURRENCY",
 		1,
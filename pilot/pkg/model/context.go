@@ -216,6 +216,25 @@ func (e *Environment) Init() {
 	e.clusterLocalServices = NewClusterLocalProvider(e)
 }
 
+// WithConfigStore returns a copy of the environment reading configs from the given store. The copy shares the service
+// registries and caches of the environment, and has no push context.
+func (e *Environment) WithConfigStore(store ConfigStore) *Environment {
+	return &Environment{
+		ServiceDiscovery:      e.ServiceDiscovery,
+		ConfigStore:           store,
+		Watcher:               e.Watcher,
+		NetworksWatcher:       e.NetworksWatcher,
+		NetworkManager:        e.NetworkManager,
+		DomainSuffix:          e.DomainSuffix,
+		TrustBundle:           e.TrustBundle,
+		clusterLocalServices:  e.clusterLocalServices,
+		CredentialsController: e.CredentialsController,
+		GatewayAPIController:  e.GatewayAPIController,
+		EndpointIndex:         e.EndpointIndex,
+		Cache:                 e.Cache,
+	}
+}
+
 func (e *Environment) InitNetworksManager(updater XDSUpdater) (err error) {
 	e.NetworkManager, err = NewNetworkManager(e, updater)
 	return
@@ -23,6 +23,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/netip"
 	"sort"
@@ -1114,6 +1115,55 @@ func (i WorkloadInfo) ResourceName() string {
 	return workloadResourceName(i.Workload)
 }
 
+// workloadInfoJSON is the JSON representation of WorkloadInfo.
+// The Workload is encoded with protojson, as encoding/json cannot decode its oneof fields.
+type workloadInfoJSON struct {
+	Workload     json.RawMessage   `json:"workload,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Source       kind.Kind         `json:"source"`
+	CreationTime time.Time         `json:"creationTime"`
+}
+
+// MarshalJSON implements json.Marshaller
+func (i WorkloadInfo) MarshalJSON() ([]byte, error) {
+	res := workloadInfoJSON{
+		Labels:       i.Labels,
+		Source:       i.Source,
+		CreationTime: i.CreationTime,
+	}
+	if i.Workload != nil {
+		w, err := protomarshal.Marshal(i.Workload)
+		if err != nil {
+			return nil, err
+		}
+		res.Workload = w
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (i *WorkloadInfo) UnmarshalJSON(b []byte) error {
+	in := workloadInfoJSON{}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	*i = WorkloadInfo{
+		Labels: in.Labels,
+		Source: in.Source,
+	}
+	if !in.CreationTime.IsZero() {
+		// Match metav1.Time, which the creation time is typically derived from, so the result compares equal.
+		i.CreationTime = in.CreationTime.Local()
+	}
+	if len(in.Workload) > 0 {
+		i.Workload = &workloadapi.Workload{}
+		if err := protomarshal.Unmarshal(in.Workload, i.Workload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type WaypointPolicyStatus struct {
 	Source     TypedObject
 	Conditions []PolicyBindingStatus
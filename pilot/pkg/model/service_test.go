@@ -15,7 +15,9 @@
 package model
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -26,9 +28,11 @@ import (
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/config/visibility"
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/workloadapi"
 )
 
 func TestGetByPort(t *testing.T) {
@@ -757,3 +761,30 @@ func BenchmarkEndpointDeepCopy(b *testing.B) {
 		_ = ep.DeepCopy()
 	}
 }
+
+func TestWorkloadInfoJSON(t *testing.T) {
+	w := WorkloadInfo{
+		Workload: &workloadapi.Workload{
+			Uid:       "cluster/pod/ns/name",
+			Name:      "name",
+			Namespace: "ns",
+			Addresses: [][]byte{{10, 0, 0, 1}},
+			Waypoint: &workloadapi.GatewayAddress{
+				Destination: &workloadapi.GatewayAddress_Hostname{
+					Hostname: &workloadapi.NamespacedHostname{Namespace: "ns", Hostname: "waypoint.ns.svc.cluster.local"},
+				},
+				HboneMtlsPort: 15008,
+			},
+		},
+		Labels:       map[string]string{"app": "foo"},
+		Source:       kind.Pod,
+		CreationTime: time.Unix(1700000000, 0),
+	}
+	b, err := json.Marshal(w)
+	assert.NoError(t, err)
+	got := WorkloadInfo{}
+	assert.NoError(t, json.Unmarshal(b, &got))
+	if !got.Equals(w) {
+		t.Fatalf("round trip mismatch: got %v, want %v", got, w)
+	}
+}
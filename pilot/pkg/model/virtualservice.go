@@ -309,7 +309,7 @@ func mergeHTTPRoutes(root *networking.HTTPRoute, delegate []*networking.HTTPRout
 func mergeHTTPRoute(root *networking.HTTPRoute, delegate *networking.HTTPRoute) *networking.HTTPRoute {
 	// suppose there are N1 match conditions in root, N2 match conditions in delegate
 	// if match condition of N2 is a subset of anyone in N1, this is a valid matching conditions
-	merged, conflict := mergeHTTPMatchRequests(root.Match, delegate.Match)
+	merged, conflict := MergeHTTPMatchRequests(root.Match, delegate.Match)
 	if conflict {
 		log.Warnf("HTTPMatchRequests conflict: root route %s, delegate route %s", root.Name, delegate.Name)
 		return nil
@@ -358,8 +358,9 @@ func mergeHTTPRoute(root *networking.HTTPRoute, delegate *networking.HTTPRoute)
 	return delegate
 }
 
-// return merged match conditions if not conflicts
-func mergeHTTPMatchRequests(root, delegate []*networking.HTTPMatchRequest) (out []*networking.HTTPMatchRequest, conflict bool) {
+// MergeHTTPMatchRequests returns the match conditions of a delegate route merged with the conditions of the root route
+// delegating to it. If they conflict, the delegate route is ignored.
+func MergeHTTPMatchRequests(root, delegate []*networking.HTTPMatchRequest) (out []*networking.HTTPMatchRequest, conflict bool) {
 	if len(root) == 0 {
 		return delegate, false
 	}
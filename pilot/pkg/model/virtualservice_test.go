@@ -1585,7 +1585,7 @@ func TestMergeHTTPMatchRequests(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.delegate = config.DeepCopy(tc.delegate).([]*networking.HTTPMatchRequest)
-			got, _ := mergeHTTPMatchRequests(tc.root, tc.delegate)
+			got, _ := MergeHTTPMatchRequests(tc.root, tc.delegate)
 			assert.Equal(t, got, tc.expected)
 		})
 	}
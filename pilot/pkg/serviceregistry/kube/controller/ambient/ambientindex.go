@@ -125,6 +125,8 @@ type Options struct {
 	StatusNotifier        *activenotifier.ActiveNotifier
 
 	Debugger *krt.DebugHandler
+	// Snapshot, if set, persists the workload collections so they can be served immediately on restart.
+	Snapshot *krt.SnapshotStore
 }
 
 func New(options Options) Index {
@@ -275,6 +277,7 @@ func New(options Options) Index {
 		EndpointSlices,
 		Namespaces,
 		withDebug,
+		krt.WithSnapshot(options.Snapshot),
 	)
 
 	WorkloadAddressIndex := krt.NewIndex[networkAddress, model.WorkloadInfo](Workloads, networkAddressFromWorkload)
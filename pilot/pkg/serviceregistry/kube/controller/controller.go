@@ -146,6 +146,9 @@ type Options struct {
 	// StatusWritingEnabled determines if status writing is enabled. This may be set to `nil`, in which case status
 	// writing will never be enabled
 	StatusWritingEnabled *activenotifier.ActiveNotifier
+
+	// KrtSnapshot, if set, is used to persist the ambient index of the config cluster, allowing it to warm-start on restart.
+	KrtSnapshot *krt.SnapshotStore
 }
 
 // kubernetesNode represents a kubernetes node that is reachable externally
@@ -281,6 +284,10 @@ func NewController(kubeClient kubelib.Client, options Options) *Controller {
 	registerHandlers[*v1.Pod](c, c.podsClient, "Pods", c.pods.onEvent, c.pods.labelFilter)
 
 	if features.EnableAmbient {
+		var snapshot *krt.SnapshotStore
+		if options.ConfigCluster {
+			snapshot = options.KrtSnapshot
+		}
 		c.ambientIndex = ambient.New(ambient.Options{
 			Client:                kubeClient,
 			SystemNamespace:       options.SystemNamespace,
@@ -292,6 +299,7 @@ func NewController(kubeClient kubelib.Client, options Options) *Controller {
 			LookupNetworkGateways: c.NetworkGateways,
 			StatusNotifier:        options.StatusWritingEnabled,
 			Debugger:              krt.GlobalDebugHandler,
+			Snapshot:              snapshot,
 		})
 	}
 	c.exports = newServiceExportCache(c)
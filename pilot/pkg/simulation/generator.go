@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pilot/pkg/serviceregistry/serviceentry"
+	istiocluster "istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/kube"
+)
+
+// GeneratorOptions are the inputs of a Generator.
+type GeneratorOptions struct {
+	// MeshConfig defaults to the default mesh config.
+	MeshConfig *meshconfig.MeshConfig
+	ClusterID  istiocluster.ID
+	// ConfigStore holds the configs. It is run by the generator.
+	ConfigStore model.ConfigStoreController
+	// GatewayAPIController, if set, converts the Gateway API resources of the ConfigStore.
+	GatewayAPIController model.GatewayController
+}
+
+// Generator generates the configuration of proxies outside of istiod, as istiod would. The configs and services are
+// read from in-memory stores and registries holding a copy of the inputs.
+//
+// The ServiceEntry registry is created from the config store. Other registries are added to Discovery before Run.
+type Generator struct {
+	Env          *model.Environment
+	XDSUpdater   model.XDSUpdater
+	Discovery    *aggregate.Controller
+	ServiceEntry *serviceentry.Controller
+
+	configStore model.ConfigStoreController
+	configGen   *core.ConfigGeneratorImpl
+	stop        chan struct{}
+}
+
+// GeneratedConfig is the configuration generated for a proxy.
+type GeneratedConfig struct {
+	Listeners []*listener.Listener
+	Clusters  []*cluster.Cluster
+	Routes    []*route.RouteConfiguration
+}
+
+// NewGenerator returns a generator for the configs of the store. It must be closed once done.
+func NewGenerator(opts GeneratorOptions) *Generator {
+	meshConfig := opts.MeshConfig
+	if meshConfig == nil {
+		meshConfig = mesh.DefaultMeshConfig()
+	}
+	env := model.NewEnvironment()
+	env.Watcher = mesh.NewFixedWatcher(meshConfig)
+	env.NetworksWatcher = mesh.NewFixedNetworksWatcher(nil)
+	xdsUpdater := model.NewEndpointIndexUpdater(env.EndpointIndex)
+
+	se := serviceentry.NewController(opts.ConfigStore, xdsUpdater, env.Watcher, serviceentry.WithClusterID(opts.ClusterID))
+	discovery := aggregate.NewController(aggregate.Options{MeshHolder: env.Watcher})
+	discovery.AddRegistry(se)
+
+	env.ServiceDiscovery = discovery
+	env.ConfigStore = opts.ConfigStore
+	env.GatewayAPIController = opts.GatewayAPIController
+	env.Init()
+	return &Generator{
+		Env:          env,
+		XDSUpdater:   xdsUpdater,
+		Discovery:    discovery,
+		ServiceEntry: se,
+		configStore:  opts.ConfigStore,
+		configGen:    core.NewConfigGenerator(&model.DisabledCache{}),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Run runs the service registries and the config store until the generator is closed. The ServiceEntry registry
+// processes endpoint updates in a queue, so configs must be created once it runs.
+func (g *Generator) Run() {
+	go g.Discovery.Run(g.stop)
+	go g.configStore.Run(g.stop)
+}
+
+// Stop returns a channel closed when the generator is closed.
+func (g *Generator) Stop() <-chan struct{} {
+	return g.stop
+}
+
+// Sync waits until the service registries and the config store are synced, then initializes the endpoints of the
+// ServiceEntries and the networks.
+func (g *Generator) Sync() error {
+	if !kube.WaitForCacheSync("simulation", g.stop, g.configStore.HasSynced, g.Discovery.HasSynced) {
+		return fmt.Errorf("failed to sync the service registries")
+	}
+	g.ServiceEntry.ResyncEDS()
+	return g.Env.InitNetworksManager(g.XDSUpdater)
+}
+
+// Close stops the service registries and the config store.
+func (g *Generator) Close() {
+	close(g.stop)
+}
+
+// Push computes the push context for the current configs and services.
+func (g *Generator) Push() (*model.PushContext, error) {
+	push := model.NewPushContext()
+	if err := push.InitContext(g.Env, nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize push context: %v", err)
+	}
+	g.Env.SetPushContext(push)
+	return push, nil
+}
+
+// InitProxy initializes the proxy for the push context, as done when it connects.
+func (g *Generator) InitProxy(proxy *model.Proxy, push *model.PushContext) {
+	proxy.SetSidecarScope(push)
+	proxy.SetServiceTargets(g.Env.ServiceDiscovery)
+	proxy.SetGatewaysForProxy(push)
+	proxy.DiscoverIPMode()
+}
+
+// Generate returns the configuration generated for the proxy, which is initialized for the push context.
+func (g *Generator) Generate(proxy *model.Proxy, push *model.PushContext) (GeneratedConfig, error) {
+	var out GeneratedConfig
+	req := &model.PushRequest{Push: push, Full: true}
+	out.Listeners = g.configGen.BuildListeners(proxy, push)
+	clusters, _ := g.configGen.BuildClusters(proxy, req)
+	for _, r := range clusters {
+		c := &cluster.Cluster{}
+		if err := r.Resource.UnmarshalTo(c); err != nil {
+			return out, err
+		}
+		out.Clusters = append(out.Clusters, c)
+	}
+	routes, _ := g.configGen.BuildHTTPRoutes(proxy, req, core.ExtractRoutesFromListeners(out.Listeners))
+	for _, r := range routes {
+		rc := &route.RouteConfiguration{}
+		if err := r.Resource.UnmarshalTo(rc); err != nil {
+			return out, err
+		}
+		out.Routes = append(out.Routes, rc)
+	}
+	return out, nil
+}
@@ -30,22 +30,24 @@ import (
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
-	"istio.io/istio/pilot/test/xds"
-	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config/host"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/slices"
-	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/wellknown"
 )
 
 var log = istiolog.RegisterScope("simulation", "")
@@ -176,7 +178,6 @@ type Result struct {
 	// if we pass the test. This is to ensure that if the behavior changes, we still capture it; the skip
 	// just ensures we notice a test is wrong
 	Skip string
-	t    test.Failer
 }
 
 func (r Result) Matches(t *testing.T, want Result) {
@@ -232,6 +233,7 @@ func (r Result) Matches(t *testing.T, want Result) {
 }
 
 type Simulation struct {
+	// t is only set for simulations created by tests, which can run expectations.
 	t         *testing.T
 	Listeners []*listener.Listener
 	Clusters  []*cluster.Cluster
@@ -249,8 +251,14 @@ func NewSimulationFromConfigGen(t *testing.T, s *core.ConfigGenTest, proxy *mode
 	return sim
 }
 
-func NewSimulation(t *testing.T, s *xds.FakeDiscoveryServer, proxy *model.Proxy) *Simulation {
-	return NewSimulationFromConfigGen(t, s.ConfigGenTest, proxy)
+// NewSimulationFromResources returns a simulation of the configuration generated for a proxy outside of tests.
+// Requests are simulated with Run; RunExpectations is not supported.
+func NewSimulationFromResources(listeners []*listener.Listener, clusters []*cluster.Cluster, routes []*route.RouteConfiguration) *Simulation {
+	return &Simulation{
+		Listeners: listeners,
+		Clusters:  clusters,
+		Routes:    routes,
+	}
 }
 
 // withT swaps out the testing struct. This allows executing sub tests.
@@ -269,18 +277,59 @@ func (sim *Simulation) RunExpectations(es []Expect) {
 }
 
 func hasFilterOnPort(l *listener.Listener, filter string, port int) bool {
-	got, f := xdstest.ExtractListenerFilters(l)[filter]
-	if !f {
+	got := slices.FindFunc(l.GetListenerFilters(), func(lf *listener.ListenerFilter) bool {
+		return lf.GetName() == filter
+	})
+	if got == nil {
 		return false
 	}
-	if got.FilterDisabled == nil {
+	if (*got).FilterDisabled == nil {
+		return true
+	}
+	return !evaluateListenerFilterPredicates((*got).FilterDisabled, port)
+}
+
+// evaluateListenerFilterPredicates returns whether the port matches the predicate. Unsupported rules do not match.
+func evaluateListenerFilterPredicates(predicate *listener.ListenerFilterChainMatchPredicate, port int) bool {
+	if predicate == nil {
 		return true
 	}
-	return !xdstest.EvaluateListenerFilterPredicates(got.FilterDisabled, port)
+	switch r := predicate.Rule.(type) {
+	case *listener.ListenerFilterChainMatchPredicate_NotMatch:
+		return !evaluateListenerFilterPredicates(r.NotMatch, port)
+	case *listener.ListenerFilterChainMatchPredicate_OrMatch:
+		matches := false
+		for _, r := range r.OrMatch.Rules {
+			matches = matches || evaluateListenerFilterPredicates(r, port)
+		}
+		return matches
+	case *listener.ListenerFilterChainMatchPredicate_DestinationPortRange:
+		return int32(port) >= r.DestinationPortRange.GetStart() && int32(port) < r.DestinationPortRange.GetEnd()
+	default:
+		return false
+	}
+}
+
+// networkFilter returns the typed config of the network filter of the given name in the filter chain, or nil if there
+// is none.
+func networkFilter[T proto.Message](fc *listener.FilterChain, name string, config T) (T, error) {
+	var empty T
+	for _, f := range fc.GetFilters() {
+		if f.GetName() != name {
+			continue
+		}
+		if f.GetTypedConfig() != nil {
+			if err := f.GetTypedConfig().UnmarshalTo(config); err != nil {
+				return empty, fmt.Errorf("failed to unmarshal %s: %v", name, err)
+			}
+		}
+		return config, nil
+	}
+	return empty, nil
 }
 
+// Run simulates the call. Configuration the simulation does not support is reported as the result error.
 func (sim *Simulation) Run(input Call) (result Result) {
-	result = Result{t: sim.t}
 	input = input.FillDefaults()
 	if input.Alpn != "" && input.TLS == Plaintext {
 		result.Error = fmt.Errorf("invalid call, ALPN can only be sent in TLS requests")
@@ -327,7 +376,12 @@ func (sim *Simulation) Run(input Call) (result Result) {
 	}
 
 	// mTLS listener will only accept mTLS traffic
-	if fc.TransportSocket != nil && sim.requiresMTLS(fc, mTLSSecretConfigName) != (input.TLS == MTLS) {
+	requiresMTLS, err := sim.requiresMTLS(fc, mTLSSecretConfigName)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if fc.TransportSocket != nil && requiresMTLS != (input.TLS == MTLS) {
 		// If there is no tls inspector, then
 		result.Error = ErrMTLSError
 		return
@@ -341,7 +395,17 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		}
 	}
 
-	if hcm := xdstest.ExtractHTTPConnectionManager(sim.t, fc); hcm != nil {
+	h, err := networkFilter(fc, wellknown.HTTPConnectionManager, &hcm.HttpConnectionManager{})
+	if err != nil {
+		result.Error = err
+		return
+	}
+	tcp, err := networkFilter(fc, wellknown.TCPProxy, &tcpproxy.TcpProxy{})
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if h != nil {
 		// We matched HCM and didn't terminate TLS, but we are sending TLS traffic - decoding will fail
 		if input.TLS != Plaintext && fc.TransportSocket == nil {
 			result.Error = ErrProtocolError
@@ -354,12 +418,16 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		}
 
 		// Fetch inline route
-		rc := hcm.GetRouteConfig()
+		rc := h.GetRouteConfig()
 		if rc == nil {
 			// If not set, fallback to RDS
-			routeName := hcm.GetRds().RouteConfigName
+			routeName := h.GetRds().RouteConfigName
 			result.RouteConfigMatched = routeName
-			rc = xdstest.ExtractRouteConfigurations(sim.Routes)[routeName]
+			if found := slices.FindFunc(sim.Routes, func(rc *route.RouteConfiguration) bool {
+				return rc.GetName() == routeName
+			}); found != nil {
+				rc = *found
+			}
 		}
 		hostHeader := ""
 		if len(input.Headers["Host"]) > 0 {
@@ -376,7 +444,11 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			return
 		}
 
-		r := sim.matchRoute(vh, input)
+		r, err := sim.matchRoute(vh, input)
+		if err != nil {
+			result.Error = err
+			return
+		}
 		if r == nil {
 			result.Error = ErrNoRoute
 			return
@@ -386,35 +458,35 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		case *route.Route_Route:
 			result.ClusterMatched = t.Route.GetCluster()
 		}
-	} else if tcp := xdstest.ExtractTCPProxy(sim.t, fc); tcp != nil {
+	} else if tcp != nil {
 		result.ClusterMatched = tcp.GetCluster()
 	}
 	return
 }
 
-func (sim *Simulation) requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) bool {
+func (sim *Simulation) requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) (bool, error) {
 	if fc.TransportSocket == nil {
-		return false
+		return false, nil
 	}
 	t := &tls.DownstreamTlsContext{}
 	if err := fc.GetTransportSocket().GetTypedConfig().UnmarshalTo(t); err != nil {
-		sim.t.Fatal(err)
+		return false, err
 	}
 
 	if len(t.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()) == 0 {
-		return false
+		return false, nil
 	}
 	// This is a lazy heuristic, we could check for explicit default resource or spiffe if it becomes necessary
 	if t.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()[0].Name != mTLSSecretConfigName {
-		return false
+		return false, nil
 	}
 	if !t.RequireClientCertificate.Value {
-		return false
+		return false, nil
 	}
-	return true
+	return true, nil
 }
 
-func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Route {
+func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) (*route.Route, error) {
 	for _, r := range vh.Routes {
 		// check path
 		switch pt := r.Match.GetPathSpecifier().(type) {
@@ -433,18 +505,97 @@ func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Rout
 		case *route.RouteMatch_SafeRegex:
 			r, err := regexp.Compile(pt.SafeRegex.GetRegex())
 			if err != nil {
-				sim.t.Fatalf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
+				return nil, fmt.Errorf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
 			}
 			if !r.MatchString(input.Path) {
 				continue
 			}
 		default:
-			sim.t.Fatalf("unknown route path type %T", pt)
+			return nil, fmt.Errorf("unknown route path type %T", pt)
+		}
+
+		matched, err := sim.matchHeaders(r.Match.GetHeaders(), input)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
 		}
 
-		// TODO this only handles path - we need to add headers, query params, etc to be complete.
+		// TODO this only handles path and headers - we need to add query params, etc to be complete.
 
-		return r
+		return r, nil
+	}
+	return nil, nil
+}
+
+func (sim *Simulation) matchHeaders(matchers []*route.HeaderMatcher, input Call) (bool, error) {
+	for _, hm := range matchers {
+		values := headerValues(input, hm.GetName())
+		var matched bool
+		switch ms := hm.GetHeaderMatchSpecifier().(type) {
+		case *route.HeaderMatcher_PresentMatch:
+			matched = (len(values) > 0) == ms.PresentMatch
+		case *route.HeaderMatcher_StringMatch:
+			for _, v := range values {
+				m, err := sim.matchString(ms.StringMatch, v)
+				if err != nil {
+					return false, err
+				}
+				if m {
+					matched = true
+					break
+				}
+			}
+		default:
+			return false, fmt.Errorf("unknown header match type %T", ms)
+		}
+		if matched == hm.GetInvertMatch() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (sim *Simulation) matchString(m *matcher.StringMatcher, v string) (bool, error) {
+	if m.GetIgnoreCase() {
+		v = strings.ToLower(v)
+	}
+	lower := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch pt := m.GetMatchPattern().(type) {
+	case *matcher.StringMatcher_Exact:
+		return v == lower(pt.Exact), nil
+	case *matcher.StringMatcher_Prefix:
+		return strings.HasPrefix(v, lower(pt.Prefix)), nil
+	case *matcher.StringMatcher_Suffix:
+		return strings.HasSuffix(v, lower(pt.Suffix)), nil
+	case *matcher.StringMatcher_Contains:
+		return strings.Contains(v, lower(pt.Contains)), nil
+	case *matcher.StringMatcher_SafeRegex:
+		r, err := regexp.Compile("^(?:" + pt.SafeRegex.GetRegex() + ")$")
+		if err != nil {
+			return false, fmt.Errorf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
+		}
+		return r.MatchString(v), nil
+	default:
+		return false, fmt.Errorf("unknown string match type %T", pt)
+	}
+}
+
+// headerValues looks up a header case-insensitively, mapping the :authority pseudo-header to Host.
+func headerValues(input Call, name string) []string {
+	if name == ":authority" {
+		name = "Host"
+	}
+	for k, v := range input.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
 	}
 	return nil
 }
@@ -516,24 +667,37 @@ func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host strin
 func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultChain *listener.FilterChain,
 	input Call, hasTLSInspector bool,
 ) (*listener.FilterChain, error) {
+	address, addressErr := netip.ParseAddr(input.Address)
+	// cidrErr is the first error matching the address against the prefix ranges of the filter chains.
+	var cidrErr error
 	chains = filter("DestinationPort", chains, (*listener.FilterChainMatch).GetDestinationPort, func(port *wrapperspb.UInt32Value) bool {
 		return int(port.GetValue()) == input.Port
 	})
 	chains = filterRank("PrefixRanges", chains, (*listener.FilterChainMatch).GetPrefixRanges, func(ranges []*envoycore.CidrRange) int {
+		if addressErr != nil {
+			cidrErr = fmt.Errorf("invalid address %v: %v", input.Address, addressErr)
+			return 0
+		}
 		best := 0
 		for _, a := range ranges {
 			s := fmt.Sprintf("%s/%d", a.AddressPrefix, a.GetPrefixLen().GetValue())
 			cidr, err := netip.ParsePrefix(s)
 			if err != nil {
-				sim.t.Fatalf("failed to parse cidr %v: %v", s, err)
+				if cidrErr == nil {
+					cidrErr = fmt.Errorf("failed to parse cidr %v: %v", s, err)
+				}
+				continue
 			}
-			if cidr.Contains(netip.MustParseAddr(input.Address)) {
+			if cidr.Contains(address) {
 				// Rank by how exact of a match it is. A /32 should match before a /8 even if they both match.
 				best = max(cidr.Bits(), best)
 			}
 		}
 		return best
 	})
+	if cidrErr != nil {
+		return nil, cidrErr
+	}
 	chains = filterRank("ServerNames", chains, (*listener.FilterChainMatch).GetServerNames, func(serverNames []string) int {
 		sni := host.Name(input.Sni)
 		best := 0
@@ -674,7 +838,12 @@ func protocolToAlpn(s Protocol) string {
 
 func matchListener(listeners []*listener.Listener, input Call) *listener.Listener {
 	if input.CallMode == CallModeInbound {
-		return xdstest.ExtractListener(model.VirtualInboundListenerName, listeners)
+		if l := slices.FindFunc(listeners, func(l *listener.Listener) bool {
+			return l.GetName() == model.VirtualInboundListenerName
+		}); l != nil {
+			return *l
+		}
+		return nil
 	}
 	// First find exact match for the IP/Port, then fallback to wildcard IP/Port
 	// There is no wildcard port
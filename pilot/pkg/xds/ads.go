@@ -84,6 +84,13 @@ type Connection struct {
 
 	s   *DiscoveryServer
 	ids []string
+
+	// pushRecords are the push history records of the push being sent to the connection, if any.
+	pushRecords []*pushRecord
+	// pushes are the last pushes sent to the connection.
+	pushes *proxyPushes
+	// sent are the validated resources last sent to the connection.
+	sent *sentResources
 }
 
 func (conn *Connection) XdsConnection() *xds.Connection {
@@ -101,11 +108,16 @@ type Event struct {
 
 	// function to call once a push is finished. This must be called or future changes may be blocked.
 	done func()
+
+	// records are the push history records of the pushes merged in the push request.
+	records []*pushRecord
 }
 
 func newConnection(peerAddr string, stream DiscoveryStream) *Connection {
 	return &Connection{
 		Connection: xds.NewConnection(peerAddr, stream),
+		pushes:     &proxyPushes{},
+		sent:       newSentResources(),
 	}
 }
 
@@ -152,6 +164,9 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 	}
 
 	shouldRespond, delta := xds.ShouldRespond(con.proxy, con.ID(), req)
+	if features.EnableXDSValidation {
+		s.trackRejection(con, req.TypeUrl, req.ErrorDetail != nil, req.ResponseNonce)
+	}
 	if !shouldRespond {
 		return nil
 	}
@@ -289,6 +304,7 @@ func (s *DiscoveryServer) closeConnection(con *Connection) {
 		return
 	}
 	s.removeCon(con.ID())
+	s.rejections.resolveConnection(con.ID())
 	s.WorkloadEntryController.OnDisconnect(con)
 }
 
@@ -480,9 +496,12 @@ func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) error {
 
 	if !s.ProxyNeedsPush(con.proxy, pushRequest) {
 		log.Debugf("Skipping push to %v, no updates required", con.ID())
+		con.recordSkipped(pushEv.records)
 		return nil
 	}
 
+	con.pushRecords = pushEv.records
+	defer func() { con.pushRecords = nil }()
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
 	wrl := con.watchedResourcesByOrder()
@@ -491,6 +510,7 @@ func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) error {
 			return err
 		}
 	}
+	con.recordPushed(pushEv.records)
 	proxiesConvergeDelay.Record(time.Since(pushRequest.Start).Seconds())
 	return nil
 }
@@ -558,6 +578,10 @@ func AdsPushAll(s *DiscoveryServer) {
 
 // AdsPushAll will send updates to all nodes, for a full config or incremental EDS.
 func (s *DiscoveryServer) AdsPushAll(req *model.PushRequest) {
+	s.adsPushAll(req, notDebounced())
+}
+
+func (s *DiscoveryServer) adsPushAll(req *model.PushRequest, debounced debounceInfo) {
 	if !req.Full {
 		log.Infof("XDS: Incremental Pushing ConnectedEndpoints:%d Version:%s",
 			s.adsClientCount(), req.Push.PushVersion)
@@ -573,11 +597,15 @@ func (s *DiscoveryServer) AdsPushAll(req *model.PushRequest) {
 		}
 	}
 
-	s.StartPush(req)
+	s.startPush(req, debounced)
 }
 
 // Send a signal to all connections, with a push event.
 func (s *DiscoveryServer) StartPush(req *model.PushRequest) {
+	s.startPush(req, notDebounced())
+}
+
+func (s *DiscoveryServer) startPush(req *model.PushRequest, debounced debounceInfo) {
 	// Push config changes, iterating over connected envoys.
 	if log.DebugEnabled() {
 		currentlyPending := s.pushQueue.Pending()
@@ -586,8 +614,10 @@ func (s *DiscoveryServer) StartPush(req *model.PushRequest) {
 		}
 	}
 	req.Start = time.Now()
-	for _, p := range s.AllClients() {
-		s.pushQueue.Enqueue(p, req)
+	clients := s.AllClients()
+	record := s.pushHistory.add(req, debounced, len(clients))
+	for _, p := range clients {
+		s.pushQueue.enqueue(p, req, record)
 	}
 }
 
@@ -142,6 +142,10 @@ type ResourceStatus struct {
 	Acked     string    `json:"acked,omitempty"`
 	SentTime  time.Time `json:"sentTime,omitempty"`
 	LastError string    `json:"lastError,omitempty"`
+	// Rejected are the names of the resources rejected with the last error, when they could be identified.
+	Rejected []string `json:"rejected,omitempty"`
+	// Invalid are the validation errors of the resources which were not sent because they failed validation, by name.
+	Invalid map[string]string `json:"invalid,omitempty"`
 }
 
 // SyncedVersions shows what resourceVersion of a given resource has been acked by Envoy.
@@ -207,11 +211,17 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/instancesz", "Debug support for service instances", s.instancesz)
 	s.addDebugHandler(mux, internalMux, "/debug/ambientz", "Debug support for ambient", s.ambientz)
 	s.addDebugHandler(mux, internalMux, "/debug/krtz", "Debug support for krt (internal state)", s.krtz)
+	s.addDebugHandler(mux, internalMux, "/debug/krtz?graph=json", "Dependency graph between krt collections, in JSON", s.krtz)
+	s.addDebugHandler(mux, internalMux, "/debug/krtz?graph=dot", "Dependency graph between krt collections, in DOT", s.krtz)
 
 	s.addDebugHandler(mux, internalMux, "/debug/authorizationz", "Internal authorization policies", s.authorizationz)
 	s.addDebugHandler(mux, internalMux, "/debug/telemetryz", "Debug Telemetry configuration", s.telemetryz)
 	s.addDebugHandler(mux, internalMux, "/debug/config_dump", "ConfigDump in the form of the Envoy admin config dump API for passed in proxyID", s.ConfigDump)
 	s.addDebugHandler(mux, internalMux, "/debug/push_status", "Last PushContext Details", s.pushStatusHandler)
+	s.addDebugHandler(mux, internalMux, "/debug/push_history", "Recent pushes with their triggers and outcome (proxyID to filter)",
+		s.pushHistoryHandler)
+	s.addDebugHandler(mux, internalMux, "/debug/push_impact", "Proxies which would be pushed if the candidate configs (POST body or config) were applied",
+		s.pushImpactHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/pushcontext", "Debug support for current push context", s.pushContextHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/connections", "Info about the connected XDS clients", s.connectionsHandler)
 
@@ -294,6 +304,8 @@ func (s *DiscoveryServer) Syncz(w http.ResponseWriter, req *http.Request) {
 					Acked:     wr.NonceAcked,
 					SentTime:  wr.LastSendTime,
 					LastError: wr.LastError,
+					Rejected:  wr.RejectedResources,
+					Invalid:   wr.InvalidResources,
 				}
 			}
 			syncz = append(syncz, SyncStatus{
@@ -854,6 +866,19 @@ func (s *DiscoveryServer) pushStatusHandler(w http.ResponseWriter, req *http.Req
 	_, _ = w.Write(out)
 }
 
+// pushHistoryHandler dumps the recent pushes, or the recent pushes sent to the proxy requested by proxyID
+func (s *DiscoveryServer) pushHistoryHandler(w http.ResponseWriter, req *http.Request) {
+	if proxyID, con := s.getDebugConnection(req); proxyID != "" {
+		if con == nil {
+			s.errorHandler(w, proxyID, con)
+			return
+		}
+		writeJSON(w, s.pushHistory.list(sets.New(con.pushes.list()...)), req)
+		return
+	}
+	writeJSON(w, s.pushHistory.list(nil), req)
+}
+
 // PushContextDebug holds debug information for push context.
 type PushContextDebug struct {
 	AuthorizationPolicies *model.AuthorizationPolicies
@@ -1083,7 +1108,18 @@ func (s *DiscoveryServer) ambientz(w http.ResponseWriter, req *http.Request) {
 }
 
 func (s *DiscoveryServer) krtz(w http.ResponseWriter, req *http.Request) {
-	writeJSON(w, krt.GlobalDebugHandler, req)
+	switch req.URL.Query().Get("graph") {
+	case "":
+		writeJSON(w, krt.GlobalDebugHandler, req)
+	case "json":
+		writeJSON(w, krt.GlobalDebugHandler.Graph(), req)
+	case "dot":
+		w.Header().Add("Content-Type", "text/vnd.graphviz")
+		_, _ = w.Write([]byte(krt.GlobalDebugHandler.Graph().DOT()))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("graph must be one of json or dot"))
+	}
 }
 
 func (s *DiscoveryServer) networkz(w http.ResponseWriter, req *http.Request) {
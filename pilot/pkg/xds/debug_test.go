@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -28,6 +29,7 @@ import (
 	"istio.io/istio/pilot/pkg/xds"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/test/util/assert"
 )
 
 func TestSyncz(t *testing.T) {
@@ -233,3 +235,93 @@ func TestDebugHandlers(t *testing.T) {
 		t.Errorf("Error in generatating debug endpoint list")
 	}
 }
+
+func TestPushImpact(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: example
+  namespace: ns-a
+spec:
+  hosts:
+  - example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+---
+apiVersion: networking.istio.io/v1
+kind: Sidecar
+metadata:
+  name: default
+  namespace: ns-b
+spec:
+  egress:
+  - hosts:
+    - ./*
+`})
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		ads := s.ConnectADS().
+			WithID(fmt.Sprintf("sidecar~1.1.1.1~app.%s~%s.svc.cluster.local", ns, ns)).
+			WithMetadata(model.NodeMetadata{Namespace: ns})
+		ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+		ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+	}
+
+	// The internal mux serves the debug handlers without authentication.
+	mux := s.Discovery.InitDebug(http.NewServeMux(), false, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/debug/push_impact?namespace=ns-a", strings.NewReader(`
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: example
+spec:
+  hosts:
+  - example.com
+  http:
+  - timeout: 5s
+    route:
+    - destination:
+        host: example.com
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: route
+spec:
+  rules:
+  - backendRefs:
+    - name: example
+      port: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wanted response code 200, got %v: %s", rr.Code, rr.Body.String())
+	}
+	got := xds.PushImpact{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, got, xds.PushImpact{
+		ConfigsUpdated: []string{"VirtualService/ns-a/example"},
+		Proxies:        []xds.ProxyPushImpact{{ID: got.Proxies[0].ID, Types: []string{"CDS", "LDS"}}},
+		ProxiesSkipped: 1,
+		Ignored:        []string{"HTTPRoute/route", "ConfigMap/ignored"},
+	})
+	// Connection IDs are suffixed by a connection counter.
+	assert.Equal(t, strings.HasPrefix(got.Proxies[0].ID, "app.ns-a-"), true)
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/push_impact", nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+}
@@ -162,9 +162,12 @@ func (s *DiscoveryServer) pushConnectionDelta(con *Connection, pushEv *Event) er
 
 	if !s.ProxyNeedsPush(con.proxy, pushRequest) {
 		deltaLog.Debugf("Skipping push to %v, no updates required", con.ID())
+		con.recordSkipped(pushEv.records)
 		return nil
 	}
 
+	con.pushRecords = pushEv.records
+	defer func() { con.pushRecords = nil }()
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
 	wrl := con.watchedResourcesByOrder()
@@ -173,6 +176,7 @@ func (s *DiscoveryServer) pushConnectionDelta(con *Connection, pushEv *Event) er
 			return err
 		}
 	}
+	con.recordPushed(pushEv.records)
 
 	proxiesConvergeDelay.Record(time.Since(pushRequest.Start).Seconds())
 	return nil
@@ -282,6 +286,9 @@ func (s *DiscoveryServer) processDeltaRequest(req *discovery.DeltaDiscoveryReque
 	}
 
 	shouldRespond := s.shouldRespondDelta(con, req)
+	if features.EnableXDSValidation {
+		s.trackRejection(con, req.TypeUrl, req.ErrorDetail != nil, req.ResponseNonce)
+	}
 	if !shouldRespond {
 		return nil
 	}
@@ -363,6 +370,7 @@ func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery
 		xds.IncrementXDSRejects(request.TypeUrl, con.proxy.ID, errCode.String())
 		con.proxy.UpdateWatchedResource(request.TypeUrl, func(wr *model.WatchedResource) *model.WatchedResource {
 			wr.LastError = request.ErrorDetail.GetMessage()
+			wr.RejectedResources = xds.RejectedResourceNames(wr.LastError, wr.ResourceNames)
 			return wr
 		})
 		return false
@@ -422,6 +430,7 @@ func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery
 			// Clear last error, we got an ACK.
 			// Otherwise, this is just a change in resource subscription, so leave the last ACK info in place.
 			wr.LastError = ""
+			wr.RejectedResources = nil
 			wr.NonceAcked = request.ResponseNonce
 		}
 		wr.ResourceNames = currentResources
@@ -505,6 +514,10 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 	if err != nil || (res == nil && deletedRes == nil) {
 		return err
 	}
+	var invalid []string
+	if features.EnableXDSValidation {
+		res, invalid = s.validatePush(con, w.TypeUrl, res, deletedRes, !usedDelta && req.Delta.IsEmpty())
+	}
 	defer func() { recordPushTime(w.TypeUrl, time.Since(t0)) }()
 	resp := &discovery.DeltaDiscoveryResponse{
 		ControlPlane: ControlPlane(),
@@ -522,7 +535,8 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 	} else if req.Full {
 		// similar to sotw
 		subscribed := sets.New(w.ResourceNames...)
-		removed := subscribed.DeleteAll(currentResources...)
+		// The invalid resources are not sent, but the proxy keeps their current version.
+		removed := subscribed.DeleteAll(currentResources...).DeleteAll(invalid...)
 		resp.RemovedResources = sets.SortedList(removed)
 	}
 	var newResourceNames []string
@@ -532,9 +546,10 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 			// Apply the delta
 			newResourceNames = sets.SortedList(sets.New(w.ResourceNames...).
 				DeleteAll(resp.RemovedResources...).
-				InsertAll(currentResources...))
+				InsertAll(currentResources...).
+				InsertAll(invalid...))
 		} else {
-			newResourceNames = currentResources
+			newResourceNames = append(currentResources, invalid...)
 		}
 	}
 	if neverRemoveDelta(w.TypeUrl) {
@@ -570,6 +585,8 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 		}
 		return err
 	}
+	con.recordPushedType(w.TypeUrl, len(res), configSize, time.Since(t0))
+	s.recordSent(con, w.TypeUrl, resp.Nonce, res, true)
 
 	switch {
 	case !req.Full:
@@ -620,6 +637,8 @@ func newDeltaConnection(peerAddr string, stream DeltaDiscoveryStream) *Connectio
 		Connection:   xds.NewConnection(peerAddr, nil),
 		deltaStream:  stream,
 		deltaReqChan: make(chan *discovery.DeltaDiscoveryRequest, 1),
+		pushes:       &proxyPushes{},
+		sent:         newSentResources(),
 	}
 }
 
@@ -97,6 +97,14 @@ type DiscoveryServer struct {
 	// pushQueue is the buffer that used after debounce and before the real xds push.
 	pushQueue *PushQueue
 
+	// pushHistory records the last pushes, for debugging.
+	pushHistory *pushHistory
+
+	// rejections tracks the Istio configs whose generated resources are rejected by the proxies.
+	rejections *configRejections
+	// rejectionStatus writes the rejections of Istio configs as a status condition, if set.
+	rejectionStatus *atomic.Pointer[rejectionStatusWriter]
+
 	// debugHandlers is the list of all the supported debug handlers.
 	debugHandlers map[string]string
 
@@ -146,6 +154,8 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		CommittedUpdates:    atomic.NewInt64(0),
 		pushChannel:         make(chan *model.PushRequest, 10),
 		pushQueue:           NewPushQueue(),
+		pushHistory:         newPushHistory(features.PushHistorySize),
+		rejectionStatus:     atomic.NewPointer[rejectionStatusWriter](nil),
 		debugHandlers:       map[string]string{},
 		adsClients:          map[string]*Connection{},
 		DebounceOptions: DebounceOptions{
@@ -157,6 +167,8 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		DiscoveryStartTime: processStartTime,
 	}
 
+	out.rejections = newConfigRejections(out.writeRejectionStatus)
+
 	out.ClusterAliases = make(map[cluster.ID]cluster.ID)
 	for alias := range clusterAliases {
 		out.ClusterAliases[cluster.ID(alias)] = cluster.ID(clusterAliases[alias])
@@ -252,10 +264,14 @@ func (s *DiscoveryServer) dropCacheForRequest(req *model.PushRequest) {
 
 // Push is called to push changes on config updates using ADS.
 func (s *DiscoveryServer) Push(req *model.PushRequest) {
+	s.push(req, notDebounced())
+}
+
+func (s *DiscoveryServer) push(req *model.PushRequest, debounced debounceInfo) {
 	if !req.Full {
 		req.Push = s.globalPushContext()
 		s.dropCacheForRequest(req)
-		s.AdsPushAll(req)
+		s.adsPushAll(req, debounced)
 		return
 	}
 	// Reset the status during the push.
@@ -278,7 +294,7 @@ func (s *DiscoveryServer) Push(req *model.PushRequest) {
 	pushContextInitTime.Record(initContextTime.Seconds())
 
 	req.Push = push
-	s.AdsPushAll(req)
+	s.adsPushAll(req, debounced)
 }
 
 func nonce(noncePrefix string) string {
@@ -323,11 +339,13 @@ func (s *DiscoveryServer) ConfigUpdate(req *model.PushRequest) {
 // It ensures that at minimum minQuiet time has elapsed since the last event before processing it.
 // It also ensures that at most maxDelay is elapsed between receiving an event and processing it.
 func (s *DiscoveryServer) handleUpdates(stopCh <-chan struct{}) {
-	debounce(s.pushChannel, stopCh, s.DebounceOptions, s.Push, s.CommittedUpdates)
+	debounce(s.pushChannel, stopCh, s.DebounceOptions, s.push, s.CommittedUpdates)
 }
 
 // The debounce helper function is implemented to enable mocking
-func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceOptions, pushFn func(req *model.PushRequest), updateSent *atomic.Int64) {
+func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceOptions,
+	pushFn func(req *model.PushRequest, debounced debounceInfo), updateSent *atomic.Int64,
+) {
 	var timeChan <-chan time.Time
 	var startDebounce time.Time
 	var lastConfigUpdateTime time.Time
@@ -342,7 +360,7 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 	freeCh := make(chan struct{}, 1)
 
 	push := func(req *model.PushRequest, debouncedEvents int, startDebounce time.Time) {
-		pushFn(req)
+		pushFn(req, debounceInfo{events: debouncedEvents, start: startDebounce})
 		updateSent.Add(int64(debouncedEvents))
 		debounceTime.Record(time.Since(startDebounce).Seconds())
 		freeCh <- struct{}{}
@@ -387,7 +405,7 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 			if !opts.enableEDSDebounce && !r.Full {
 				// trigger push now, just for EDS
 				go func(req *model.PushRequest) {
-					pushFn(req)
+					pushFn(req, notDebounced())
 					updateSent.Inc()
 				}(r)
 				continue
@@ -464,10 +482,11 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 			semaphore <- struct{}{}
 
 			// Get the next proxy to push. This will block if there are no updates required.
-			client, push, shuttingdown := queue.Dequeue()
+			client, pending, shuttingdown := queue.dequeue()
 			if shuttingdown {
 				return
 			}
+			push := pending.request
 			recordPushTriggers(push.Reason)
 			// Signals that a push is done by reading from the semaphore, allowing another send on it.
 			doneFunc := func() {
@@ -475,7 +494,7 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 				<-semaphore
 			}
 
-			proxiesQueueTime.Record(time.Since(push.Start).Seconds())
+			proxiesQueueTime.With(classTag.Value(pending.class.String())).Record(time.Since(pending.queued).Seconds())
 			var closed <-chan struct{}
 			if client.deltaStream != nil {
 				closed = client.deltaStream.Context().Done()
@@ -486,6 +505,7 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 				pushEv := &Event{
 					pushRequest: push,
 					done:        doneFunc,
+					records:     pending.records,
 				}
 
 				select {
@@ -24,11 +24,13 @@ import (
 	"time"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	dto "github.com/prometheus/client_model/go"
 	uatomic "go.uber.org/atomic"
 	"google.golang.org/grpc"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/monitoring/monitortest"
 	"istio.io/istio/pkg/test/util/retry"
 	"istio.io/istio/pkg/util/sets"
 )
@@ -162,6 +164,32 @@ func TestSendPushesSinglePush(t *testing.T) {
 	}
 }
 
+func TestSendPushesQueueTime(t *testing.T) {
+	mt := monitortest.New(t)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	queue := newPushQueue(true, time.Hour)
+	defer queue.ShutDown()
+	con := createProxies(1)[0]
+	con.proxy = &model.Proxy{Type: model.Router}
+
+	// The push started long before the connection was queued, which is not counted as time in the queue.
+	queue.Enqueue(con, &model.PushRequest{Full: true, Start: time.Now().Add(-time.Hour)})
+	go doSendPushes(stopCh, make(chan struct{}, 1), queue)
+	(<-con.PushCh()).(*Event).done()
+
+	mt.Assert("pilot_proxy_queue_time", map[string]string{"class": "gateway"}, func(v any) error {
+		d := v.(*dto.Histogram)
+		if d.GetSampleCount() == 0 {
+			return fmt.Errorf("no samples")
+		}
+		if d.GetSampleSum() >= time.Minute.Seconds() {
+			return fmt.Errorf("want a queue time of less than a minute, got %vs", d.GetSampleSum())
+		}
+		return nil
+	})
+}
+
 type fakeStream struct {
 	grpc.ServerStream
 }
@@ -272,7 +300,7 @@ func TestDebounce(t *testing.T) {
 
 			wg := sync.WaitGroup{}
 
-			fakePush := func(req *model.PushRequest) {
+			fakePush := func(req *model.PushRequest, _ debounceInfo) {
 				if req.Full {
 					select {
 					case pushingCh <- struct{}{}:
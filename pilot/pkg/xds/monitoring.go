@@ -28,6 +28,7 @@ import (
 var (
 	typeTag    = monitoring.CreateLabel("type")
 	versionTag = monitoring.CreateLabel("version")
+	classTag   = monitoring.CreateLabel("class")
 
 	monServices = monitoring.NewGauge(
 		"pilot_services",
@@ -74,7 +75,7 @@ var (
 
 	proxiesQueueTime = monitoring.NewDistribution(
 		"pilot_proxy_queue_time",
-		"Time in seconds, a proxy is in the push queue before being dequeued.",
+		"Time in seconds, a proxy is in the push queue before being dequeued, labeled by class of the push.",
 		[]float64{.1, .5, 1, 3, 5, 10, 20, 30},
 	)
 
@@ -94,6 +95,11 @@ var (
 		"Number of errors (timeouts) initiating push context.",
 	)
 
+	xdsValidationFailures = monitoring.NewSum(
+		"pilot_xds_validation_failures",
+		"Total number of pushes with generated resources left out because they failed the pre-push validation.",
+	)
+
 	inboundUpdates = monitoring.NewSum(
 		"pilot_inbound_updates",
 		"Total number of updates received by pilot.",
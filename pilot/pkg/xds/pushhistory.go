@@ -0,0 +1,238 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	// maxRecordedConfigs is the maximum number of updated configs recorded for a push.
+	maxRecordedConfigs = 100
+	// maxProxyPushes is the number of pushes remembered for each connected proxy.
+	maxProxyPushes = 10
+)
+
+// debounceInfo describes the updates merged in a push while debouncing.
+type debounceInfo struct {
+	// events is the number of merged updates.
+	events int
+	// start is the time of the first of them.
+	start time.Time
+}
+
+// notDebounced returns the debounceInfo of a push which was not debounced.
+func notDebounced() debounceInfo {
+	return debounceInfo{events: 1, start: time.Now()}
+}
+
+// PushRecord describes a push: the updates which triggered it, and its outcome on the connected proxies.
+// Proxies are pushed once for all the pushes merged while they are queued, which are all accounted for this push.
+type PushRecord struct {
+	// ID identifies the push in the history.
+	ID uint64 `json:"id"`
+	// Version is the version of the push context used by the push.
+	Version string `json:"version"`
+	Full    bool   `json:"full"`
+	// Reasons are the reasons of the updates which triggered the push, with their count.
+	Reasons model.ReasonStats `json:"reasons,omitempty"`
+	// ConfigsUpdated are the first updated configs, sorted. All configs are considered updated if there are none.
+	ConfigsUpdated      []string `json:"configsUpdated,omitempty"`
+	ConfigsUpdatedCount int      `json:"configsUpdatedCount"`
+	// DebouncedEvents is the number of updates merged while debouncing, starting at DebounceStart.
+	DebouncedEvents int       `json:"debouncedEvents"`
+	DebounceStart   time.Time `json:"debounceStart"`
+	Start           time.Time `json:"start"`
+	// ProxiesQueued is the number of proxies the push was queued for. Of these, ProxiesPushed were pushed, and
+	// ProxiesSkipped were not as the updates do not affect them. The others are still queued, or failed.
+	ProxiesQueued  int `json:"proxiesQueued"`
+	ProxiesPushed  int `json:"proxiesPushed"`
+	ProxiesSkipped int `json:"proxiesSkipped"`
+	// Types are the statistics of the pushed resources, by type.
+	Types map[string]*PushTypeStats `json:"types,omitempty"`
+}
+
+// PushTypeStats are the statistics of the resources of a type sent for a push.
+type PushTypeStats struct {
+	// Pushes is the number of responses sent.
+	Pushes    int `json:"pushes"`
+	Resources int `json:"resources"`
+	Bytes     int `json:"bytes"`
+	// GenerationTime is the total time spent generating the resources, in nanoseconds.
+	GenerationTime time.Duration `json:"generationTime"`
+}
+
+// pushRecord is a PushRecord updated as the push is sent to the proxies.
+type pushRecord struct {
+	mu     sync.Mutex
+	record PushRecord
+}
+
+func (r *pushRecord) skipped() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.ProxiesSkipped++
+}
+
+func (r *pushRecord) pushed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.record.ProxiesPushed++
+}
+
+func (r *pushRecord) pushedType(typeURL string, resources, bytes int, generationTime time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := v3.GetShortType(typeURL)
+	if r.record.Types == nil {
+		r.record.Types = map[string]*PushTypeStats{}
+	}
+	stats := r.record.Types[t]
+	if stats == nil {
+		stats = &PushTypeStats{}
+		r.record.Types[t] = stats
+	}
+	stats.Pushes++
+	stats.Resources += resources
+	stats.Bytes += bytes
+	stats.GenerationTime += generationTime
+}
+
+// snapshot returns a copy of the record.
+func (r *pushRecord) snapshot() PushRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := r.record
+	out.Types = make(map[string]*PushTypeStats, len(r.record.Types))
+	for t, stats := range r.record.Types {
+		s := *stats
+		out.Types[t] = &s
+	}
+	return out
+}
+
+// pushHistory records the last pushes.
+type pushHistory struct {
+	mu sync.RWMutex
+	// records is a ring buffer of the last pushes. Once it is full, next is the index of the oldest one.
+	records []*pushRecord
+	next    int
+	lastID  uint64
+}
+
+func newPushHistory(size int) *pushHistory {
+	return &pushHistory{records: make([]*pushRecord, 0, max(size, 0))}
+}
+
+// add records a push queued for the given number of proxies. It returns nil if the history is disabled.
+func (h *pushHistory) add(req *model.PushRequest, debounced debounceInfo, proxies int) *pushRecord {
+	if cap(h.records) == 0 {
+		return nil
+	}
+	configs := slices.Sort(slices.Map(req.ConfigsUpdated.UnsortedList(), model.ConfigKey.String))
+	r := &pushRecord{record: PushRecord{
+		Full:                req.Full,
+		Reasons:             maps.Clone(req.Reason),
+		ConfigsUpdated:      configs[:min(len(configs), maxRecordedConfigs)],
+		ConfigsUpdatedCount: len(configs),
+		DebouncedEvents:     debounced.events,
+		DebounceStart:       debounced.start,
+		Start:               req.Start,
+		ProxiesQueued:       proxies,
+	}}
+	if req.Push != nil {
+		r.record.Version = req.Push.PushVersion
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	r.record.ID = h.lastID
+	if len(h.records) < cap(h.records) {
+		h.records = append(h.records, r)
+	} else {
+		h.records[h.next] = r
+		h.next = (h.next + 1) % len(h.records)
+	}
+	return r
+}
+
+// list returns the recorded pushes, oldest first. If ids is not nil, only the pushes with these IDs are returned.
+func (h *pushHistory) list(ids sets.Set[uint64]) []PushRecord {
+	h.mu.RLock()
+	records := append(slices.Clone(h.records[h.next:]), h.records[:h.next]...)
+	h.mu.RUnlock()
+
+	out := make([]PushRecord, 0, len(records))
+	for _, r := range records {
+		snapshot := r.snapshot()
+		if ids == nil || ids.Contains(snapshot.ID) {
+			out = append(out, snapshot)
+		}
+	}
+	return out
+}
+
+// proxyPushes are the IDs of the last pushes sent to a proxy.
+type proxyPushes struct {
+	mu  sync.Mutex
+	ids []uint64
+}
+
+func (p *proxyPushes) add(records []*pushRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, r := range records {
+		p.ids = append(p.ids, r.record.ID)
+	}
+	if len(p.ids) > maxProxyPushes {
+		p.ids = slices.Clone(p.ids[len(p.ids)-maxProxyPushes:])
+	}
+}
+
+func (p *proxyPushes) list() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.ids)
+}
+
+// recordSkipped records that the pushes were not sent to the connection, as they do not affect its proxy.
+func (conn *Connection) recordSkipped(records []*pushRecord) {
+	for _, r := range records {
+		r.skipped()
+	}
+}
+
+// recordPushed records that the pushes were sent to the connection.
+func (conn *Connection) recordPushed(records []*pushRecord) {
+	for _, r := range records {
+		r.pushed()
+	}
+	conn.pushes.add(records)
+}
+
+// recordPushedType records the resources of a type sent to the connection for the pushes being sent, if any.
+func (conn *Connection) recordPushedType(typeURL string, resources, bytes int, generationTime time.Duration) {
+	for _, r := range conn.pushRecords {
+		r.pushedType(typeURL, resources, bytes, generationTime)
+	}
+}
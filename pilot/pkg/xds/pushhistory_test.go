@@ -0,0 +1,118 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func recordIDs(records []PushRecord) []uint64 {
+	return slices.Map(records, func(r PushRecord) uint64 {
+		return r.ID
+	})
+}
+
+func TestPushHistory(t *testing.T) {
+	req := &model.PushRequest{
+		Full:   true,
+		Reason: model.NewReasonStats(model.ConfigUpdate),
+		ConfigsUpdated: sets.New(
+			model.ConfigKey{Kind: kind.VirtualService, Name: "b", Namespace: "default"},
+			model.ConfigKey{Kind: kind.DestinationRule, Name: "a", Namespace: "default"},
+		),
+		Push: &model.PushContext{PushVersion: "v1"},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		h := newPushHistory(0)
+		assert.Equal(t, h.add(req, notDebounced(), 1), nil)
+		assert.Equal(t, len(h.list(nil)), 0)
+	})
+
+	t.Run("record", func(t *testing.T) {
+		h := newPushHistory(10)
+		start := time.Now()
+		r := h.add(req, debounceInfo{events: 3, start: start}, 2)
+		r.pushed()
+		r.skipped()
+		r.pushedType(v3.ClusterType, 2, 100, time.Millisecond)
+		r.pushedType(v3.ClusterType, 1, 50, time.Millisecond)
+
+		got := h.list(nil)
+		assert.Equal(t, len(got), 1)
+		assert.Equal(t, got[0].ID, uint64(1))
+		assert.Equal(t, got[0].Version, "v1")
+		assert.Equal(t, got[0].Full, true)
+		assert.Equal(t, got[0].Reasons, model.NewReasonStats(model.ConfigUpdate))
+		assert.Equal(t, got[0].ConfigsUpdated, []string{"DestinationRule/default/a", "VirtualService/default/b"})
+		assert.Equal(t, got[0].ConfigsUpdatedCount, 2)
+		assert.Equal(t, got[0].DebouncedEvents, 3)
+		assert.Equal(t, got[0].DebounceStart, start)
+		assert.Equal(t, got[0].ProxiesQueued, 2)
+		assert.Equal(t, got[0].ProxiesPushed, 1)
+		assert.Equal(t, got[0].ProxiesSkipped, 1)
+		assert.Equal(t, got[0].Types, map[string]*PushTypeStats{
+			"CDS": {Pushes: 2, Resources: 3, Bytes: 150, GenerationTime: 2 * time.Millisecond},
+		})
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		h := newPushHistory(3)
+		for i := 0; i < 5; i++ {
+			h.add(req, notDebounced(), 1)
+		}
+		assert.Equal(t, recordIDs(h.list(nil)), []uint64{3, 4, 5})
+		assert.Equal(t, recordIDs(h.list(sets.New[uint64](2, 4))), []uint64{4})
+	})
+}
+
+func TestProxyPushes(t *testing.T) {
+	h := newPushHistory(maxProxyPushes * 2)
+	p := &proxyPushes{}
+	for i := 0; i < maxProxyPushes+2; i++ {
+		p.add([]*pushRecord{h.add(&model.PushRequest{}, notDebounced(), 1)})
+	}
+	assert.Equal(t, p.list(), []uint64{3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+}
+
+func TestPushQueueRecords(t *testing.T) {
+	h := newPushHistory(10)
+	con := newConnection("", nil)
+	p := NewPushQueue()
+	defer p.ShutDown()
+
+	first := h.add(&model.PushRequest{}, notDebounced(), 1)
+	second := h.add(&model.PushRequest{}, notDebounced(), 1)
+	p.enqueue(con, &model.PushRequest{}, first)
+	p.enqueue(con, &model.PushRequest{}, second)
+	p.Enqueue(con, &model.PushRequest{})
+	_, push, _ := p.dequeue()
+	assert.Equal(t, slices.Equal(push.records, []*pushRecord{first, second}), true)
+
+	// Pushes enqueued while the connection is processed are dequeued once it is done.
+	third := h.add(&model.PushRequest{}, notDebounced(), 1)
+	p.enqueue(con, &model.PushRequest{}, third)
+	p.MarkDone(con)
+	_, push, _ = p.dequeue()
+	assert.Equal(t, slices.Equal(push.records, []*pushRecord{third}), true)
+}
@@ -0,0 +1,229 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// PushImpact describes the pushes which would be sent to the connected proxies if candidate configs were applied.
+type PushImpact struct {
+	// ConfigsUpdated are the configs the candidate configs would update, sorted.
+	ConfigsUpdated []string `json:"configsUpdated"`
+	// Proxies are the connected proxies which would be pushed.
+	Proxies []ProxyPushImpact `json:"proxies"`
+	// ProxiesSkipped is the number of connected proxies which would not be pushed.
+	ProxiesSkipped int `json:"proxiesSkipped"`
+	// Ignored are the candidate resources of kinds which are not handled by the push context, and the candidate Gateway
+	// API resources. Reconciling the Gateway API resources updates the state of the Gateway API controller, so they are
+	// not previewed.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// ProxyPushImpact describes the push which would be sent to a proxy.
+type ProxyPushImpact struct {
+	ID string `json:"id"`
+	// Types are the types of the watched resources which would be pushed, in push order.
+	Types []string `json:"types"`
+}
+
+// candidateConfigStore is a config store in which candidate configs are applied, replacing the configs with the same
+// name, if any.
+type candidateConfigStore struct {
+	model.ConfigStore
+	candidates map[config.GroupVersionKind][]config.Config
+}
+
+func (s candidateConfigStore) Get(typ config.GroupVersionKind, name, namespace string) *config.Config {
+	for _, c := range s.candidates[typ] {
+		if c.Name == name && c.Namespace == namespace {
+			return &c
+		}
+	}
+	return s.ConfigStore.Get(typ, name, namespace)
+}
+
+func (s candidateConfigStore) List(typ config.GroupVersionKind, namespace string) []config.Config {
+	candidates := slices.Filter(s.candidates[typ], func(c config.Config) bool {
+		return namespace == model.NamespaceAll || c.Namespace == namespace
+	})
+	if len(candidates) == 0 {
+		return s.ConfigStore.List(typ, namespace)
+	}
+	replaced := sets.New(slices.Map(candidates, config.Config.NamespacedName)...)
+	out := slices.Filter(s.ConfigStore.List(typ, namespace), func(c config.Config) bool {
+		return !replaced.Contains(c.NamespacedName())
+	})
+	return append(out, candidates...)
+}
+
+// candidateConfigKeys returns the configs updated by the candidate configs, as they are notified to the discovery
+// server. Services are updated by hostname.
+func candidateConfigKeys(candidates []config.Config) sets.Set[model.ConfigKey] {
+	keys := sets.New[model.ConfigKey]()
+	for _, c := range candidates {
+		k := kind.MustFromGVK(c.GroupVersionKind)
+		if k == kind.ServiceEntry {
+			for _, h := range c.Spec.(*networking.ServiceEntry).Hosts {
+				keys.Insert(model.ConfigKey{Kind: kind.ServiceEntry, Name: h, Namespace: c.Namespace})
+			}
+			continue
+		}
+		keys.Insert(model.ConfigKey{Kind: k, Name: c.Name, Namespace: c.Namespace})
+	}
+	return keys
+}
+
+// typeNeedsPush returns whether the generator of the type would push the proxy for the request. The generators of
+// the types which are not listed push on every request.
+func typeNeedsPush(typeURL string, proxy *model.Proxy, req *model.PushRequest) bool {
+	switch typeURL {
+	case v3.ClusterType:
+		return cdsNeedsPush(req, proxy)
+	case v3.ListenerType:
+		return ldsNeedsPush(proxy, req)
+	case v3.RouteType:
+		return rdsNeedsPush(req)
+	case v3.EndpointType:
+		return edsNeedsPush(req.ConfigsUpdated)
+	case v3.SecretType:
+		return sdsNeedsPush(req.ConfigsUpdated)
+	case v3.ExtensionConfigurationType:
+		return ecdsNeedsPush(req)
+	case v3.NameTableType:
+		return ndsNeedsPush(req)
+	case v3.ProxyConfigType:
+		return pcdsNeedsPush(req)
+	}
+	return true
+}
+
+// pushImpact computes the pushes which would be sent to the connected proxies if the candidate configs were applied.
+// The push context is updated with the candidate configs as for a config update, so the proxies are checked against
+// both their current and their updated sidecar scope.
+func (s *DiscoveryServer) pushImpact(candidates []config.Config) (*PushImpact, error) {
+	store := candidateConfigStore{ConfigStore: s.Env.ConfigStore, candidates: map[config.GroupVersionKind][]config.Config{}}
+	for _, c := range candidates {
+		store.candidates[c.GroupVersionKind] = append(store.candidates[c.GroupVersionKind], c)
+	}
+	env := s.Env.WithConfigStore(store)
+	// The Gateway API resources are not reconciled, as this updates the state of the controller. The Istio resources
+	// the controller converted them to are still read from the config store.
+	env.GatewayAPIController = nil
+
+	req := &model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: candidateConfigKeys(candidates),
+		Reason:         model.NewReasonStats(model.ConfigUpdate),
+	}
+	push := model.NewPushContext()
+	if err := push.InitContext(env, s.globalPushContext(), req); err != nil {
+		return nil, err
+	}
+	req.Push = push
+
+	impact := &PushImpact{
+		ConfigsUpdated: slices.Sort(slices.Map(req.ConfigsUpdated.UnsortedList(), model.ConfigKey.String)),
+		Proxies:        []ProxyPushImpact{},
+	}
+	for _, con := range s.SortedClients() {
+		proxy := cloneProxy(con.proxy)
+		proxy.SetSidecarScope(push)
+		if !s.ProxyNeedsPush(proxy, req) {
+			impact.ProxiesSkipped++
+			continue
+		}
+		types := []string{}
+		for _, w := range con.watchedResourcesByOrder() {
+			if typeNeedsPush(w.TypeUrl, proxy, req) {
+				types = append(types, v3.GetShortType(w.TypeUrl))
+			}
+		}
+		impact.Proxies = append(impact.Proxies, ProxyPushImpact{ID: con.ID(), Types: types})
+	}
+	return impact, nil
+}
+
+// pushImpactHandler dumps the pushes which would be sent to the connected proxies if the candidate configs were
+// applied. The candidate configs are read from the request body, or from the config query parameter, and are
+// created in the namespace query parameter if they have none.
+func (s *DiscoveryServer) pushImpactHandler(w http.ResponseWriter, req *http.Request) {
+	input := req.URL.Query().Get("config")
+	if req.Method == http.MethodPost {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			handleHTTPError(w, err)
+			return
+		}
+		input = string(body)
+	}
+	if input == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("You must provide the candidate configs in the request body or the config query string\n"))
+		return
+	}
+	namespace := req.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	candidates, others, err := crd.ParseInputs(input)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error() + "\n"))
+		return
+	}
+	// Gateway API resources are not reconciled, see pushImpact.
+	gatewayAPI := slices.Filter(candidates, isGatewayAPI)
+	candidates = slices.FilterInPlace(candidates, func(c config.Config) bool {
+		return !isGatewayAPI(c)
+	})
+	for i, c := range candidates {
+		if schema, ok := collections.All.FindByGroupVersionKind(c.GroupVersionKind); ok && !schema.IsClusterScoped() && c.Namespace == "" {
+			candidates[i].Namespace = namespace
+		}
+	}
+
+	impact, err := s.pushImpact(candidates)
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	for _, c := range gatewayAPI {
+		impact.Ignored = append(impact.Ignored, fmt.Sprintf("%s/%s", c.GroupVersionKind.Kind, c.Name))
+	}
+	for _, o := range others {
+		impact.Ignored = append(impact.Ignored, fmt.Sprintf("%s/%s", o.Kind, o.Name))
+	}
+	writeJSON(w, impact, req)
+}
+
+// isGatewayAPI returns whether the config is a Gateway API resource, which is reconciled by the Gateway API controller.
+func isGatewayAPI(c config.Config) bool {
+	return c.GroupVersionKind.Group == gvk.KubernetesGateway.Group
+}
@@ -16,39 +16,184 @@ package xds
 
 import (
 	"sync"
+	"time"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 )
 
+// pushClass is the class of a queued push. When pushes are prioritized, classes are dequeued in order.
+type pushClass int
+
+const (
+	// gatewayPush is a push to a gateway, a waypoint or a ztunnel, which serve the traffic of many workloads.
+	gatewayPush pushClass = iota
+	// incrementalPush is an incremental push, such as an EDS update, to a sidecar.
+	incrementalPush
+	// fullPush is a full push to a sidecar.
+	fullPush
+	numPushClasses
+)
+
+func (c pushClass) String() string {
+	switch c {
+	case gatewayPush:
+		return "gateway"
+	case incrementalPush:
+		return "incremental"
+	default:
+		return "full"
+	}
+}
+
+// classifyPush returns the class of the push of the request to the connection.
+func classifyPush(con *Connection, request *model.PushRequest) pushClass {
+	if con.proxy != nil {
+		switch con.proxy.Type {
+		case model.Router, model.Waypoint, model.Ztunnel:
+			return gatewayPush
+		}
+	}
+	if !request.Full {
+		return incrementalPush
+	}
+	return fullPush
+}
+
 type PushQueue struct {
 	cond *sync.Cond
 
 	// pending stores all connections in the queue. If the same connection is enqueued again,
 	// the PushRequest will be merged.
-	pending map[*Connection]*model.PushRequest
+	pending map[*Connection]*pendingPush
+
+	// classes are the queues of the pending connections of each class. Connections are queued by namespace, and
+	// namespaces are dequeued in turn. If pushes are not prioritized, all connections are queued in a single queue.
+	classes [numPushClasses]namespaceQueue
 
-	// queue maintains ordering of the queue
-	queue []*Connection
+	// arrivals maintains the order in which connections were queued, to dequeue the connections queued for longer
+	// than maxWait first. It is only maintained if pushes are prioritized.
+	arrivals []queuedConnection
 
 	// processing stores all connections that have been Dequeue(), but not MarkDone().
 	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
-	// If model.PushRequest is not nil, it will be Enqueued again once MarkDone has been called.
-	processing map[*Connection]*model.PushRequest
+	// If pendingPush is not nil, it will be Enqueued again once MarkDone has been called.
+	processing map[*Connection]*pendingPush
+
+	// prioritized is true if the connections are dequeued by class.
+	prioritized bool
+	maxWait     time.Duration
+	// lastSeq is the last sequence number assigned to a queued connection.
+	lastSeq uint64
 
 	shuttingDown bool
 }
 
+// pendingPush is the push request queued for a connection, and the push history records of the pushes merged in it.
+type pendingPush struct {
+	request *model.PushRequest
+	records []*pushRecord
+
+	class pushClass
+	// queued is the time the connection was queued. Pushes merged while the connection is processed are queued once
+	// it is done.
+	queued time.Time
+	// seq identifies the entry of the connection in the queue of its class. Connections are queued again when their
+	// class changes, and their previous entry is then skipped.
+	seq uint64
+	// arrival identifies the entry of the connection in the arrivals.
+	arrival uint64
+}
+
+func (p *pendingPush) merge(con *Connection, request *model.PushRequest, record *pushRecord) *pendingPush {
+	if p == nil {
+		p = &pendingPush{}
+	}
+	p.request = p.request.CopyMerge(request)
+	p.class = classifyPush(con, p.request)
+	if record != nil {
+		p.records = append(p.records, record)
+	}
+	return p
+}
+
+// queuedConnection is an entry of a connection in a queue. It is skipped if it does not match the pending push of
+// the connection.
+type queuedConnection struct {
+	con *Connection
+	seq uint64
+}
+
+// namespaceQueue queues connections by namespace, and dequeues the namespaces in turn.
+type namespaceQueue struct {
+	queues map[string][]queuedConnection
+	// namespaces are the namespaces with queued connections, in the order they are dequeued.
+	namespaces []string
+}
+
+func (q *namespaceQueue) push(namespace string, c queuedConnection) {
+	if q.queues == nil {
+		q.queues = map[string][]queuedConnection{}
+	}
+	if _, f := q.queues[namespace]; !f {
+		q.namespaces = append(q.namespaces, namespace)
+	}
+	q.queues[namespace] = append(q.queues[namespace], c)
+}
+
+// pop removes the first connection for which valid returns true, skipping the others. It returns false if there is
+// none.
+func (q *namespaceQueue) pop(valid func(queuedConnection) bool) (queuedConnection, bool) {
+	for len(q.namespaces) > 0 {
+		ns := q.namespaces[0]
+		queue := q.queues[ns]
+		for len(queue) > 0 && !valid(queue[0]) {
+			queue[0] = queuedConnection{}
+			queue = queue[1:]
+		}
+		if len(queue) == 0 {
+			delete(q.queues, ns)
+			q.namespaces = q.namespaces[1:]
+			continue
+		}
+		c := queue[0]
+		// The underlying array will still exist, despite the slice changing, so the object may not GC without this
+		// See https://github.com/grpc/grpc-go/issues/4758
+		queue[0] = queuedConnection{}
+		q.namespaces = q.namespaces[1:]
+		if len(queue) > 1 {
+			q.queues[ns] = queue[1:]
+			q.namespaces = append(q.namespaces, ns)
+		} else {
+			delete(q.queues, ns)
+		}
+		return c, true
+	}
+	return queuedConnection{}, false
+}
+
 func NewPushQueue() *PushQueue {
+	return newPushQueue(features.PushQueuePriority, features.PushQueueMaxWait)
+}
+
+func newPushQueue(prioritized bool, maxWait time.Duration) *PushQueue {
 	return &PushQueue{
-		pending:    make(map[*Connection]*model.PushRequest),
-		processing: make(map[*Connection]*model.PushRequest),
-		cond:       sync.NewCond(&sync.Mutex{}),
+		pending:     make(map[*Connection]*pendingPush),
+		processing:  make(map[*Connection]*pendingPush),
+		prioritized: prioritized,
+		maxWait:     maxWait,
+		cond:        sync.NewCond(&sync.Mutex{}),
 	}
 }
 
 // Enqueue will mark a proxy as pending a push. If it is already pending, pushInfo will be merged.
 // ServiceEntry updates will be added together, and full will be set if either were full
 func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
+	p.enqueue(con, pushRequest, nil)
+}
+
+// enqueue is Enqueue, recording the push in the push history if record is not nil.
+func (p *PushQueue) enqueue(con *Connection, pushRequest *model.PushRequest, record *pushRecord) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
@@ -57,64 +202,136 @@ func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
 	}
 
 	// If its already in progress, merge the info and return
-	if request, f := p.processing[con]; f {
-		p.processing[con] = request.CopyMerge(pushRequest)
+	if push, f := p.processing[con]; f {
+		p.processing[con] = push.merge(con, pushRequest, record)
 		return
 	}
 
-	if request, f := p.pending[con]; f {
-		p.pending[con] = request.CopyMerge(pushRequest)
+	if push, f := p.pending[con]; f {
+		class := push.class
+		push.merge(con, pushRequest, record)
+		if p.prioritized && push.class != class {
+			// Queue the connection in its new class, keeping its arrival.
+			p.queueClass(con, push)
+		}
 		return
 	}
 
-	p.pending[con] = pushRequest
-	p.queue = append(p.queue, con)
+	p.add(con, (*pendingPush)(nil).merge(con, pushRequest, record))
+}
+
+// add queues the connection.
+func (p *PushQueue) add(con *Connection, push *pendingPush) {
+	push.queued = time.Now()
+	p.pending[con] = push
+	p.queueClass(con, push)
+	if p.prioritized {
+		p.lastSeq++
+		push.arrival = p.lastSeq
+		p.arrivals = append(p.arrivals, queuedConnection{con: con, seq: push.arrival})
+	}
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
 
+// queueClass queues the connection in the queue of its class.
+func (p *PushQueue) queueClass(con *Connection, push *pendingPush) {
+	p.lastSeq++
+	push.seq = p.lastSeq
+	class, namespace := pushClass(0), ""
+	if p.prioritized {
+		class = push.class
+		if con.proxy != nil {
+			namespace = con.proxy.ConfigNamespace
+		}
+	}
+	p.classes[class].push(namespace, queuedConnection{con: con, seq: push.seq})
+}
+
 // Remove a proxy from the queue. If there are no proxies ready to be removed, this will block
 func (p *PushQueue) Dequeue() (con *Connection, request *model.PushRequest, shutdown bool) {
+	con, push, shutdown := p.dequeue()
+	if push == nil {
+		return con, nil, shutdown
+	}
+	return con, push.request, shutdown
+}
+
+// dequeue is Dequeue, returning the pending push of the connection.
+func (p *PushQueue) dequeue() (con *Connection, push *pendingPush, shutdown bool) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.queue) == 0 && !p.shuttingDown {
+	for len(p.pending) == 0 && !p.shuttingDown {
 		p.cond.Wait()
 	}
 
-	if len(p.queue) == 0 {
+	if len(p.pending) == 0 {
 		// We must be shutting down.
 		return nil, nil, true
 	}
 
-	con = p.queue[0]
-	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
-	// See https://github.com/grpc/grpc-go/issues/4758
-	p.queue[0] = nil
-	p.queue = p.queue[1:]
-
-	request = p.pending[con]
+	con = p.next()
+	push = p.pending[con]
 	delete(p.pending, con)
 
 	// Mark the connection as in progress
 	p.processing[con] = nil
 
-	return con, request, false
+	if len(p.pending) == 0 {
+		// Drop the skipped entries, so that the connections may be garbage collected.
+		p.arrivals = nil
+		p.classes = [numPushClasses]namespaceQueue{}
+	}
+
+	return con, push, false
+}
+
+// next returns the next connection to dequeue: the first connection queued for longer than maxWait, if any, or
+// the next connection of the first class with queued connections.
+func (p *PushQueue) next() *Connection {
+	// Drop the arrivals of the connections which were dequeued.
+	for len(p.arrivals) > 0 {
+		c := p.arrivals[0]
+		if push, f := p.pending[c.con]; f && push.arrival == c.seq {
+			break
+		}
+		p.arrivals[0] = queuedConnection{}
+		p.arrivals = p.arrivals[1:]
+	}
+	if len(p.arrivals) > 0 {
+		if c := p.arrivals[0]; time.Since(p.pending[c.con].queued) > p.maxWait {
+			return c.con
+		}
+	}
+
+	valid := func(c queuedConnection) bool {
+		push, f := p.pending[c.con]
+		return f && push.seq == c.seq
+	}
+	for i := range p.classes {
+		if c, ok := p.classes[i].pop(valid); ok {
+			return c.con
+		}
+	}
+	// All pending connections are queued in their class, so this is not reached.
+	for con := range p.pending {
+		return con
+	}
+	return nil
 }
 
 func (p *PushQueue) MarkDone(con *Connection) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	request := p.processing[con]
+	push := p.processing[con]
 	delete(p.processing, con)
 
 	// If the info is present, that means Enqueue was called while connection was not yet marked done.
 	// This means we need to add it back to the queue.
-	if request != nil {
-		p.pending[con] = request
-		p.queue = append(p.queue, con)
-		p.cond.Signal()
+	if push != nil {
+		p.add(con, push)
 	}
 }
 
@@ -122,7 +339,7 @@ func (p *PushQueue) MarkDone(con *Connection) {
 func (p *PushQueue) Pending() int {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	return len(p.queue)
+	return len(p.pending)
 }
 
 // ShutDown will cause queue to ignore all new items added to it. As soon as the
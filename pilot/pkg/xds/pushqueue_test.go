@@ -373,3 +373,97 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 }
+
+func TestPriorityPushQueue(t *testing.T) {
+	newProxy := func(id string, tp model.NodeType, namespace string) *Connection {
+		conn := newConnection("", nil)
+		conn.SetID(id)
+		conn.proxy = &model.Proxy{Type: tp, ConfigNamespace: namespace}
+		return conn
+	}
+	gateway := newProxy("gateway", model.Router, "istio-system")
+	sidecarA1 := newProxy("a1", model.SidecarProxy, "a")
+	sidecarA2 := newProxy("a2", model.SidecarProxy, "a")
+	sidecarA3 := newProxy("a3", model.SidecarProxy, "a")
+	sidecarB1 := newProxy("b1", model.SidecarProxy, "b")
+
+	t.Run("dequeue by class", func(t *testing.T) {
+		t.Parallel()
+		p := newPushQueue(true, time.Hour)
+		defer p.ShutDown()
+
+		p.Enqueue(sidecarA1, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarA2, &model.PushRequest{Full: false})
+		p.Enqueue(gateway, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, gateway)
+		ExpectDequeue(t, p, sidecarA2)
+		ExpectDequeue(t, p, sidecarA1)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("requeue on class change", func(t *testing.T) {
+		t.Parallel()
+		p := newPushQueue(true, time.Hour)
+		defer p.ShutDown()
+
+		p.Enqueue(sidecarA1, &model.PushRequest{Full: false})
+		p.Enqueue(sidecarA2, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarA1, &model.PushRequest{Full: true})
+		if p.Pending() != 2 {
+			t.Fatalf("Expected 2 pending proxies, got %d", p.Pending())
+		}
+
+		ExpectDequeue(t, p, sidecarA2)
+		ExpectDequeue(t, p, sidecarA1)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("dequeue namespaces in turn", func(t *testing.T) {
+		t.Parallel()
+		p := newPushQueue(true, time.Hour)
+		defer p.ShutDown()
+
+		p.Enqueue(sidecarA1, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarA2, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarA3, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarB1, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, sidecarA1)
+		ExpectDequeue(t, p, sidecarB1)
+		ExpectDequeue(t, p, sidecarA2)
+		ExpectDequeue(t, p, sidecarA3)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("dequeue starved proxies first", func(t *testing.T) {
+		t.Parallel()
+		p := newPushQueue(true, 10*time.Millisecond)
+		defer p.ShutDown()
+
+		p.Enqueue(sidecarA1, &model.PushRequest{Full: true})
+		time.Sleep(20 * time.Millisecond)
+		p.Enqueue(gateway, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, sidecarA1)
+		ExpectDequeue(t, p, gateway)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("dequeue in order if not prioritized", func(t *testing.T) {
+		t.Parallel()
+		p := newPushQueue(false, time.Hour)
+		defer p.ShutDown()
+
+		p.Enqueue(sidecarA1, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarA2, &model.PushRequest{Full: true})
+		p.Enqueue(sidecarB1, &model.PushRequest{Full: false})
+		p.Enqueue(gateway, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, sidecarA1)
+		ExpectDequeue(t, p, sidecarA2)
+		ExpectDequeue(t, p, sidecarB1)
+		ExpectDequeue(t, p, gateway)
+		ExpectTimeout(t, p)
+	})
+}
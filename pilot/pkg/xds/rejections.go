@@ -0,0 +1,300 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"istio.io/api/meta/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	modelstatus "istio.io/istio/pilot/pkg/model/status"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/config/schema/resource"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	// rejectedCondition is the status condition of the Istio configs whose generated resources are rejected.
+	rejectedCondition = "Rejected"
+	// proxyRejectedReason is the reason of the rejections of resources NACKed by a proxy.
+	proxyRejectedReason = "ProxyRejected"
+	// validationFailedReason is the reason of the rejections of resources which failed the pre-push validation.
+	validationFailedReason = "ValidationFailed"
+	// maxRejectionMessage is the maximum length of the message of a rejected condition.
+	maxRejectionMessage = 1024
+)
+
+// rejectionSource identifies the rejection of the resources of a type for a connection.
+type rejectionSource struct {
+	conID   string
+	typeURL string
+	// resource is the name of the resource which failed validation. It is empty for the resources rejected by the
+	// proxy.
+	resource string
+}
+
+// configRejection is the rejection of resources generated from an Istio config.
+type configRejection struct {
+	reason  string
+	message string
+}
+
+// configRejections tracks the Istio configs whose generated resources are rejected for the connected proxies.
+type configRejections struct {
+	mu sync.Mutex
+	// sources are the configs rejected by each source.
+	sources map[rejectionSource]sets.Set[model.ConfigKey]
+	// configs are the rejections of each config, by source.
+	configs map[model.ConfigKey]map[rejectionSource]configRejection
+	// report is called when a config is first rejected, with its rejection, and when it is no longer rejected by any
+	// source, with nil.
+	report func(key model.ConfigKey, rejection *configRejection)
+}
+
+func newConfigRejections(report func(key model.ConfigKey, rejection *configRejection)) *configRejections {
+	return &configRejections{
+		sources: map[rejectionSource]sets.Set[model.ConfigKey]{},
+		configs: map[model.ConfigKey]map[rejectionSource]configRejection{},
+		report:  report,
+	}
+}
+
+type rejectionReport struct {
+	key       model.ConfigKey
+	rejection *configRejection
+}
+
+// reject records the configs as rejected by the source, replacing the configs it previously rejected. The reports are
+// sent while holding the lock, so they are sent in the order the rejections change.
+func (r *configRejections) reject(source rejectionSource, configs sets.Set[model.ConfigKey], rejection configRejection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reports := r.resolveLocked(source, configs)
+	for key := range configs {
+		if r.configs[key] == nil {
+			r.configs[key] = map[rejectionSource]configRejection{}
+		}
+		if len(r.configs[key]) == 0 {
+			reports = append(reports, rejectionReport{key: key, rejection: &rejection})
+		}
+		r.configs[key][source] = rejection
+	}
+	if len(configs) > 0 {
+		r.sources[source] = configs
+	}
+	r.sendReports(reports)
+}
+
+// resolve records the configs rejected by the source as no longer rejected by it.
+func (r *configRejections) resolve(source rejectionSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sendReports(r.resolveLocked(source, nil))
+}
+
+// resolveConnection records the configs rejected by the connection as no longer rejected by it.
+func (r *configRejections) resolveConnection(conID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for source := range r.sources {
+		if source.conID == conID {
+			r.sendReports(r.resolveLocked(source, nil))
+		}
+	}
+}
+
+// resolveLocked removes the rejections of the source, except for the configs which are kept, and returns the reports
+// of the configs no longer rejected.
+func (r *configRejections) resolveLocked(source rejectionSource, kept sets.Set[model.ConfigKey]) []rejectionReport {
+	var reports []rejectionReport
+	for key := range r.sources[source] {
+		if kept.Contains(key) {
+			continue
+		}
+		delete(r.configs[key], source)
+		if len(r.configs[key]) == 0 {
+			delete(r.configs, key)
+			reports = append(reports, rejectionReport{key: key})
+		}
+	}
+	delete(r.sources, source)
+	return reports
+}
+
+func (r *configRejections) sendReports(reports []rejectionReport) {
+	if r.report == nil {
+		return
+	}
+	for _, report := range reports {
+		r.report(report.key, report.rejection)
+	}
+}
+
+// trackRejection tracks the rejection of the resources of a type by the proxy. On a NACK of the last response, the
+// Istio configs of the rejected resources are recorded as rejected; on an ACK, the previous rejection is resolved.
+func (s *DiscoveryServer) trackRejection(con *Connection, typeURL string, nacked bool, nonce string) {
+	w := con.proxy.GetWatchedResource(typeURL)
+	if w == nil {
+		return
+	}
+	source := rejectionSource{conID: con.ID(), typeURL: typeURL}
+	if !nacked {
+		if nonce != "" && w.NonceAcked == nonce {
+			con.sent.ack(typeURL, nonce)
+			s.rejections.resolve(source)
+		}
+		return
+	}
+	if nonce != w.NonceSent {
+		// A NACK of an earlier response, which is followed by the ACK or NACK of the last one.
+		return
+	}
+	s.rejections.reject(source, rejectedConfigs(con, w, nonce), configRejection{
+		reason:  proxyRejectedReason,
+		message: "Generated configuration was rejected by " + con.ID() + ". " + w.LastError,
+	})
+}
+
+// rejectedConfigs returns the Istio configs the resources rejected by the proxy are generated from, as recorded in
+// the resources sent in the rejected response.
+func rejectedConfigs(con *Connection, w *model.WatchedResource, nonce string) sets.Set[model.ConfigKey] {
+	configs := sets.New[model.ConfigKey]()
+	for _, r := range con.sent.get(w.TypeUrl, nonce, w.RejectedResources) {
+		msg := newValidatableResource(w.TypeUrl)
+		if err := r.Resource.UnmarshalTo(msg); err == nil {
+			configs.Merge(resourceConfigs(msg, nil))
+		}
+	}
+	return configs
+}
+
+// rejectionStatusWriter writes the rejections observed by an instance as a status condition.
+type rejectionStatusWriter struct {
+	ctl *status.Controller
+	// instance identifies the instance in the condition, which is shared by all the instances.
+	instance string
+}
+
+// rejectionUpdate is the update of the rejection of a config observed by an instance, which is nil if the config is
+// no longer rejected.
+type rejectionUpdate struct {
+	instance  string
+	rejection *configRejection
+}
+
+// SetRejectionStatusWrite enables writing the rejections of Istio configs observed by this instance, identified by
+// instance, as a status condition, or disables it if statusManager is nil.
+func (s *DiscoveryServer) SetRejectionStatusWrite(statusManager *status.Manager, instance string) {
+	if statusManager == nil {
+		s.rejectionStatus.Store(nil)
+		return
+	}
+	s.rejectionStatus.Store(&rejectionStatusWriter{
+		ctl:      statusManager.CreateIstioStatusController(setRejectedCondition),
+		instance: instance,
+	})
+}
+
+// writeRejectionStatus enqueues the status update of a config which is rejected, or no longer rejected if the
+// rejection is nil. Only the Istio configs have their status written.
+func (s *DiscoveryServer) writeRejectionStatus(key model.ConfigKey, rejection *configRejection) {
+	writer := s.rejectionStatus.Load()
+	if writer == nil {
+		return
+	}
+	schema := slices.FindFunc(collections.Pilot.All(), func(schema resource.Schema) bool {
+		return kind.MustFromGVK(schema.GroupVersionKind()) == key.Kind
+	})
+	if schema == nil || !strings.HasSuffix((*schema).Group(), "istio.io") {
+		return
+	}
+	cfg := s.Env.ConfigStore.Get((*schema).GroupVersionKind(), key.Name, key.Namespace)
+	if cfg == nil {
+		return
+	}
+	if rejection != nil && len(rejection.message) > maxRejectionMessage {
+		rejection = &configRejection{reason: rejection.reason, message: rejection.message[:maxRejectionMessage]}
+	}
+	writer.ctl.EnqueueStatusUpdateResource(rejectionUpdate{instance: writer.instance, rejection: rejection}, status.ResourceFromModelConfig(*cfg))
+}
+
+// setRejectedCondition merges the rejection of the update into the rejected condition of the status. Every instance
+// writes the rejections observed for its own proxies, so the message of the condition has a line per instance with a
+// rejection, and each instance only replaces or removes its own line. The status is written against the resource
+// version it was read at, so concurrent writes of the instances conflict rather than overwrite each other. The
+// condition is removed once no instance reports a rejection.
+func setRejectedCondition(manipulator status.Manipulator, context any) {
+	update, ok := context.(rejectionUpdate)
+	if !ok {
+		return
+	}
+	var conditions *[]*v1alpha1.IstioCondition
+	switch s := manipulator.Unwrap().(type) {
+	case *v1alpha1.IstioStatus:
+		conditions = &s.Conditions
+	case *networking.ServiceEntryStatus:
+		conditions = &s.Conditions
+	default:
+		return
+	}
+	var existing *v1alpha1.IstioCondition
+	*conditions = slices.FilterInPlace(*conditions, func(c *v1alpha1.IstioCondition) bool {
+		if c.Type == rejectedCondition {
+			existing = c
+			return false
+		}
+		return true
+	})
+
+	instances := map[string]string{}
+	for _, line := range strings.Split(existing.GetMessage(), "\n") {
+		if instance, message, ok := strings.Cut(line, ": "); ok {
+			instances[instance] = message
+		}
+	}
+	reason := existing.GetReason()
+	if update.rejection != nil {
+		instances[update.instance] = strings.ReplaceAll(update.rejection.message, "\n", " ")
+		reason = update.rejection.reason
+	} else {
+		delete(instances, update.instance)
+	}
+	if len(instances) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(instances))
+	for _, instance := range sets.SortedList(sets.New(maps.Keys(instances)...)) {
+		lines = append(lines, instance+": "+instances[instance])
+	}
+	transitionTime := timestamppb.Now()
+	if existing != nil {
+		transitionTime = existing.LastTransitionTime
+	}
+	*conditions = append(*conditions, &v1alpha1.IstioCondition{
+		Type:               rejectedCondition,
+		Status:             modelstatus.StatusTrue,
+		LastTransitionTime: transitionTime,
+		Reason:             reason,
+		Message:            strings.Join(lines, "\n"),
+	})
+}
@@ -0,0 +1,240 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"istio.io/api/meta/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestConfigRejections(t *testing.T) {
+	type report struct {
+		Key    string
+		Reason string
+	}
+	var reports []report
+	r := newConfigRejections(func(key model.ConfigKey, rejection *configRejection) {
+		reason := ""
+		if rejection != nil {
+			reason = rejection.reason
+		}
+		reports = append(reports, report{Key: key.String(), Reason: reason})
+	})
+	a := model.ConfigKey{Kind: kind.VirtualService, Name: "a", Namespace: "default"}
+	b := model.ConfigKey{Kind: kind.DestinationRule, Name: "b", Namespace: "default"}
+	rejected := configRejection{reason: proxyRejectedReason}
+	cds := rejectionSource{conID: "proxy-1", typeURL: "cds"}
+	lds := rejectionSource{conID: "proxy-1", typeURL: "lds"}
+	other := rejectionSource{conID: "proxy-2", typeURL: "cds"}
+
+	r.reject(cds, sets.New(a), rejected)
+	r.reject(other, sets.New(a), rejected)
+	// Configs are reported the first time they are rejected only.
+	assert.Equal(t, reports, []report{{Key: a.String(), Reason: proxyRejectedReason}})
+
+	// A new rejection of the source replaces its previous one.
+	r.reject(cds, sets.New(b), rejected)
+	assert.Equal(t, reports[1:], []report{{Key: b.String(), Reason: proxyRejectedReason}})
+
+	r.reject(lds, sets.New(a, b), rejected)
+	r.resolve(cds)
+	r.resolve(other)
+	assert.Equal(t, len(reports), 2)
+	// Configs are reported once they are no longer rejected by any source.
+	r.resolveConnection("proxy-1")
+	assert.Equal(t, sets.New(reports[2:]...), sets.New(report{Key: a.String()}, report{Key: b.String()}))
+	assert.Equal(t, len(r.sources), 0)
+	assert.Equal(t, len(r.configs), 0)
+}
+
+func TestSetRejectedCondition(t *testing.T) {
+	s := &v1alpha1.IstioStatus{Conditions: []*v1alpha1.IstioCondition{{Type: "Other", Status: "True"}}}
+	sm := status.GetStatusManipulator(s)
+
+	setRejectedCondition(sm, rejectionUpdate{
+		instance:  "istiod-b",
+		rejection: &configRejection{reason: validationFailedReason, message: "invalid\ncluster"},
+	})
+	assert.Equal(t, len(s.Conditions), 2)
+	assert.Equal(t, s.Conditions[1].Type, rejectedCondition)
+	assert.Equal(t, s.Conditions[1].Status, "True")
+	assert.Equal(t, s.Conditions[1].Reason, validationFailedReason)
+	assert.Equal(t, s.Conditions[1].Message, "istiod-b: invalid cluster")
+	transitionTime := s.Conditions[1].LastTransitionTime
+
+	// The rejections of the other instances are kept.
+	setRejectedCondition(sm, rejectionUpdate{
+		instance:  "istiod-a",
+		rejection: &configRejection{reason: proxyRejectedReason, message: "rejected"},
+	})
+	assert.Equal(t, len(s.Conditions), 2)
+	assert.Equal(t, s.Conditions[1].Reason, proxyRejectedReason)
+	assert.Equal(t, s.Conditions[1].Message, "istiod-a: rejected\nistiod-b: invalid cluster")
+	assert.Equal(t, s.Conditions[1].LastTransitionTime, transitionTime)
+
+	setRejectedCondition(sm, rejectionUpdate{instance: "istiod-b"})
+	assert.Equal(t, len(s.Conditions), 2)
+	assert.Equal(t, s.Conditions[1].Message, "istiod-a: rejected")
+
+	// The condition is removed once no instance reports a rejection.
+	setRejectedCondition(sm, rejectionUpdate{instance: "istiod-a"})
+	assert.Equal(t, len(s.Conditions), 1)
+	assert.Equal(t, s.Conditions[0].Type, "Other")
+}
+
+func TestResourceConfigs(t *testing.T) {
+	meta := func(k config.GroupVersionKind, name string) config.Meta {
+		return config.Meta{GroupVersionKind: k, Name: name, Namespace: "default"}
+	}
+	dr := model.ConfigKey{Kind: kind.DestinationRule, Name: "dr", Namespace: "default"}
+	valid := model.ConfigKey{Kind: kind.VirtualService, Name: "valid", Namespace: "default"}
+	invalid := model.ConfigKey{Kind: kind.VirtualService, Name: "invalid", Namespace: "default"}
+
+	c := &cluster.Cluster{Name: "outbound|80||a.com", Metadata: util.BuildConfigInfoMetadata(meta(gvk.DestinationRule, "dr"))}
+	assert.Equal(t, resourceConfigs(c, nil), sets.New(dr))
+
+	rc := &route.RouteConfiguration{
+		Name: "80",
+		VirtualHosts: []*route.VirtualHost{{
+			Name:    "a.com:80",
+			Domains: []string{"a.com"},
+			Routes: []*route.Route{
+				{
+					Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+					Action: &route.Route_Route{Route: &route.RouteAction{
+						ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "outbound|80||a.com"},
+					}},
+					Metadata: util.BuildConfigInfoMetadata(meta(gvk.VirtualService, "valid")),
+				},
+				{
+					// Routes require a match.
+					Action: &route.Route_Route{Route: &route.RouteAction{
+						ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "outbound|80||a.com"},
+					}},
+					Metadata: util.BuildConfigInfoMetadata(meta(gvk.VirtualService, "invalid")),
+				},
+			},
+		}},
+	}
+	assert.Equal(t, resourceConfigs(rc, nil), sets.New(valid, invalid))
+	assert.Equal(t, resourceConfigs(rc, func(part validatableResource) bool {
+		return part.ValidateAll() != nil
+	}), sets.New(invalid))
+
+	invalidResources := validateResources(v3.RouteType, model.Resources{{Name: rc.Name, Resource: protoconv.MessageToAny(rc)}})
+	assert.Equal(t, len(invalidResources), 1)
+	assert.Equal(t, invalidResources[0].name, "80")
+	assert.Equal(t, invalidResources[0].configs, sets.New(invalid))
+}
+
+func TestValidatePush(t *testing.T) {
+	s := &DiscoveryServer{rejections: newConfigRejections(nil)}
+	con := newConnection("", nil)
+	con.proxy = &model.Proxy{
+		ID:               "proxy-1",
+		WatchedResources: map[string]*model.WatchedResource{v3.ClusterType: {TypeUrl: v3.ClusterType}},
+	}
+	valid := &discovery.Resource{Name: "valid", Resource: protoconv.MessageToAny(&cluster.Cluster{Name: "valid"})}
+	invalid := &discovery.Resource{
+		Name: "invalid",
+		Resource: protoconv.MessageToAny(&cluster.Cluster{
+			Name:           "invalid",
+			ConnectTimeout: durationpb.New(0),
+			Metadata:       util.BuildConfigInfoMetadata(config.Meta{GroupVersionKind: gvk.DestinationRule, Name: "dr", Namespace: "default"}),
+		}),
+	}
+	invalidResources := func() []string {
+		return sets.SortedList(sets.New(maps.Keys(con.proxy.GetWatchedResource(v3.ClusterType).InvalidResources)...))
+	}
+	names := func(res model.Resources) []string {
+		return slices.Map(res, func(r *discovery.Resource) string {
+			return r.Name
+		})
+	}
+
+	res, rejected := s.validatePush(con, v3.ClusterType, model.Resources{valid, invalid}, nil, true)
+	assert.Equal(t, names(res), []string{"valid"})
+	assert.Equal(t, rejected, []string{"invalid"})
+	assert.Equal(t, invalidResources(), []string{"invalid"})
+	assert.Equal(t, len(s.rejections.sources), 1)
+
+	// Partial pushes keep the invalid resources they do not generate, unless they are deleted.
+	res, rejected = s.validatePush(con, v3.ClusterType, model.Resources{valid}, nil, false)
+	assert.Equal(t, names(res), []string{"valid"})
+	assert.Equal(t, rejected, nil)
+	assert.Equal(t, invalidResources(), []string{"invalid"})
+	s.validatePush(con, v3.ClusterType, nil, []string{"invalid"}, false)
+	assert.Equal(t, invalidResources(), nil)
+
+	// Full pushes generate all the resources.
+	s.validatePush(con, v3.ClusterType, model.Resources{invalid}, nil, false)
+	assert.Equal(t, invalidResources(), []string{"invalid"})
+	s.validatePush(con, v3.ClusterType, model.Resources{valid}, nil, true)
+	assert.Equal(t, invalidResources(), nil)
+	assert.Equal(t, len(s.rejections.sources), 0)
+}
+
+func TestTrackRejection(t *testing.T) {
+	test.SetForTest(t, &features.EnableXDSValidation, true)
+	s := &DiscoveryServer{rejections: newConfigRejections(nil)}
+	con := newConnection("", nil)
+	w := &model.WatchedResource{TypeUrl: v3.ClusterType, NonceSent: "nonce-2"}
+	con.proxy = &model.Proxy{
+		ID:               "proxy-1",
+		WatchedResources: map[string]*model.WatchedResource{v3.ClusterType: w},
+	}
+	dr := model.ConfigKey{Kind: kind.DestinationRule, Name: "dr", Namespace: "default"}
+	c := &discovery.Resource{
+		Name: "c",
+		Resource: protoconv.MessageToAny(&cluster.Cluster{
+			Name:     "c",
+			Metadata: util.BuildConfigInfoMetadata(config.Meta{GroupVersionKind: gvk.DestinationRule, Name: dr.Name, Namespace: dr.Namespace}),
+		}),
+	}
+	s.recordSent(con, v3.ClusterType, "nonce-2", model.Resources{c}, false)
+	w.RejectedResources = []string{"c"}
+
+	// The NACK of an earlier response is followed by the ACK or NACK of the last one.
+	s.trackRejection(con, v3.ClusterType, true, "nonce-1")
+	assert.Equal(t, len(s.rejections.configs), 0)
+
+	// The configs of the rejected resources are those recorded in the resources sent in the rejected response.
+	s.trackRejection(con, v3.ClusterType, true, "nonce-2")
+	assert.Equal(t, maps.Keys(s.rejections.configs), []model.ConfigKey{dr})
+
+	w.NonceAcked = "nonce-2"
+	s.trackRejection(con, v3.ClusterType, false, "nonce-2")
+	assert.Equal(t, len(s.rejections.configs), 0)
+}
@@ -136,9 +136,29 @@ func (sg *StatusGen) debugSyncz() model.Resources {
 				}
 
 				xdsConfigs = append(xdsConfigs, pxc)
+				// The rejected resources are reported individually, following the status of their type.
+				for _, name := range wr.RejectedResources {
+					xdsConfigs = append(xdsConfigs, &status.ClientConfig_GenericXdsConfig{
+						TypeUrl:      wr.TypeUrl,
+						Name:         name,
+						ConfigStatus: status.ConfigStatus_ERROR,
+						ErrorState:   pxc.ErrorState,
+					})
+				}
+				for name, err := range wr.InvalidResources {
+					xdsConfigs = append(xdsConfigs, &status.ClientConfig_GenericXdsConfig{
+						TypeUrl:      wr.TypeUrl,
+						Name:         name,
+						ConfigStatus: status.ConfigStatus_ERROR,
+						ErrorState: &admin.UpdateFailureState{
+							LastUpdateAttempt: timestamppb.New(wr.LastSendTime),
+							Details:           "Validation failed: " + err,
+						},
+					})
+				}
 			}
 			slices.SortBy(xdsConfigs, func(a *status.ClientConfig_GenericXdsConfig) string {
-				return a.TypeUrl
+				return a.TypeUrl + "/" + a.Name
 			})
 			clientConfig := &status.ClientConfig{
 				Node: &core.Node{
@@ -0,0 +1,295 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strings"
+	"sync"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/util/strcase"
+	"istio.io/istio/pkg/xds"
+)
+
+// validatableResource is a generated resource which can be validated against the constraints of its proto.
+type validatableResource interface {
+	proto.Message
+	ValidateAll() error
+}
+
+// newValidatableResource returns an empty resource of the type, or nil if the resources of the type are not validated.
+func newValidatableResource(typeURL string) validatableResource {
+	switch typeURL {
+	case v3.ListenerType:
+		return &listener.Listener{}
+	case v3.ClusterType:
+		return &cluster.Cluster{}
+	case v3.RouteType:
+		return &route.RouteConfiguration{}
+	}
+	return nil
+}
+
+// invalidResource is a generated resource which failed the validation.
+type invalidResource struct {
+	name string
+	err  error
+	// configs are the Istio configs the invalid parts of the resource are generated from.
+	configs sets.Set[model.ConfigKey]
+}
+
+// validateResources validates the generated resources of the type, and returns the invalid ones.
+func validateResources(typeURL string, res model.Resources) []invalidResource {
+	var invalid []invalidResource
+	for _, r := range res {
+		msg := newValidatableResource(typeURL)
+		if msg == nil {
+			return nil
+		}
+		if err := r.Resource.UnmarshalTo(msg); err != nil {
+			invalid = append(invalid, invalidResource{name: r.Name, err: err})
+			continue
+		}
+		if err := msg.ValidateAll(); err != nil {
+			configs := resourceConfigs(msg, func(part validatableResource) bool {
+				return part.ValidateAll() != nil
+			})
+			invalid = append(invalid, invalidResource{name: r.Name, err: err, configs: configs})
+		}
+	}
+	return invalid
+}
+
+// validatePush validates the resources generated for a push of the type, and returns the valid ones to send with the
+// names of the invalid ones. The invalid resources are not sent and are recorded as rejected, so the proxy keeps their
+// current version, if any. With SotW, listeners and clusters which are not sent are removed by the proxy instead, so
+// the caller resends their last acked version.
+//
+// A full push generates all the watched resources, so the previously invalid resources which are not generated no
+// longer exist; otherwise, they are kept unless they are generated or deleted.
+func (s *DiscoveryServer) validatePush(
+	con *Connection,
+	typeURL string,
+	res model.Resources,
+	deleted []string,
+	full bool,
+) (model.Resources, []string) {
+	invalid := validateResources(typeURL, res)
+	var previous map[string]string
+	if w := con.proxy.GetWatchedResource(typeURL); w != nil {
+		previous = w.InvalidResources
+	}
+	if len(invalid) == 0 && len(previous) == 0 {
+		return res, nil
+	}
+
+	generated := sets.New(slices.Map(res, func(r *discovery.Resource) string {
+		return r.Name
+	})...)
+	current := map[string]string{}
+	for name, err := range previous {
+		if generated.Contains(name) || slices.Contains(deleted, name) || full {
+			s.rejections.resolve(rejectionSource{conID: con.ID(), typeURL: typeURL, resource: name})
+			continue
+		}
+		current[name] = err
+	}
+	invalidNames := sets.New[string]()
+	for _, r := range invalid {
+		current[r.name] = r.err.Error()
+		invalidNames.Insert(r.name)
+		s.rejections.reject(rejectionSource{conID: con.ID(), typeURL: typeURL, resource: r.name}, r.configs, configRejection{
+			reason:  validationFailedReason,
+			message: "Generated configuration for " + con.ID() + " failed validation. " + r.name + ": " + r.err.Error(),
+		})
+	}
+	if len(current) == 0 {
+		current = nil
+	}
+	con.proxy.UpdateWatchedResource(typeURL, func(wr *model.WatchedResource) *model.WatchedResource {
+		if wr != nil {
+			wr.InvalidResources = current
+		}
+		return wr
+	})
+	if len(invalid) == 0 {
+		return res, nil
+	}
+
+	xdsValidationFailures.With(typeTag.Value(v3.GetMetricType(typeURL))).Increment()
+	errs := slices.Map(invalid, func(r invalidResource) string {
+		return r.name + ": " + r.err.Error()
+	})
+	log.Warnf("%s: SKIP invalid resources for node:%s: %s", v3.GetShortType(typeURL), con.proxy.ID, strings.Join(errs, ", "))
+	valid := slices.Filter(res, func(r *discovery.Resource) bool {
+		return !invalidNames.Contains(r.Name)
+	})
+	return valid, sets.SortedList(invalidNames)
+}
+
+// resourceConfigs returns the Istio configs a resource is generated from, as recorded in its config metadata. The
+// configs of listeners and route configurations are those of their filter chains and routes accepted by include, or
+// of all of them if include is nil.
+func resourceConfigs(msg proto.Message, include func(part validatableResource) bool) sets.Set[model.ConfigKey] {
+	configs := sets.New[model.ConfigKey]()
+	add := func(part validatableResource, md *core.Metadata) {
+		if include != nil && !include(part) {
+			return
+		}
+		if key, ok := metadataConfig(md); ok {
+			configs.Insert(key)
+		}
+	}
+	switch m := msg.(type) {
+	case *cluster.Cluster:
+		add(m, m.GetMetadata())
+	case *listener.Listener:
+		for _, fc := range m.GetFilterChains() {
+			add(fc, fc.GetMetadata())
+		}
+	case *route.RouteConfiguration:
+		for _, vh := range m.GetVirtualHosts() {
+			for _, r := range vh.GetRoutes() {
+				add(r, r.GetMetadata())
+			}
+		}
+	}
+	return configs
+}
+
+// metadataConfig returns the Istio config recorded in the metadata, which is identified by a path such as
+// "/apis/networking.istio.io/v1/namespaces/default/virtual-service/name".
+func metadataConfig(md *core.Metadata) (model.ConfigKey, bool) {
+	path := md.GetFilterMetadata()[util.IstioMetadataKey].GetFields()["config"].GetStringValue()
+	parts := strings.Split(path, "/")
+	if len(parts) != 8 || parts[1] != "apis" || parts[4] != "namespaces" {
+		return model.ConfigKey{}, false
+	}
+	group, namespace, kebabKind, name := parts[2], parts[5], parts[6], parts[7]
+	for _, schema := range collections.All.All() {
+		if schema.Group() == group && strcase.CamelCaseToKebabCase(schema.Kind()) == kebabKind {
+			return model.ConfigKey{Kind: kind.MustFromGVK(schema.GroupVersionKind()), Name: name, Namespace: namespace}, true
+		}
+	}
+	return model.ConfigKey{}, false
+}
+
+// sentResources are the validated resources last sent to a proxy, by type. They are retained to find the Istio configs
+// of the resources the proxy rejects, and with SotW, to resend the last acked version of the listeners and clusters
+// which fail validation. The resources are shared with the generators, so retaining them does not copy them.
+type sentResources struct {
+	mu    sync.Mutex
+	types map[string]*sentTypeResources
+}
+
+// sentTypeResources are the resources of a type last sent to a proxy.
+type sentTypeResources struct {
+	// nonce is the nonce of the last response.
+	nonce string
+	// sent are the resources of the last response, by name.
+	sent map[string]*discovery.Resource
+	// acked are the resources of the last acked response, by name, if they are retained.
+	acked     map[string]*discovery.Resource
+	keepAcked bool
+}
+
+func newSentResources() *sentResources {
+	return &sentResources{types: map[string]*sentTypeResources{}}
+}
+
+// record records the resources of the response with the nonce. The resources of the last acked response are retained
+// if keepAcked is set.
+func (s *sentResources) record(typeURL, nonce string, res model.Resources, keepAcked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.types[typeURL]
+	if t == nil {
+		t = &sentTypeResources{}
+		s.types[typeURL] = t
+	}
+	t.nonce = nonce
+	t.sent = make(map[string]*discovery.Resource, len(res))
+	for _, r := range res {
+		t.sent[r.Name] = r
+	}
+	t.keepAcked = keepAcked
+	if !keepAcked {
+		t.acked = nil
+	}
+}
+
+// ack records the response with the nonce as acked.
+func (s *sentResources) ack(typeURL, nonce string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.types[typeURL]; t != nil && t.nonce == nonce && t.keepAcked {
+		t.acked = t.sent
+	}
+}
+
+// get returns the resources with the names sent in the response with the nonce, if it is the last response.
+func (s *sentResources) get(typeURL, nonce string, names []string) model.Resources {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.types[typeURL]
+	if t == nil || t.nonce != nonce {
+		return nil
+	}
+	return lookupResources(t.sent, names)
+}
+
+// getAcked returns the last acked version of the resources with the names, if any.
+func (s *sentResources) getAcked(typeURL string, names []string) model.Resources {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.types[typeURL]
+	if t == nil {
+		return nil
+	}
+	return lookupResources(t.acked, names)
+}
+
+func lookupResources(res map[string]*discovery.Resource, names []string) model.Resources {
+	var out model.Resources
+	for _, name := range names {
+		if r, ok := res[name]; ok {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// recordSent records the resources sent to the proxy in the response with the nonce, if they are validated. With SotW,
+// the last acked version of the listeners and clusters is retained, so it can be resent in place of an invalid one.
+func (s *DiscoveryServer) recordSent(con *Connection, typeURL, nonce string, res model.Resources, delta bool) {
+	if !features.EnableXDSValidation || newValidatableResource(typeURL) == nil {
+		return
+	}
+	con.sent.record(typeURL, nonce, res, !delta && xds.IsWildcardTypeURL(typeURL))
+}
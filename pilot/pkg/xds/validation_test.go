@@ -0,0 +1,242 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pilot/test/xdstest"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const serviceEntryConfig = `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: a
+  namespace: default
+spec:
+  hosts:
+  - a.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`
+
+// invalidDestinationRule generates an invalid cluster for the service entry. It is rejected by the config validation,
+// so it is added to the config store directly.
+var invalidDestinationRule = config.Config{
+	Meta: config.Meta{
+		GroupVersionKind: gvk.DestinationRule,
+		Name:             "a",
+		Namespace:        "default",
+	},
+	Spec: &networking.DestinationRule{
+		Host: "a.example.com",
+		TrafficPolicy: &networking.TrafficPolicy{
+			ConnectionPool: &networking.ConnectionPoolSettings{
+				Tcp: &networking.ConnectionPoolSettings_TCPSettings{ConnectTimeout: durationpb.New(0)},
+			},
+		},
+	},
+}
+
+// resourceStatus returns the sync status of the resources of the type for the only connected proxy.
+func resourceStatus(t *testing.T, s *xds.DiscoveryServer, typeURL string) (xds.ResourceStatus, error) {
+	syncz := getSyncStatus(t, s)
+	if len(syncz) != 1 {
+		return xds.ResourceStatus{}, fmt.Errorf("expected a single proxy, got %d", len(syncz))
+	}
+	return syncz[0].Resources[typeURL], nil
+}
+
+func TestPushValidation(t *testing.T) {
+	test.SetForTest(t, &features.EnableXDSValidation, true)
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{
+		ConfigString: serviceEntryConfig,
+		Configs:      []config.Config{invalidDestinationRule},
+	})
+	ads := s.ConnectADS()
+
+	// The valid clusters are pushed without the invalid one.
+	resp := ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+	clusters := slices.Map(resp.Resources, func(r *anypb.Any) string {
+		return xdstest.UnmarshalAny[cluster.Cluster](t, r).Name
+	})
+	if len(clusters) == 0 || slices.Contains(clusters, "outbound|80||a.example.com") {
+		t.Fatalf("expected the valid clusters only, got %v", clusters)
+	}
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+
+	retry.UntilSuccessOrFail(t, func() error {
+		cds, err := resourceStatus(t, s.Discovery, v3.ClusterType)
+		if err != nil {
+			return err
+		}
+		if cds.Acked == "" || cds.LastError != "" {
+			return fmt.Errorf("expected clusters acked, got %+v", cds)
+		}
+		if !strings.HasPrefix(cds.Invalid["outbound|80||a.example.com"], "invalid Cluster.ConnectTimeout") {
+			return fmt.Errorf("unexpected invalid resources %v", cds.Invalid)
+		}
+		return nil
+	})
+}
+
+// invalidListenerFilter makes the outbound listener on port 80 invalid. It is rejected by the config validation, so it
+// is added to the config store directly.
+var invalidListenerFilter = config.Config{
+	Meta: config.Meta{
+		GroupVersionKind: gvk.EnvoyFilter,
+		Name:             "invalid-listener",
+		Namespace:        "default",
+	},
+	Spec: &networking.EnvoyFilter{
+		ConfigPatches: []*networking.EnvoyFilter_EnvoyConfigObjectPatch{{
+			ApplyTo: networking.EnvoyFilter_LISTENER,
+			Match: &networking.EnvoyFilter_EnvoyConfigObjectMatch{
+				Context: networking.EnvoyFilter_SIDECAR_OUTBOUND,
+				ObjectTypes: &networking.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+					Listener: &networking.EnvoyFilter_ListenerMatch{PortNumber: 80},
+				},
+			},
+			Patch: &networking.EnvoyFilter_Patch{
+				Operation: networking.EnvoyFilter_Patch_MERGE,
+				Value: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"max_connections_to_accept_per_socket_event": structpb.NewNumberValue(0),
+				}},
+			},
+		}},
+	},
+}
+
+func TestPushValidationKeepsAckedListener(t *testing.T) {
+	test.SetForTest(t, &features.EnableXDSValidation, true)
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{ConfigString: serviceEntryConfig})
+	ads := s.ConnectADS().WithType(v3.ListenerType)
+
+	listeners := func(resp *discovery.DiscoveryResponse) map[string]*listener.Listener {
+		out := map[string]*listener.Listener{}
+		for _, r := range resp.Resources {
+			l := xdstest.UnmarshalAny[listener.Listener](t, r)
+			out[l.Name] = l
+		}
+		return out
+	}
+	resp := ads.RequestResponseAck(t, nil)
+	acked := listeners(resp)["0.0.0.0_80"]
+	if acked == nil {
+		t.Fatalf("expected the listener 0.0.0.0_80, got %v", maps.Keys(listeners(resp)))
+	}
+	retry.UntilSuccessOrFail(t, func() error {
+		lds, err := resourceStatus(t, s.Discovery, v3.ListenerType)
+		if err != nil {
+			return err
+		}
+		if lds.Acked != resp.Nonce {
+			return fmt.Errorf("expected the listeners acked, got %+v", lds)
+		}
+		return nil
+	})
+
+	// Over SotW, the proxy removes the listeners which are not sent, so the acked version of the invalid listener is
+	// sent instead.
+	if _, err := s.Store().Create(invalidListenerFilter); err != nil {
+		t.Fatal(err)
+	}
+	s.Discovery.ConfigUpdate(&model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: sets.New(model.ConfigKey{Kind: kind.EnvoyFilter, Name: invalidListenerFilter.Name, Namespace: "default"}),
+	})
+	resp = ads.ExpectResponse(t)
+	got := listeners(resp)["0.0.0.0_80"]
+	if got == nil {
+		t.Fatalf("expected the acked listener 0.0.0.0_80, got %v", maps.Keys(listeners(resp)))
+	}
+	assert.Equal(t, got, acked)
+
+	retry.UntilSuccessOrFail(t, func() error {
+		lds, err := resourceStatus(t, s.Discovery, v3.ListenerType)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(lds.Invalid["0.0.0.0_80"], "MaxConnectionsToAcceptPerSocketEvent") {
+			return fmt.Errorf("unexpected invalid resources %v", lds.Invalid)
+		}
+		return nil
+	})
+}
+
+func TestNackRejectedResources(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	ads := s.ConnectADS()
+
+	ads.Request(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+	resp := ads.ExpectResponse(t)
+	ads.Request(t, &discovery.DiscoveryRequest{
+		TypeUrl:       v3.ClusterType,
+		ResponseNonce: resp.Nonce,
+		ErrorDetail:   &status.Status{Message: "Error adding/updating cluster(s) outbound|80||a.example.com: bad config"},
+	})
+	retry.UntilSuccessOrFail(t, func() error {
+		cds, err := resourceStatus(t, s.Discovery, v3.ClusterType)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(cds.Rejected, []string{"outbound|80||a.example.com"}) {
+			return fmt.Errorf("unexpected rejected resources %v", cds.Rejected)
+		}
+		return nil
+	})
+
+	// The rejection is cleared on the next ACK.
+	ads.Request(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType, ResponseNonce: resp.Nonce})
+	retry.UntilSuccessOrFail(t, func() error {
+		cds, err := resourceStatus(t, s.Discovery, v3.ClusterType)
+		if err != nil {
+			return err
+		}
+		if len(cds.Rejected) != 0 || cds.LastError != "" {
+			return fmt.Errorf("expected no rejected resources, got %+v", cds)
+		}
+		return nil
+	})
+}
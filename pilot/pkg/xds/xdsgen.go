@@ -23,6 +23,7 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/util"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
@@ -132,6 +133,15 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 
 		return err
 	}
+	if features.EnableXDSValidation {
+		var invalid []string
+		res, invalid = s.validatePush(con, w.TypeUrl, res, nil, req.Delta.IsEmpty())
+		if xds.IsWildcardTypeURL(w.TypeUrl) {
+			// The listeners and clusters which are not sent are removed by the proxy, so the last acked version of the
+			// invalid ones is sent instead.
+			res = append(res, con.sent.getAcked(w.TypeUrl, invalid)...)
+		}
+	}
 	defer func() { recordPushTime(w.TypeUrl, time.Since(t0)) }()
 
 	resp := &discovery.DiscoveryResponse{
@@ -158,6 +168,8 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 		}
 		return err
 	}
+	con.recordPushedType(w.TypeUrl, len(res), configSize, time.Since(t0))
+	s.recordSent(con, w.TypeUrl, resp.Nonce, res, false)
 
 	switch {
 	case !req.Full:
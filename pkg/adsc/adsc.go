@@ -118,6 +118,9 @@ type Config struct {
 	BackoffPolicy backoff.BackOff
 
 	GrpcOpts []grpc.DialOption
+
+	// Recorder, if set, records the xDS messages exchanged on the connection.
+	Recorder *Recorder
 }
 
 // ADSConfig for the ADS connection.
@@ -397,6 +400,9 @@ func (a *ADSC) Run() error {
 	if err != nil {
 		return err
 	}
+	if a.cfg.Recorder != nil {
+		a.stream = a.cfg.Recorder.recordStream(a.stream)
+	}
 	a.sendNodeMeta = true
 	a.initialLoad = 0
 	a.initialLds = false
@@ -285,6 +285,9 @@ func (c *Client) runOnce(ctx context.Context) error {
 	c.log.WithLabels("node", c.nodeID()).Infof("established connection")
 	c.sendNodeMeta = true
 	c.xdsClient = xdsClient
+	if c.cfg.Recorder != nil {
+		c.xdsClient = c.cfg.Recorder.recordDeltaStream(xdsClient)
+	}
 	for _, w := range c.initialWatches {
 		c.log.Infof("sending initial watch %v", w.TypeURL)
 		if err := c.request(w); err != nil {
@@ -0,0 +1,241 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+// Direction is the direction of a recorded xDS message.
+type Direction string
+
+const (
+	// DirectionRequest is a request sent by the client.
+	DirectionRequest Direction = "request"
+	// DirectionResponse is a response received from the server.
+	DirectionResponse Direction = "response"
+)
+
+// RecordedMessage is an xDS message exchanged by a client, as recorded by a Recorder.
+type RecordedMessage struct {
+	Time time.Time `json:"time"`
+	// Stream is the index of the stream the message was exchanged on, in the order the streams were opened. A client
+	// opens a new stream each time it reconnects.
+	Stream int `json:"stream"`
+	// Delta is set if the message was exchanged on a delta stream.
+	Delta     bool      `json:"delta,omitempty"`
+	Direction Direction `json:"direction"`
+	TypeURL   string    `json:"typeUrl"`
+	Nonce     string    `json:"nonce,omitempty"`
+	// Message is the message in its binary proto encoding, so it is replayed exactly as it was exchanged.
+	Message []byte `json:"message"`
+}
+
+// Unmarshal returns the recorded message, which is a DiscoveryRequest or DiscoveryResponse, or a DeltaDiscoveryRequest
+// or DeltaDiscoveryResponse if it was exchanged on a delta stream.
+func (m RecordedMessage) Unmarshal() (proto.Message, error) {
+	var msg proto.Message
+	switch {
+	case m.Delta && m.Direction == DirectionRequest:
+		msg = &discovery.DeltaDiscoveryRequest{}
+	case m.Delta && m.Direction == DirectionResponse:
+		msg = &discovery.DeltaDiscoveryResponse{}
+	case m.Direction == DirectionRequest:
+		msg = &discovery.DiscoveryRequest{}
+	case m.Direction == DirectionResponse:
+		msg = &discovery.DiscoveryResponse{}
+	default:
+		return nil, fmt.Errorf("unknown direction %q", m.Direction)
+	}
+	if err := proto.Unmarshal(m.Message, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Recorder records the xDS messages exchanged by clients, SotW and delta, as a stream of JSON objects, one
+// RecordedMessage per line. A recording can be served by a Replayer.
+type Recorder struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	closer  io.Closer
+	streams int
+	// err is the first error writing the recording, after which nothing more is recorded.
+	err error
+}
+
+// NewRecorder returns a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// NewFileRecorder returns a recorder writing to the file, which is created or truncated. The file is closed when the
+// recorder is closed.
+func NewFileRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f)
+	r.closer = f
+	return r, nil
+}
+
+// Close stops the recording. It returns the first error writing the recording, if any.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.err
+	if r.closer != nil {
+		err = errors.Join(err, r.closer.Close())
+		r.closer = nil
+	}
+	if r.err == nil {
+		r.err = errors.New("recorder closed")
+	}
+	return err
+}
+
+func (r *Recorder) newStream() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stream := r.streams
+	r.streams++
+	return stream
+}
+
+func (r *Recorder) record(stream int, delta bool, direction Direction, typeURL, nonce string, msg proto.Message) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		adscLog.Warnf("failed to record %s %s: %v", typeURL, direction, err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(RecordedMessage{
+		Time:      time.Now(),
+		Stream:    stream,
+		Delta:     delta,
+		Direction: direction,
+		TypeURL:   typeURL,
+		Nonce:     nonce,
+		Message:   b,
+	}); err != nil {
+		adscLog.Warnf("failed to write recording, stopping: %v", err)
+		r.err = err
+	}
+}
+
+// recordStream returns the stream recording the messages exchanged on it.
+func (r *Recorder) recordStream(stream discovery.AggregatedDiscoveryService_StreamAggregatedResourcesClient) recordingStream {
+	return recordingStream{
+		AggregatedDiscoveryService_StreamAggregatedResourcesClient: stream,
+		recorder: r,
+		stream:   r.newStream(),
+	}
+}
+
+// recordDeltaStream returns the delta stream recording the messages exchanged on it.
+func (r *Recorder) recordDeltaStream(stream DeltaAggregatedResourcesClient) recordingDeltaStream {
+	return recordingDeltaStream{
+		DeltaAggregatedResourcesClient: stream,
+		recorder:                       r,
+		stream:                         r.newStream(),
+	}
+}
+
+type recordingStream struct {
+	discovery.AggregatedDiscoveryService_StreamAggregatedResourcesClient
+	recorder *Recorder
+	stream   int
+}
+
+func (s recordingStream) Send(req *discovery.DiscoveryRequest) error {
+	if err := s.AggregatedDiscoveryService_StreamAggregatedResourcesClient.Send(req); err != nil {
+		return err
+	}
+	s.recorder.record(s.stream, false, DirectionRequest, req.TypeUrl, req.ResponseNonce, req)
+	return nil
+}
+
+func (s recordingStream) Recv() (*discovery.DiscoveryResponse, error) {
+	resp, err := s.AggregatedDiscoveryService_StreamAggregatedResourcesClient.Recv()
+	if err != nil {
+		return nil, err
+	}
+	s.recorder.record(s.stream, false, DirectionResponse, resp.TypeUrl, resp.Nonce, resp)
+	return resp, nil
+}
+
+type recordingDeltaStream struct {
+	DeltaAggregatedResourcesClient
+	recorder *Recorder
+	stream   int
+}
+
+func (s recordingDeltaStream) Send(req *discovery.DeltaDiscoveryRequest) error {
+	if err := s.DeltaAggregatedResourcesClient.Send(req); err != nil {
+		return err
+	}
+	s.recorder.record(s.stream, true, DirectionRequest, req.TypeUrl, req.ResponseNonce, req)
+	return nil
+}
+
+func (s recordingDeltaStream) Recv() (*discovery.DeltaDiscoveryResponse, error) {
+	resp, err := s.DeltaAggregatedResourcesClient.Recv()
+	if err != nil {
+		return nil, err
+	}
+	s.recorder.record(s.stream, true, DirectionResponse, resp.TypeUrl, resp.Nonce, resp)
+	return resp, nil
+}
+
+// ReadRecording reads the messages of a recording.
+func ReadRecording(r io.Reader) ([]RecordedMessage, error) {
+	var messages []RecordedMessage
+	dec := json.NewDecoder(r)
+	for {
+		var m RecordedMessage
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				return messages, nil
+			}
+			return nil, fmt.Errorf("message %d: %v", len(messages), err)
+		}
+		messages = append(messages, m)
+	}
+}
+
+// LoadRecording reads the messages of a recording from the file.
+func LoadRecording(path string) ([]RecordedMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
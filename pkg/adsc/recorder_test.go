@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+var testClusterResponse = &discovery.DeltaDiscoveryResponse{
+	TypeUrl: v3.ClusterType,
+	Nonce:   "nonce-1",
+	Resources: []*discovery.Resource{
+		{
+			Name:     "test-eds",
+			Resource: protoconv.MessageToAny(testClusterNoSecret),
+		},
+	},
+}
+
+func TestRecorder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr := serveXds(t, &mockDeltaXdsServer{
+		handler: func(delta discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) {
+			_ = delta.Send(testClusterResponse)
+		},
+		ctx: ctx,
+	})
+
+	buf := &bytes.Buffer{}
+	recorder := NewRecorder(buf)
+	tracker := assert.NewTracker[string](t)
+	client := NewDelta(addr, &DeltaADSConfig{Config: Config{Recorder: recorder}}, buildHandlers(t, tracker)...)
+	go client.Run(ctx)
+	tracker.WaitUnordered("add/" + v3.ClusterType + "/test-eds")
+	// The client subscribes to the endpoints of the cluster in response.
+	assert.EventuallyEqual(t, func() bool {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return bytes.Contains(buf.Bytes(), []byte(v3.EndpointType))
+	}, true)
+	cancel()
+	assert.NoError(t, recorder.Close())
+
+	messages, err := ReadRecording(buf)
+	assert.NoError(t, err)
+	for _, m := range messages {
+		assert.Equal(t, m.Stream, 0)
+		assert.Equal(t, m.Delta, true)
+	}
+	responses := slices.FilterInPlace(slices.Clone(messages), func(m RecordedMessage) bool {
+		return m.Direction == DirectionResponse
+	})
+	assert.Equal(t, len(responses), 1)
+	assert.Equal(t, responses[0].TypeURL, v3.ClusterType)
+	assert.Equal(t, responses[0].Nonce, "nonce-1")
+	resp, err := responses[0].Unmarshal()
+	assert.NoError(t, err)
+	assert.Equal(t, resp.(*discovery.DeltaDiscoveryResponse), testClusterResponse)
+
+	requested := slices.Map(slices.FilterInPlace(slices.Clone(messages), func(m RecordedMessage) bool {
+		return m.Direction == DirectionRequest
+	}), func(m RecordedMessage) string {
+		return m.TypeURL
+	})
+	assert.Equal(t, requested[:2], []string{v3.ClusterType, v3.ListenerType})
+	assert.Equal(t, slices.Contains(requested, v3.EndpointType), true)
+}
+
+func TestFileRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recorder, err := NewFileRecorder(path)
+	assert.NoError(t, err)
+	recorder.record(recorder.newStream(), false, DirectionRequest, v3.ClusterType, "", &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+	stream := recorder.newStream()
+	recorder.record(stream, false, DirectionResponse, v3.ClusterType, "nonce", &discovery.DiscoveryResponse{TypeUrl: v3.ClusterType, Nonce: "nonce"})
+	assert.NoError(t, recorder.Close())
+	// Messages are not recorded once the recorder is closed.
+	recorder.record(stream, false, DirectionRequest, v3.ClusterType, "nonce", &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+
+	messages, err := LoadRecording(path)
+	assert.NoError(t, err)
+	assert.Equal(t, len(messages), 2)
+	assert.Equal(t, messages[0].Stream, 0)
+	assert.Equal(t, messages[0].Direction, DirectionRequest)
+	assert.Equal(t, messages[1].Stream, 1)
+	assert.Equal(t, messages[1].Direction, DirectionResponse)
+	assert.Equal(t, messages[1].Nonce, "nonce")
+	resp, err := messages[1].Unmarshal()
+	assert.NoError(t, err)
+	assert.Equal(t, resp.(*discovery.DiscoveryResponse), &discovery.DiscoveryResponse{TypeUrl: v3.ClusterType, Nonce: "nonce"})
+}
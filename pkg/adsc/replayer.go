@@ -0,0 +1,171 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Replayer is a fake xDS server serving the responses of a recording, so the configuration a client received can be
+// reproduced without the control plane it was recorded from.
+//
+// Each stream opened by a client replays the responses of the recorded stream of the same kind, SotW or delta, and
+// index; once they are exhausted, further streams replay the last recorded one. The responses are sent in the recorded
+// order, each once the client has requested its type on the stream. The stream is then kept open until the client
+// closes it.
+type Replayer struct {
+	mu sync.Mutex
+	// sotw and delta are the responses of each recorded stream, in the order the streams were opened.
+	sotw  [][]RecordedMessage
+	delta [][]RecordedMessage
+	// sotwStreams and deltaStreams are the number of streams opened by clients.
+	sotwStreams  int
+	deltaStreams int
+}
+
+var _ discovery.AggregatedDiscoveryServiceServer = &Replayer{}
+
+// NewReplayer returns a replayer serving the recorded messages.
+func NewReplayer(messages []RecordedMessage) *Replayer {
+	type streamKey struct {
+		stream int
+		delta  bool
+	}
+	streams := map[streamKey]int{}
+	r := &Replayer{}
+	for _, m := range messages {
+		if m.Direction != DirectionResponse {
+			continue
+		}
+		key := streamKey{stream: m.Stream, delta: m.Delta}
+		recorded := &r.sotw
+		if m.Delta {
+			recorded = &r.delta
+		}
+		idx, f := streams[key]
+		if !f {
+			idx = len(*recorded)
+			streams[key] = idx
+			*recorded = append(*recorded, nil)
+		}
+		(*recorded)[idx] = append((*recorded)[idx], m)
+	}
+	return r
+}
+
+// nextStream returns the responses to replay for a new stream.
+func (r *Replayer) nextStream(delta bool) []RecordedMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorded, opened := r.sotw, &r.sotwStreams
+	if delta {
+		recorded, opened = r.delta, &r.deltaStreams
+	}
+	idx := *opened
+	*opened++
+	if len(recorded) == 0 {
+		return nil
+	}
+	return recorded[min(idx, len(recorded)-1)]
+}
+
+func (r *Replayer) StreamAggregatedResources(stream discovery.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	return replay(stream.Context(), r.nextStream(false), func() (string, error) {
+		req, err := stream.Recv()
+		if err != nil {
+			return "", err
+		}
+		return req.TypeUrl, nil
+	}, func(msg proto.Message) error {
+		return stream.Send(msg.(*discovery.DiscoveryResponse))
+	})
+}
+
+func (r *Replayer) DeltaAggregatedResources(stream discovery.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	return replay(stream.Context(), r.nextStream(true), func() (string, error) {
+		req, err := stream.Recv()
+		if err != nil {
+			return "", err
+		}
+		return req.TypeUrl, nil
+	}, func(msg proto.Message) error {
+		return stream.Send(msg.(*discovery.DeltaDiscoveryResponse))
+	})
+}
+
+// replay sends the responses on a stream, each once its type is requested. recv returns the type of the next request.
+func replay(ctx context.Context, responses []RecordedMessage, recv func() (string, error), send func(proto.Message) error) error {
+	requests := make(chan string)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			typeURL, err := recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- typeURL:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	requested := sets.New[string]()
+	// wait blocks until the next request is received, and returns whether the stream is still open.
+	wait := func() (bool, error) {
+		select {
+		case typeURL := <-requests:
+			requested.Insert(typeURL)
+			return true, nil
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+			return false, err
+		case <-ctx.Done():
+			return false, nil
+		}
+	}
+	for _, m := range responses {
+		for !requested.Contains(m.TypeURL) {
+			if open, err := wait(); !open {
+				return err
+			}
+		}
+		msg, err := m.Unmarshal()
+		if err != nil {
+			adscLog.Warnf("failed to replay %s response %s: %v", m.TypeURL, m.Nonce, err)
+			continue
+		}
+		if err := send(msg); err != nil {
+			return err
+		}
+	}
+	for {
+		if open, err := wait(); !open {
+			return err
+		}
+	}
+}
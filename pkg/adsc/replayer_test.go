@@ -0,0 +1,136 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adsc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/grpc"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+// serveXds serves the xDS server until the test ends, and returns its address.
+func serveXds(t *testing.T, srv discovery.AggregatedDiscoveryServiceServer) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	xds := grpc.NewServer()
+	discovery.RegisterAggregatedDiscoveryServiceServer(xds, srv)
+	go func() {
+		_ = xds.Serve(l)
+	}()
+	t.Cleanup(xds.Stop)
+	return l.Addr().String()
+}
+
+// recording returns the recording of the responses on a single stream.
+func recording(t *testing.T, delta bool, messages ...any) []RecordedMessage {
+	buf := &bytes.Buffer{}
+	recorder := NewRecorder(buf)
+	stream := recorder.newStream()
+	for _, m := range messages {
+		switch m := m.(type) {
+		case *discovery.DiscoveryResponse:
+			recorder.record(stream, delta, DirectionResponse, m.TypeUrl, m.Nonce, m)
+		case *discovery.DeltaDiscoveryResponse:
+			recorder.record(stream, delta, DirectionResponse, m.TypeUrl, m.Nonce, m)
+		}
+	}
+	assert.NoError(t, recorder.Close())
+	recorded, err := ReadRecording(buf)
+	assert.NoError(t, err)
+	return recorded
+}
+
+func TestReplayerDelta(t *testing.T) {
+	addr := serveXds(t, NewReplayer(recording(t, true, testClusterResponse)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker := assert.NewTracker[string](t)
+	client := NewDelta(addr, &DeltaADSConfig{}, buildHandlers(t, tracker)...)
+	go client.Run(ctx)
+	tracker.WaitUnordered("add/" + v3.ClusterType + "/test-eds")
+	tracker.Empty()
+	cancel()
+	assert.EventuallyEqual(t, client.closed.Load, true)
+	assert.Equal(t, client.dumpTree(), `CDS/:
+  CDS/test-eds:
+    EDS/test-eds:
+LDS/:
+`)
+}
+
+func TestReplayerSotW(t *testing.T) {
+	clusters := &discovery.DiscoveryResponse{
+		TypeUrl:     v3.ClusterType,
+		VersionInfo: "1",
+		Nonce:       "nonce-1",
+		Resources:   []*anypb.Any{protoconv.MessageToAny(testClusterNoSecret)},
+	}
+	listeners := &discovery.DiscoveryResponse{
+		TypeUrl:     v3.ListenerType,
+		VersionInfo: "1",
+		Nonce:       "nonce-2",
+		Resources:   []*anypb.Any{protoconv.MessageToAny(testListenerNoSecret)},
+	}
+	// Responses of delta streams are not replayed to SotW streams.
+	addr := serveXds(t, NewReplayer(append(recording(t, false, clusters, listeners), recording(t, true, testClusterResponse)...)))
+
+	// The recorded stream is replayed again to the following streams.
+	for i := range 2 {
+		a, err := New(addr, &ADSConfig{
+			InitialDiscoveryRequests: []*discovery.DiscoveryRequest{{TypeUrl: v3.ClusterType}, {TypeUrl: v3.ListenerType}},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, a.Run())
+		assert.Equal(t, <-a.XDSUpdates, clusters, fmt.Sprintf("stream %d", i))
+		assert.Equal(t, <-a.XDSUpdates, listeners, fmt.Sprintf("stream %d", i))
+		assert.Equal(t, len(a.GetEdsClusters()), 1)
+		assert.Equal(t, len(a.GetHTTPListeners()), 1)
+		a.Close()
+	}
+}
+
+func TestReplayerStreams(t *testing.T) {
+	messages := []RecordedMessage{
+		{Stream: 0, Direction: DirectionRequest, TypeURL: v3.ClusterType},
+		{Stream: 0, Direction: DirectionResponse, TypeURL: v3.ClusterType, Nonce: "a"},
+		{Stream: 1, Delta: true, Direction: DirectionResponse, TypeURL: v3.ClusterType, Nonce: "b"},
+		{Stream: 2, Direction: DirectionResponse, TypeURL: v3.ClusterType, Nonce: "c"},
+		{Stream: 0, Direction: DirectionResponse, TypeURL: v3.ListenerType, Nonce: "d"},
+	}
+	nonces := func(messages []RecordedMessage) []string {
+		var nonces []string
+		for _, m := range messages {
+			nonces = append(nonces, m.Nonce)
+		}
+		return nonces
+	}
+	r := NewReplayer(messages)
+	assert.Equal(t, nonces(r.nextStream(false)), []string{"a", "d"})
+	assert.Equal(t, nonces(r.nextStream(true)), []string{"b"})
+	assert.Equal(t, nonces(r.nextStream(false)), []string{"c"})
+	assert.Equal(t, nonces(r.nextStream(false)), []string{"c"})
+	assert.Equal(t, nonces(r.nextStream(true)), []string{"b"})
+	assert.Equal(t, nonces(NewReplayer(nil).nextStream(false)), nil)
+}
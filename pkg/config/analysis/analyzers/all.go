@@ -42,6 +42,7 @@ func All() []analysis.Analyzer {
 		// Please keep this list sorted alphabetically by pkg.name for convenience
 		&annotations.K8sAnalyzer{},
 		&authz.AuthorizationPoliciesAnalyzer{},
+		&authz.PolicyRulesAnalyzer{},
 		&deployment.ServiceAssociationAnalyzer{},
 		&deployment.ApplicationUIDAnalyzer{},
 		&deprecation.FieldAnalyzer{},
@@ -54,15 +55,20 @@ func All() []analysis.Analyzer {
 		&injection.ImageAnalyzer{},
 		&injection.ImageAutoAnalyzer{},
 		&k8sgateway.SelectorAnalyzer{},
+		&k8sgateway.RouteAnalyzer{},
 		&multicluster.MeshNetworksAnalyzer{},
 		&service.PortNameAnalyzer{},
 		&sidecar.SelectorAnalyzer{},
+		&sidecar.EgressAnalyzer{},
 		&virtualservice.ConflictingMeshGatewayHostsAnalyzer{},
 		&virtualservice.DestinationHostAnalyzer{},
 		&virtualservice.DestinationRuleAnalyzer{},
 		&virtualservice.GatewayAnalyzer{},
 		&virtualservice.JWTClaimRouteAnalyzer{},
+		&virtualservice.RouteCoverageAnalyzer{},
 		&destinationrule.CaCertificateAnalyzer{},
+		&destinationrule.MTLSConflictAnalyzer{},
+		&destinationrule.SubsetEndpointsAnalyzer{},
 		&serviceentry.ProtocolAddressesAnalyzer{},
 		&webhook.Analyzer{},
 		&envoyfilter.EnvoyPatchAnalyzer{},
@@ -70,6 +76,9 @@ func All() []analysis.Analyzer {
 		&telemetry.SelectorAnalyzer{},
 		&telemetry.DefaultSelectorAnalyzer{},
 		&telemetry.LightstepAnalyzer{},
+		&telemetry.TracingSamplingAnalyzer{},
+		&telemetry.ExpressionAnalyzer{},
+		&telemetry.ProviderServiceAnalyzer{},
 		&multicluster.ServiceAnalyzer{},
 	}
 
@@ -78,6 +87,15 @@ func All() []analysis.Analyzer {
 	return analyzers
 }
 
+// Optional returns the analyzers which are not run by default, as they are expensive. They are only run by
+// istioctl analyze when requested, and never by the in-cluster analysis of istiod.
+func Optional() []analysis.Analyzer {
+	return []analysis.Analyzer{
+		// Generates the configuration of a proxy per workload selected by each EnvoyFilter.
+		&envoyfilter.DryRunAnalyzer{},
+	}
+}
+
 func AllMultiCluster() []analysis.Analyzer {
 	analyzers := []analysis.Analyzer{
 		&multicluster.ServiceAnalyzer{},
@@ -105,6 +105,19 @@ var testGrid = []testCase{
 		},
 		skipAll: true,
 	},
+	{
+		name:       "authorizationPolicyRules",
+		inputFiles: []string{"testdata/authorizationpolicy-rules.yaml"},
+		analyzer:   &authz.PolicyRulesAnalyzer{},
+		expected: []message{
+			{msg.AuthorizationPolicyDeadAllowRule, "AuthorizationPolicy shop/db-allow"},
+			{msg.AuthorizationPolicyRedundantRule, "AuthorizationPolicy shop/db-allow"},
+			{msg.AuthorizationPolicyHTTPFieldsOnTCPPort, "AuthorizationPolicy shop/db-allow"},
+			{msg.AuthorizationPolicyUnmatchablePrincipal, "AuthorizationPolicy shop/db-allow"},
+			{msg.AuthorizationPolicyUnmatchablePrincipal, "AuthorizationPolicy shop/db-allow"},
+			{msg.AuthorizationPolicyUnmatchablePrincipal, "AuthorizationPolicy shop/deny-admin"},
+		},
+	},
 	{
 		name:       "deprecation",
 		inputFiles: []string{"testdata/deprecation.yaml"},
@@ -635,6 +648,42 @@ var testGrid = []testCase{
 		analyzer: &destinationrule.CaCertificateAnalyzer{},
 		expected: []message{},
 	},
+	{
+		name: "destinationrule mtls conflicts with peerauthentication",
+		inputFiles: []string{
+			"testdata/destinationrule-mtls-conflict.yaml",
+		},
+		analyzer: &destinationrule.MTLSConflictAnalyzer{},
+		expected: []message{
+			{msg.MTLSPolicyConflict, "DestinationRule strict/db-disable"},
+			{msg.MTLSPolicyConflict, "DestinationRule default/db-simple-port"},
+			{msg.MTLSPolicyConflict, "DestinationRule strict/db-subsets"},
+			{msg.MTLSPolicyConflict, "DestinationRule plain/legacy-istio-mutual"},
+		},
+	},
+	{
+		name:       "virtualServiceRouteCoverage",
+		inputFiles: []string{"testdata/virtualservice_routecoverage.yaml"},
+		analyzer:   &virtualservice.RouteCoverageAnalyzer{},
+		expected: []message{
+			{msg.VirtualServiceShadowedRoute, "VirtualService default/shadowed-canary"},
+			{msg.VirtualServiceShadowedRoute, "VirtualService default/shadowed-by-several"},
+			{msg.VirtualServiceShadowedRoute, "VirtualService default/early-catch-all"},
+			{msg.VirtualServiceShadowedRoute, "VirtualService default/root"},
+			{msg.VirtualServiceShadowedRoute, "VirtualService reviews/reviews-delegate"},
+		},
+	},
+	{
+		name:       "destinationRuleSubsetEndpoints",
+		inputFiles: []string{"testdata/destinationrule-subset-endpoints.yaml"},
+		analyzer:   &destinationrule.SubsetEndpointsAnalyzer{},
+		expected: []message{
+			{msg.DestinationRuleSubsetNoEndpoints, "DestinationRule default/reviews"},
+			{msg.DestinationRuleSubsetNoEndpoints, "DestinationRule default/external-db"},
+			{msg.VirtualServiceRouteToEmptySubset, "VirtualService default/reviews"},
+			{msg.VirtualServiceRouteToEmptySubset, "VirtualService default/reviews"},
+		},
+	},
 	{
 		name: "dupmatches",
 		inputFiles: []string{
@@ -889,6 +938,25 @@ var testGrid = []testCase{
 			{msg.EnvoyFilterUsesRelativeOperation, "EnvoyFilter bookinfo/test-remove-5"},
 		},
 	},
+	{
+		name:       "EnvoyFilterMatchesInternalName",
+		inputFiles: []string{"testdata/envoy-filter-internal-names.yaml"},
+		analyzer:   &envoyfilter.EnvoyPatchAnalyzer{},
+		expected: []message{
+			{msg.EnvoyFilterMatchesInternalName, "EnvoyFilter bookinfo/test-internal-1"},
+			{msg.EnvoyFilterMatchesInternalName, "EnvoyFilter bookinfo/test-internal-2"},
+			{msg.EnvoyFilterMatchesInternalName, "EnvoyFilter bookinfo/test-internal-2"},
+		},
+	},
+	{
+		name:       "EnvoyFilterDryRun",
+		inputFiles: []string{"testdata/envoy-filter-dry-run.yaml"},
+		analyzer:   &envoyfilter.DryRunAnalyzer{},
+		expected: []message{
+			{msg.EnvoyFilterPatchNoMatch, "EnvoyFilter default/reviews-fault"},
+			{msg.EnvoyFilterPatchInvalidConfig, "EnvoyFilter default/reviews-timeout"},
+		},
+	},
 	{
 		name:       "Analyze conflicting gateway with list type",
 		inputFiles: []string{"testdata/analyze-list-type.yaml"},
@@ -944,6 +1012,39 @@ var testGrid = []testCase{
 			{msg.Deprecated, "Telemetry istio-system/mesh-default"},
 		},
 	},
+	{
+		name:           "telemetryTracingSampling",
+		inputFiles:     []string{"testdata/telemetry-consistency.yaml"},
+		analyzer:       &telemetry.TracingSamplingAnalyzer{},
+		meshConfigFile: "testdata/telemetry-consistency-meshconfig.yaml",
+		expected: []message{
+			{msg.TelemetryTracingSamplingConflict, "Telemetry istio-system/mesh-default"},
+			{msg.TelemetryTracingSamplingConflict, "Telemetry ns1/otel-sampling"},
+			{msg.TelemetryTracingSamplingConflict, "Telemetry ns2/no-provider"},
+		},
+	},
+	{
+		name:       "telemetryExpressions",
+		inputFiles: []string{"testdata/telemetry-consistency.yaml"},
+		analyzer:   &telemetry.ExpressionAnalyzer{},
+		expected: []message{
+			{msg.InvalidTelemetryExpression, "Telemetry ns4/invalid-expressions"},
+			{msg.TelemetryUnknownReference, "Telemetry ns4/invalid-expressions"},
+			{msg.TelemetryUnknownReference, "Telemetry ns4/invalid-expressions"},
+			{msg.TelemetryUnknownReference, "Telemetry ns4/invalid-expressions"},
+			{msg.TelemetryUnknownReference, "Telemetry ns4/invalid-expressions"},
+		},
+	},
+	{
+		name:           "telemetryProviderService",
+		inputFiles:     []string{"testdata/telemetry-consistency.yaml"},
+		analyzer:       &telemetry.ProviderServiceAnalyzer{},
+		meshConfigFile: "testdata/telemetry-consistency-meshconfig.yaml",
+		expected: []message{
+			{msg.TelemetryProviderServiceNotFound, "Telemetry istio-system/mesh-default"},
+			{msg.TelemetryProviderServiceNotFound, "Telemetry ns4/collector"},
+		},
+	},
 	{
 		name:       "KubernetesGatewaySelector",
 		inputFiles: []string{"testdata/k8sgateway-selector.yaml"},
@@ -955,6 +1056,36 @@ var testGrid = []testCase{
 			{msg.IneffectiveSelector, "Telemetry default/telemetry-ineffective"},
 		},
 	},
+	{
+		name:       "sidecarEgress",
+		inputFiles: []string{"testdata/sidecar-egress.yaml"},
+		analyzer:   &sidecar.EgressAnalyzer{},
+		expected: []message{
+			{msg.ReferencedResourceNotFound, "Sidecar default/default"},
+			{msg.ReferencedResourceNotFound, "Sidecar default/default"},
+			{msg.SidecarEgressHostNotImported, "Sidecar default/default"},
+		},
+	},
+	{
+		name:       "sidecarEgressNoNamespaces",
+		inputFiles: []string{"testdata/sidecar-egress-no-namespaces.yaml"},
+		analyzer:   &sidecar.EgressAnalyzer{},
+		expected:   []message{},
+	},
+	{
+		name:       "k8sgatewayRoutes",
+		inputFiles: []string{"testdata/k8sgateway-routes.yaml"},
+		analyzer:   &k8sgateway.RouteAnalyzer{},
+		expected: []message{
+			{msg.GatewayAPIRouteNotAccepted, "HTTPRoute default/hostname-mismatch"},
+			{msg.GatewayAPIRouteNotAccepted, "HTTPRoute other/other-namespace"},
+			{msg.GatewayAPIRouteNotAccepted, "HTTPRoute default/missing-parents"},
+			{msg.GatewayAPIRouteNotAccepted, "HTTPRoute default/missing-parents"},
+			{msg.GatewayAPIReferenceNotPermitted, "Gateway default/gateway"},
+			{msg.GatewayAPIReferenceNotPermitted, "HTTPRoute default/not-permitted"},
+			{msg.GatewayAPIRouteConflict, "HTTPRoute default/conflict"},
+		},
+	},
 	{
 		name:       "ServiceEntry Addresses Required Lowercase Protocol",
 		inputFiles: []string{"testdata/serviceentry-address-required-lowercase.yaml"},
@@ -1020,7 +1151,7 @@ func TestAnalyzers(t *testing.T) {
 	t.Run("CheckMetadataInputs", func(t *testing.T) {
 		g := NewWithT(t)
 	outer:
-		for _, a := range All() {
+		for _, a := range append(All(), Optional()...) {
 			var isMultiClusterAnalyzer bool
 			for _, mc := range AllMultiCluster() {
 				if a.Metadata().Name == mc.Metadata().Name {
@@ -1056,12 +1187,12 @@ func TestAnalyzers(t *testing.T) {
 	})
 }
 
-// Verify that all of the analyzers tested here are also registered in All()
+// Verify that all of the analyzers tested here are also registered in All() or Optional()
 func TestAnalyzersInAll(t *testing.T) {
 	g := NewWithT(t)
 
 	var allNames []string
-	for _, a := range All() {
+	for _, a := range append(All(), Optional()...) {
 		allNames = append(allNames, a.Metadata().Name)
 	}
 
@@ -1076,14 +1207,14 @@ func TestAnalyzersHaveUniqueNames(t *testing.T) {
 	g := NewWithT(t)
 
 	existingNames := sets.New[string]()
-	for _, a := range All() {
+	for _, a := range append(All(), Optional()...) {
 		n := a.Metadata().Name
 		// TODO (Nino-K): remove this condition once metadata is clean up
 		if existingNames.Contains(n) && n == "schema.ValidationAnalyzer.ServiceEntry" {
 			continue
 		}
 		g.Expect(existingNames.Contains(n)).To(BeFalse(), fmt.Sprintf("Analyzer name %q is used more than once. "+
-			"Analyzers should be registered in All() exactly once and have a unique name.", n))
+			"Analyzers should be registered in All() or Optional() exactly once and have a unique name.", n))
 
 		existingNames.Insert(n)
 	}
@@ -1092,7 +1223,7 @@ func TestAnalyzersHaveUniqueNames(t *testing.T) {
 func TestAnalyzersHaveDescription(t *testing.T) {
 	g := NewWithT(t)
 
-	for _, a := range All() {
+	for _, a := range append(All(), Optional()...) {
 		g.Expect(a.Metadata().Description).ToNot(Equal(""))
 	}
 }
@@ -0,0 +1,191 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"net/netip"
+	"strings"
+
+	"istio.io/api/security/v1beta1"
+)
+
+// condition is a constraint on a single attribute of a request: the attribute must match one of values, if any, and
+// must not match any of notValues.
+type condition struct {
+	values    []string
+	notValues []string
+}
+
+// constraints are conditions on several attributes, which must all be satisfied.
+type constraints map[string]condition
+
+// ruleModel is the set of requests matched by an authorization policy rule: any of the sources, any of the
+// operations, and all of the conditions.
+type ruleModel struct {
+	from []constraints
+	to   []constraints
+	when constraints
+}
+
+func (c constraints) add(key string, values, notValues []string) {
+	if len(values) == 0 && len(notValues) == 0 {
+		return
+	}
+	c[key] = condition{values: values, notValues: notValues}
+}
+
+func newRuleModel(r *v1beta1.Rule) ruleModel {
+	m := ruleModel{when: constraints{}}
+	for _, f := range r.GetFrom() {
+		s := f.GetSource()
+		c := constraints{}
+		c.add("principals", s.GetPrincipals(), s.GetNotPrincipals())
+		c.add("requestPrincipals", s.GetRequestPrincipals(), s.GetNotRequestPrincipals())
+		c.add("namespaces", s.GetNamespaces(), s.GetNotNamespaces())
+		c.add("ipBlocks", s.GetIpBlocks(), s.GetNotIpBlocks())
+		c.add("remoteIpBlocks", s.GetRemoteIpBlocks(), s.GetNotRemoteIpBlocks())
+		m.from = append(m.from, c)
+	}
+	for _, t := range r.GetTo() {
+		o := t.GetOperation()
+		c := constraints{}
+		c.add("hosts", o.GetHosts(), o.GetNotHosts())
+		c.add("ports", o.GetPorts(), o.GetNotPorts())
+		c.add("methods", o.GetMethods(), o.GetNotMethods())
+		c.add("paths", o.GetPaths(), o.GetNotPaths())
+		m.to = append(m.to, c)
+	}
+	for _, w := range r.GetWhen() {
+		m.when.add(w.GetKey(), w.GetValues(), w.GetNotValues())
+	}
+	// A rule without sources or operations matches any source or operation.
+	if len(m.from) == 0 {
+		m.from = []constraints{{}}
+	}
+	if len(m.to) == 0 {
+		m.to = []constraints{{}}
+	}
+	return m
+}
+
+// covers returns true if every request matched by o is also matched by m. The check is conservative: it may return
+// false for rules that do cover each other, for example when the same attribute is constrained by different fields.
+func (m ruleModel) covers(o ruleModel) bool {
+	if !m.when.covers(o.when) {
+		return false
+	}
+	return coversAny(m.from, o.from) && coversAny(m.to, o.to)
+}
+
+// coversAny returns true if each of the alternatives of o is covered by one of the alternatives of m.
+func coversAny(m, o []constraints) bool {
+	for _, oc := range o {
+		covered := false
+		for _, mc := range m {
+			if mc.covers(oc) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func (c constraints) covers(o constraints) bool {
+	for key, mc := range c {
+		if !mc.covers(key, o[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) covers(key string, o condition) bool {
+	if len(c.values) > 0 {
+		// Every value o may match must be matched by one of our values.
+		if len(o.values) == 0 {
+			return false
+		}
+		for _, ov := range o.values {
+			if !matchesAny(key, c.values, ov) {
+				return false
+			}
+		}
+	}
+	// Everything we exclude must also be excluded by o.
+	for _, nv := range c.notValues {
+		if !matchesAny(key, o.notValues, nv) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(key string, patterns []string, value string) bool {
+	for _, p := range patterns {
+		if valueCovers(key, p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueCovers returns true if every attribute value matched by the pattern o is also matched by the pattern p.
+func valueCovers(key, p, o string) bool {
+	if p == o || p == "*" {
+		return true
+	}
+	switch key {
+	case "ipBlocks", "remoteIpBlocks", "source.ip", "remote.ip", "destination.ip":
+		pp, perr := parsePrefix(p)
+		op, oerr := parsePrefix(o)
+		return perr == nil && oerr == nil && pp.Bits() <= op.Bits() && pp.Contains(op.Addr())
+	case "hosts":
+		p, o = strings.ToLower(p), strings.ToLower(o)
+		if p == o {
+			return true
+		}
+	}
+	switch {
+	case strings.HasSuffix(p, "*"):
+		prefix := strings.TrimSuffix(p, "*")
+		if strings.HasPrefix(o, "*") {
+			return false
+		}
+		return strings.HasPrefix(strings.TrimSuffix(o, "*"), prefix)
+	case strings.HasPrefix(p, "*"):
+		suffix := strings.TrimPrefix(p, "*")
+		if strings.HasSuffix(o, "*") {
+			return false
+		}
+		return strings.HasSuffix(strings.TrimPrefix(o, "*"), suffix)
+	}
+	return false
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
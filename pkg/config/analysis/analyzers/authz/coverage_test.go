@@ -0,0 +1,133 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"testing"
+
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestValueCovers(t *testing.T) {
+	assert.Equal(t, valueCovers("paths", "*", "/foo"), true)
+	assert.Equal(t, valueCovers("paths", "/foo*", "/foo/bar"), true)
+	assert.Equal(t, valueCovers("paths", "/foo*", "/foo/bar*"), true)
+	assert.Equal(t, valueCovers("paths", "/foo/bar*", "/foo*"), false)
+	assert.Equal(t, valueCovers("paths", "/foo*", "*/foo"), false)
+	assert.Equal(t, valueCovers("hosts", "*.example.com", "API.example.com"), true)
+	assert.Equal(t, valueCovers("hosts", "*.example.com", "*.api.example.com"), true)
+	assert.Equal(t, valueCovers("hosts", "Example.com", "example.COM"), true)
+	assert.Equal(t, valueCovers("ipBlocks", "10.0.0.0/8", "10.1.2.3"), true)
+	assert.Equal(t, valueCovers("ipBlocks", "10.0.0.0/8", "10.1.0.0/16"), true)
+	assert.Equal(t, valueCovers("ipBlocks", "10.1.0.0/16", "10.0.0.0/8"), false)
+	assert.Equal(t, valueCovers("ports", "80", "8080"), false)
+}
+
+func TestRuleCovers(t *testing.T) {
+	source := func(s *v1beta1.Source) []*v1beta1.Rule_From {
+		return []*v1beta1.Rule_From{{Source: s}}
+	}
+	operation := func(o *v1beta1.Operation) []*v1beta1.Rule_To {
+		return []*v1beta1.Rule_To{{Operation: o}}
+	}
+	cases := []struct {
+		name string
+		rule *v1beta1.Rule
+		// other is covered by rule, if want is true
+		other *v1beta1.Rule
+		want  bool
+	}{
+		{
+			name:  "empty rule matches everything",
+			rule:  &v1beta1.Rule{},
+			other: &v1beta1.Rule{From: source(&v1beta1.Source{Namespaces: []string{"foo"}})},
+			want:  true,
+		},
+		{
+			name:  "restricted rule does not cover empty rule",
+			rule:  &v1beta1.Rule{From: source(&v1beta1.Source{Namespaces: []string{"foo"}})},
+			other: &v1beta1.Rule{},
+			want:  false,
+		},
+		{
+			name: "additional conditions narrow the rule",
+			rule: &v1beta1.Rule{From: source(&v1beta1.Source{Namespaces: []string{"foo", "bar"}})},
+			other: &v1beta1.Rule{
+				From: source(&v1beta1.Source{Namespaces: []string{"foo"}, Principals: []string{"cluster.local/ns/foo/sa/a"}}),
+				To:   operation(&v1beta1.Operation{Methods: []string{"GET"}}),
+			},
+			want: true,
+		},
+		{
+			name:  "value not covered",
+			rule:  &v1beta1.Rule{From: source(&v1beta1.Source{Namespaces: []string{"foo"}})},
+			other: &v1beta1.Rule{From: source(&v1beta1.Source{Namespaces: []string{"foo", "bar"}})},
+			want:  false,
+		},
+		{
+			name:  "every source must be covered",
+			rule:  &v1beta1.Rule{From: source(&v1beta1.Source{Namespaces: []string{"foo"}})},
+			other: &v1beta1.Rule{From: append(source(&v1beta1.Source{Namespaces: []string{"foo"}}), source(&v1beta1.Source{Namespaces: []string{"bar"}})...)},
+			want:  false,
+		},
+		{
+			name:  "negated values must be excluded by the other rule",
+			rule:  &v1beta1.Rule{To: operation(&v1beta1.Operation{NotPaths: []string{"/admin*"}})},
+			other: &v1beta1.Rule{To: operation(&v1beta1.Operation{Paths: []string{"/api"}, NotPaths: []string{"/admin*", "/debug"}})},
+			want:  true,
+		},
+		{
+			name:  "negated values not excluded",
+			rule:  &v1beta1.Rule{To: operation(&v1beta1.Operation{NotPaths: []string{"/admin*"}})},
+			other: &v1beta1.Rule{To: operation(&v1beta1.Operation{Paths: []string{"/api"}})},
+			want:  false,
+		},
+		{
+			name: "conditions",
+			rule: &v1beta1.Rule{When: []*v1beta1.Condition{{Key: "request.headers[x-env]", Values: []string{"prod*"}}}},
+			other: &v1beta1.Rule{When: []*v1beta1.Condition{
+				{Key: "request.headers[x-env]", Values: []string{"prod-eu"}},
+				{Key: "source.ip", Values: []string{"10.0.0.1"}},
+			}},
+			want: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, newRuleModel(tc.rule).covers(newRuleModel(tc.other)), tc.want)
+		})
+	}
+}
+
+func TestUnmatchablePrincipal(t *testing.T) {
+	tds := []string{"td-new", "td-old"}
+	for principal, matchable := range map[string]bool{
+		"cluster.local/ns/foo/sa/bar":         true,
+		"td-old/ns/foo/sa/bar":                true,
+		"*/ns/foo/sa/bar":                     true,
+		"td-*/ns/foo/sa/bar":                  true,
+		"td-new/ns/foo/*":                     true,
+		"*":                                   true,
+		"*bar":                                true,
+		"other/ns/foo/sa/bar":                 false,
+		"other/ns/foo/*":                      false,
+		"spiffe://td-new/ns/foo/sa/bar":       false,
+		"bar":                                 false,
+		"td-new/namespace/foo/serviceaccount": false,
+	} {
+		assert.Equal(t, unmatchablePrincipal(principal, tds) == "", matchable, principal)
+	}
+}
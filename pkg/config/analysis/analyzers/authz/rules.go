@@ -0,0 +1,412 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// PolicyRulesAnalyzer checks the rules of authorization policies against the other policies applying to the same
+// workloads: ALLOW rules that are dead because of DENY rules, or redundant because of other ALLOW rules. It also
+// reports rules using HTTP-only fields on TCP ports, and principals that can never match the mesh trust domain.
+type PolicyRulesAnalyzer struct{}
+
+var _ analysis.Analyzer = &PolicyRulesAnalyzer{}
+
+func (a *PolicyRulesAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.PolicyRulesAnalyzer",
+		Description: "Checks for unreachable, shadowed and ineffective authorization policy rules",
+		Inputs: []config.GroupVersionKind{
+			gvk.MeshConfig,
+			gvk.AuthorizationPolicy,
+			gvk.Service,
+			gvk.Pod,
+			gvk.Deployment,
+		},
+	}
+}
+
+// policy is an ALLOW or DENY authorization policy applying to workloads selected by labels.
+type policy struct {
+	r        *resource.Instance
+	ap       *v1beta1.AuthorizationPolicy
+	ns       resource.Namespace
+	labels   map[string]string
+	rules    []ruleModel
+	meshWide bool
+}
+
+func (p *policy) name() string {
+	return p.ns.String() + "/" + p.r.Metadata.FullName.Name.String()
+}
+
+// contains returns true if p applies to every workload o applies to.
+func (p *policy) contains(o *policy) bool {
+	if !p.meshWide && p.ns != o.ns {
+		return false
+	}
+	if o.meshWide && !p.meshWide {
+		return false
+	}
+	return maps.Contains(o.labels, p.labels)
+}
+
+// workload is a Pod, or the pod template of a Deployment.
+type workload struct {
+	name   string
+	labels klabels.Set
+	spec   *corev1.PodSpec
+}
+
+func (a *PolicyRulesAnalyzer) Analyze(c analysis.Context) {
+	mc := &v1alpha1.MeshConfig{}
+	rootNamespace := resource.Namespace(constants.IstioSystemNamespace)
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		mc = r.Message.(*v1alpha1.MeshConfig)
+		if mc.GetRootNamespace() != "" {
+			rootNamespace = resource.Namespace(mc.GetRootNamespace())
+		}
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	var allows, denies []*policy
+	c.ForEach(gvk.AuthorizationPolicy, func(r *resource.Instance) bool {
+		ap := r.Message.(*v1beta1.AuthorizationPolicy)
+		// Policies attached to gateways and waypoints, and delegated policies, are out of scope.
+		if ap.GetTargetRef() != nil || len(ap.GetTargetRefs()) > 0 {
+			return true
+		}
+		p := &policy{
+			r:        r,
+			ap:       ap,
+			ns:       r.Metadata.FullName.Namespace,
+			labels:   ap.GetSelector().GetMatchLabels(),
+			meshWide: r.Metadata.FullName.Namespace == rootNamespace,
+		}
+		for _, rule := range ap.GetRules() {
+			p.rules = append(p.rules, newRuleModel(rule))
+		}
+		switch ap.GetAction() {
+		case v1beta1.AuthorizationPolicy_ALLOW:
+			allows = append(allows, p)
+		case v1beta1.AuthorizationPolicy_DENY:
+			denies = append(denies, p)
+		}
+		a.analyzePrincipals(c, r, ap, mc)
+		return true
+	})
+	sortPolicies(allows)
+	sortPolicies(denies)
+
+	a.analyzeDeadAllowRules(c, allows, denies)
+	a.analyzeRedundantAllowRules(c, allows)
+	a.analyzeHTTPFieldsOnTCPPorts(c, append(allows, denies...))
+}
+
+// sortPolicies orders mesh wide policies first, then by namespace and name, which defines which of two equivalent
+// rules is reported as redundant.
+func sortPolicies(ps []*policy) {
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].meshWide != ps[j].meshWide {
+			return ps[i].meshWide
+		}
+		return ps[i].name() < ps[j].name()
+	})
+}
+
+func (a *PolicyRulesAnalyzer) analyzeDeadAllowRules(c analysis.Context, allows, denies []*policy) {
+	for _, allow := range allows {
+		for i, rule := range allow.rules {
+		deny:
+			for _, deny := range denies {
+				if !deny.contains(allow) {
+					continue
+				}
+				for j, dr := range deny.rules {
+					if dr.covers(rule) {
+						m := msg.NewAuthorizationPolicyDeadAllowRule(allow.r, i, j, deny.name())
+						reportRule(c, allow.r, i, m)
+						break deny
+					}
+				}
+			}
+		}
+	}
+}
+
+func (a *PolicyRulesAnalyzer) analyzeRedundantAllowRules(c analysis.Context, allows []*policy) {
+	for pi, allow := range allows {
+		for i, rule := range allow.rules {
+		other:
+			// Only rules ordered before this one are considered, so only one of two equivalent rules is reported.
+			for _, o := range allows[:pi+1] {
+				if !o.contains(allow) {
+					continue
+				}
+				for j, or := range o.rules {
+					if o == allow && j >= i {
+						break
+					}
+					if or.covers(rule) {
+						m := msg.NewAuthorizationPolicyRedundantRule(allow.r, i, j, o.name())
+						reportRule(c, allow.r, i, m)
+						break other
+					}
+				}
+			}
+		}
+	}
+}
+
+func (a *PolicyRulesAnalyzer) analyzeHTTPFieldsOnTCPPorts(c analysis.Context, policies []*policy) {
+	workloads := map[resource.Namespace][]workload{}
+	for _, w := range util.Workloads(c) {
+		ns := w.Resource.Metadata.FullName.Namespace
+		workloads[ns] = append(workloads[ns], workload{
+			name:   w.Kind + " " + w.Resource.Metadata.FullName.String(),
+			labels: w.Labels,
+			spec:   w.Spec,
+		})
+	}
+	services := map[resource.Namespace][]*corev1.ServiceSpec{}
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		services[ns] = append(services[ns], r.Message.(*corev1.ServiceSpec))
+		return true
+	})
+
+	for _, p := range policies {
+		for i, rule := range p.ap.GetRules() {
+			fields := httpOnlyFields(rule)
+			if len(fields) == 0 {
+				continue
+			}
+			rulePorts, allPorts := rulePorts(rule)
+			tcpPorts := sets.New[uint32]()
+			var names []string
+			for ns, wls := range workloads {
+				if !p.meshWide && ns != p.ns {
+					continue
+				}
+				for _, wl := range wls {
+					if !klabels.SelectorFromSet(p.labels).Matches(wl.labels) {
+						continue
+					}
+					found := false
+					for port := range tcpTargetPorts(wl, services[ns]) {
+						if allPorts || rulePorts.Contains(port) {
+							tcpPorts.Insert(port)
+							found = true
+						}
+					}
+					if found {
+						names = append(names, wl.name)
+					}
+				}
+			}
+			if len(tcpPorts) == 0 {
+				continue
+			}
+			effect := "The rule is ignored for TCP traffic, so it never allows it."
+			if p.ap.GetAction() == v1beta1.AuthorizationPolicy_DENY {
+				effect = "The HTTP-only fields are ignored for TCP traffic, so the rule denies all TCP traffic matching its other conditions."
+			}
+			ports := make([]string, 0, len(tcpPorts))
+			for _, port := range sets.SortedList(tcpPorts) {
+				ports = append(ports, strconv.Itoa(int(port)))
+			}
+			sort.Strings(names)
+			m := msg.NewAuthorizationPolicyHTTPFieldsOnTCPPort(p.r, i, strings.Join(fields, ", "),
+				strings.Join(ports, ", "), strings.Join(names, ", "), effect)
+			reportRule(c, p.r, i, m)
+		}
+	}
+}
+
+// httpOnlyFields returns the fields of the rule that only apply to HTTP traffic. When generating the RBAC filter for
+// TCP traffic, pilot/pkg/security/authz/builder drops them.
+func httpOnlyFields(rule *v1beta1.Rule) []string {
+	fields := sets.New[string]()
+	for _, f := range rule.GetFrom() {
+		s := f.GetSource()
+		if len(s.GetRequestPrincipals()) > 0 || len(s.GetNotRequestPrincipals()) > 0 {
+			fields.Insert("requestPrincipals")
+		}
+	}
+	for _, t := range rule.GetTo() {
+		o := t.GetOperation()
+		if len(o.GetHosts()) > 0 || len(o.GetNotHosts()) > 0 {
+			fields.Insert("hosts")
+		}
+		if len(o.GetMethods()) > 0 || len(o.GetNotMethods()) > 0 {
+			fields.Insert("methods")
+		}
+		if len(o.GetPaths()) > 0 || len(o.GetNotPaths()) > 0 {
+			fields.Insert("paths")
+		}
+	}
+	for _, w := range rule.GetWhen() {
+		if strings.HasPrefix(w.GetKey(), "request.") {
+			fields.Insert(w.GetKey())
+		}
+	}
+	return sets.SortedList(fields)
+}
+
+// rulePorts returns the destination ports the rule is restricted to, or true if it applies to all ports.
+func rulePorts(rule *v1beta1.Rule) (sets.Set[uint32], bool) {
+	ports := sets.New[uint32]()
+	add := func(values []string) {
+		for _, v := range values {
+			if p, err := strconv.ParseUint(v, 10, 32); err == nil {
+				ports.Insert(uint32(p))
+			}
+		}
+	}
+	restricted := false
+	for _, w := range rule.GetWhen() {
+		if w.GetKey() == "destination.port" && len(w.GetValues()) > 0 {
+			add(w.GetValues())
+			restricted = true
+		}
+	}
+	if len(rule.GetTo()) == 0 {
+		return ports, !restricted
+	}
+	for _, t := range rule.GetTo() {
+		if len(t.GetOperation().GetPorts()) == 0 {
+			// An operation without ports applies to all ports, unless the rule restricts them with a condition.
+			return ports, !restricted
+		}
+		add(t.GetOperation().GetPorts())
+	}
+	return ports, false
+}
+
+// tcpTargetPorts returns the workload ports that services declare as TCP, for which HTTP attributes are unavailable.
+// Ports whose protocol is sniffed are not included.
+func tcpTargetPorts(wl workload, services []*corev1.ServiceSpec) sets.Set[uint32] {
+	ports := sets.New[uint32]()
+	for _, svc := range services {
+		if len(svc.Selector) == 0 || !klabels.SelectorFromSet(svc.Selector).Matches(wl.labels) {
+			continue
+		}
+		for _, sp := range svc.Ports {
+			proto := kube.ConvertProtocol(sp.Port, sp.Name, sp.Protocol, sp.AppProtocol)
+			if !proto.IsTCP() {
+				continue
+			}
+			if target, ok := util.ResolveTargetPort(sp, wl.spec); ok {
+				ports.Insert(target)
+			}
+		}
+	}
+	return ports
+}
+
+// analyzePrincipals reports principals that can never match the identity of a mesh workload.
+func (a *PolicyRulesAnalyzer) analyzePrincipals(c analysis.Context, r *resource.Instance, ap *v1beta1.AuthorizationPolicy, mc *v1alpha1.MeshConfig) {
+	trustDomain := mc.GetTrustDomain()
+	if trustDomain == "" {
+		trustDomain = constants.DefaultClusterLocalDomain
+	}
+	trustDomains := append([]string{trustDomain}, mc.GetTrustDomainAliases()...)
+	check := func(principal string, rule int, path string) {
+		reason := unmatchablePrincipal(principal, trustDomains)
+		if reason == "" {
+			return
+		}
+		m := msg.NewAuthorizationPolicyUnmatchablePrincipal(r, principal, rule, reason)
+		if line, ok := util.ErrorLine(r, path); ok {
+			m.Line = line
+		}
+		c.Report(gvk.AuthorizationPolicy, m)
+	}
+	for i, rule := range ap.GetRules() {
+		for j, f := range rule.GetFrom() {
+			for k, p := range f.GetSource().GetPrincipals() {
+				check(p, i, fmt.Sprintf(util.AuthorizationPolicyPrincipal, i, j, k))
+			}
+			for k, p := range f.GetSource().GetNotPrincipals() {
+				check(p, i, fmt.Sprintf(util.AuthorizationPolicyNotPrincipal, i, j, k))
+			}
+		}
+		for j, w := range rule.GetWhen() {
+			if w.GetKey() != "source.principal" {
+				continue
+			}
+			for k, p := range w.GetValues() {
+				check(p, i, fmt.Sprintf(util.AuthorizationPolicyWhenValue, i, j, k))
+			}
+			for k, p := range w.GetNotValues() {
+				check(p, i, fmt.Sprintf(util.AuthorizationPolicyWhenNotValue, i, j, k))
+			}
+		}
+	}
+}
+
+// unmatchablePrincipal returns why the principal can never match a workload identity, or an empty string if it can.
+func unmatchablePrincipal(principal string, trustDomains []string) string {
+	wildcard := strings.Contains(principal, "*")
+	parts := strings.Split(principal, "/")
+	switch {
+	case strings.HasPrefix(principal, "spiffe://"):
+		return "principals must not include the spiffe:// prefix"
+	case wildcard && (len(parts) < 2 || parts[1] != "ns"):
+		// Wildcards are matched as prefixes or suffixes of the identity.
+		return ""
+	case !wildcard && (len(parts) != 5 || parts[1] != "ns" || parts[3] != "sa"):
+		return "principals must be in the <trust-domain>/ns/<namespace>/sa/<service-account> format"
+	}
+	td := parts[0]
+	// cluster.local always refers to the trust domain of the mesh.
+	if strings.Contains(td, "*") || td == constants.DefaultClusterLocalDomain {
+		return ""
+	}
+	for _, t := range trustDomains {
+		if t == td {
+			return ""
+		}
+	}
+	return fmt.Sprintf("trust domain %q is not the mesh trust domain or one of its aliases (%s)", td, strings.Join(trustDomains, ", "))
+}
+
+func reportRule(c analysis.Context, r *resource.Instance, rule int, m diag.Message) {
+	if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.AuthorizationPolicyRule, rule)); ok {
+		m.Line = line
+	}
+	c.Report(gvk.AuthorizationPolicy, m)
+}
@@ -0,0 +1,278 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// MTLSConflictAnalyzer checks that the client TLS mode set by DestinationRules is compatible with the mTLS mode
+// PeerAuthentications require on the workloads behind the destination host.
+type MTLSConflictAnalyzer struct{}
+
+var _ analysis.Analyzer = &MTLSConflictAnalyzer{}
+
+func (m *MTLSConflictAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.MTLSConflictAnalyzer",
+		Description: "Checks that DestinationRule TLS modes are compatible with the PeerAuthentication mTLS modes of the destination workloads",
+		Inputs: []config.GroupVersionKind{
+			gvk.DestinationRule,
+			gvk.PeerAuthentication,
+			gvk.Service,
+			gvk.Pod,
+			gvk.Deployment,
+			gvk.MeshConfig,
+		},
+	}
+}
+
+// serverMode is the effective mTLS mode of a workload port, and the PeerAuthentication it comes from.
+type serverMode struct {
+	mode   v1beta1.PeerAuthentication_MutualTLS_Mode
+	policy string
+}
+
+// peerAuthentications holds the PeerAuthentications of the mesh, by scope.
+type peerAuthentications struct {
+	root      *resource.Instance
+	namespace map[resource.Namespace]*resource.Instance
+	workload  map[resource.Namespace][]*resource.Instance
+}
+
+type backendPod struct {
+	labels klabels.Set
+	spec   *corev1.PodSpec
+}
+
+type backendService struct {
+	namespace resource.Namespace
+	fqdn      host.Name
+	spec      *corev1.ServiceSpec
+}
+
+func (m *MTLSConflictAnalyzer) Analyze(c analysis.Context) {
+	pas := initPeerAuthentications(c)
+
+	pods := map[resource.Namespace][]backendPod{}
+	for _, w := range util.Workloads(c) {
+		ns := w.Resource.Metadata.FullName.Namespace
+		pods[ns] = append(pods[ns], backendPod{labels: w.Labels, spec: w.Spec})
+	}
+
+	var services []backendService
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		services = append(services, backendService{
+			namespace: r.Metadata.FullName.Namespace,
+			fqdn:      host.Name(util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, r.Metadata.FullName.Name.String())),
+			spec:      r.Message.(*corev1.ServiceSpec),
+		})
+		return true
+	})
+
+	c.ForEach(gvk.DestinationRule, func(r *resource.Instance) bool {
+		m.analyzeDestinationRule(r, c, pas, services, pods)
+		return true
+	})
+}
+
+// clientPolicy is a traffic policy of a DestinationRule, along with the subset it applies to.
+type clientPolicy struct {
+	policy *v1alpha3.TrafficPolicy
+	labels klabels.Set
+	// subset is the index of the subset, or -1 for the top level policy.
+	subset int
+}
+
+func (m *MTLSConflictAnalyzer) analyzeDestinationRule(r *resource.Instance, c analysis.Context, pas peerAuthentications,
+	services []backendService, pods map[resource.Namespace][]backendPod,
+) {
+	dr := r.Message.(*v1alpha3.DestinationRule)
+	drHost := host.Name(util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, dr.GetHost()))
+
+	policies := []clientPolicy{{policy: dr.GetTrafficPolicy(), subset: -1}}
+	for i, ss := range dr.GetSubsets() {
+		policies = append(policies, clientPolicy{policy: ss.GetTrafficPolicy(), labels: ss.GetLabels(), subset: i})
+	}
+
+	reported := sets.New[string]()
+	for _, svc := range services {
+		if !drHost.Matches(svc.fqdn) || len(svc.spec.Selector) == 0 {
+			continue
+		}
+		selector := klabels.SelectorFromSet(svc.spec.Selector)
+		for _, port := range svc.spec.Ports {
+			for _, p := range policies {
+				tls, path := p.clientTLS(uint32(port.Port))
+				if tls == nil {
+					// Without TLS settings, auto mTLS picks the mode matching the server.
+					continue
+				}
+				clientMode := tls.GetMode()
+				for _, pod := range pods[svc.namespace] {
+					if !selector.Matches(pod.labels) || (p.labels != nil && !klabels.SelectorFromSet(p.labels).Matches(pod.labels)) {
+						continue
+					}
+					targetPort, ok := util.ResolveTargetPort(port, pod.spec)
+					if !ok {
+						continue
+					}
+					server := pas.effectiveMode(svc.namespace, pod.labels, targetPort)
+					if !conflicts(clientMode, server.mode) {
+						continue
+					}
+					key := fmt.Sprintf("%s/%d/%s/%v", svc.fqdn, port.Port, path, server)
+					if reported.InsertContains(key) {
+						continue
+					}
+					message := msg.NewMTLSPolicyConflict(r, string(svc.fqdn), clientMode.String(), int(port.Port), server.policy, server.mode.String())
+					if line, ok := util.ErrorLine(r, path); ok {
+						message.Line = line
+					}
+					c.Report(gvk.DestinationRule, message)
+				}
+			}
+		}
+	}
+}
+
+// clientTLS returns the TLS settings the policy applies to the port, along with the path of the mode field.
+func (p clientPolicy) clientTLS(port uint32) (*v1alpha3.ClientTLSSettings, string) {
+	if p.subset >= 0 {
+		for i, pls := range p.policy.GetPortLevelSettings() {
+			if pls.GetPort().GetNumber() == port && pls.GetTls() != nil {
+				return pls.GetTls(), fmt.Sprintf(util.DestinationRuleSubsetTLSPortLevelMode, p.subset, i)
+			}
+		}
+		if p.policy.GetTls() != nil {
+			return p.policy.GetTls(), fmt.Sprintf(util.DestinationRuleSubsetTLSMode, p.subset)
+		}
+		// The subset inherits the settings of the top level policy; conflicts are reported for the top level policy.
+		return nil, ""
+	}
+	for i, pls := range p.policy.GetPortLevelSettings() {
+		if pls.GetPort().GetNumber() == port && pls.GetTls() != nil {
+			return pls.GetTls(), fmt.Sprintf(util.DestinationRuleTLSPortLevelMode, i)
+		}
+	}
+	return p.policy.GetTls(), util.DestinationRuleTLSMode
+}
+
+// conflicts returns true if a client using the given TLS mode cannot connect to a server with the given mTLS mode.
+func conflicts(client v1alpha3.ClientTLSSettings_TLSmode, server v1beta1.PeerAuthentication_MutualTLS_Mode) bool {
+	switch server {
+	case v1beta1.PeerAuthentication_MutualTLS_STRICT:
+		// Plaintext, and TLS without an Istio client certificate, are rejected.
+		return client == v1alpha3.ClientTLSSettings_DISABLE || client == v1alpha3.ClientTLSSettings_SIMPLE
+	case v1beta1.PeerAuthentication_MutualTLS_DISABLE:
+		// The server sidecar does not terminate Istio mTLS, and passes the TLS stream to the application.
+		return client == v1alpha3.ClientTLSSettings_ISTIO_MUTUAL
+	}
+	return false
+}
+
+func initPeerAuthentications(c analysis.Context) peerAuthentications {
+	rootNamespace := resource.Namespace("istio-system")
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		if ns := r.Message.(*v1alpha1.MeshConfig).GetRootNamespace(); ns != "" {
+			rootNamespace = resource.Namespace(ns)
+		}
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	var all []*resource.Instance
+	c.ForEach(gvk.PeerAuthentication, func(r *resource.Instance) bool {
+		all = append(all, r)
+		return true
+	})
+	// When several policies apply at the same level, istiod uses the oldest one. Creation time is not available
+	// for files, so fall back to the name for a stable result.
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].Metadata.CreateTime.Equal(all[j].Metadata.CreateTime) {
+			return all[i].Metadata.CreateTime.Before(all[j].Metadata.CreateTime)
+		}
+		return all[i].Metadata.FullName.String() < all[j].Metadata.FullName.String()
+	})
+
+	pas := peerAuthentications{
+		namespace: map[resource.Namespace]*resource.Instance{},
+		workload:  map[resource.Namespace][]*resource.Instance{},
+	}
+	for _, r := range all {
+		pa := r.Message.(*v1beta1.PeerAuthentication)
+		ns := r.Metadata.FullName.Namespace
+		switch {
+		case len(pa.GetSelector().GetMatchLabels()) > 0:
+			pas.workload[ns] = append(pas.workload[ns], r)
+		case ns == rootNamespace:
+			if pas.root == nil {
+				pas.root = r
+			}
+		default:
+			if _, f := pas.namespace[ns]; !f {
+				pas.namespace[ns] = r
+			}
+		}
+	}
+	return pas
+}
+
+// effectiveMode resolves the mTLS mode of a workload port: port level settings of a workload policy take precedence
+// over the workload policy, then the namespace policy, then the mesh policy. UNSET inherits from the parent.
+func (p peerAuthentications) effectiveMode(ns resource.Namespace, labels klabels.Set, port uint32) serverMode {
+	for _, r := range p.workload[ns] {
+		pa := r.Message.(*v1beta1.PeerAuthentication)
+		if !klabels.SelectorFromSet(pa.GetSelector().GetMatchLabels()).Matches(labels) {
+			continue
+		}
+		if pm, f := pa.GetPortLevelMtls()[port]; f && pm.GetMode() != v1beta1.PeerAuthentication_MutualTLS_UNSET {
+			return serverMode{mode: pm.GetMode(), policy: peerAuthenticationName(r)}
+		}
+		if mode := pa.GetMtls().GetMode(); mode != v1beta1.PeerAuthentication_MutualTLS_UNSET {
+			return serverMode{mode: mode, policy: peerAuthenticationName(r)}
+		}
+		// Only the first matching workload policy is used.
+		break
+	}
+	for _, r := range []*resource.Instance{p.namespace[ns], p.root} {
+		if r == nil {
+			continue
+		}
+		if mode := r.Message.(*v1beta1.PeerAuthentication).GetMtls().GetMode(); mode != v1beta1.PeerAuthentication_MutualTLS_UNSET {
+			return serverMode{mode: mode, policy: peerAuthenticationName(r)}
+		}
+	}
+	return serverMode{mode: v1beta1.PeerAuthentication_MutualTLS_PERMISSIVE}
+}
+
+func peerAuthenticationName(r *resource.Instance) string {
+	return r.Metadata.FullName.Namespace.String() + "/" + r.Metadata.FullName.Name.String()
+}
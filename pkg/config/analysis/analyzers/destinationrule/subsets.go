@@ -0,0 +1,193 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+)
+
+// SubsetEndpointsAnalyzer checks that the subsets of DestinationRules match some of the workloads backing their host,
+// and reports VirtualService routes sending traffic to subsets without endpoints.
+type SubsetEndpointsAnalyzer struct{}
+
+var _ analysis.Analyzer = &SubsetEndpointsAnalyzer{}
+
+func (s *SubsetEndpointsAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.SubsetEndpointsAnalyzer",
+		Description: "Checks that DestinationRule subsets match endpoints of their host",
+		Inputs: []config.GroupVersionKind{
+			gvk.DestinationRule,
+			gvk.VirtualService,
+			gvk.Service,
+			gvk.ServiceEntry,
+			gvk.Pod,
+			gvk.Deployment,
+			gvk.WorkloadEntry,
+		},
+	}
+}
+
+type hostSubset struct {
+	host   host.Name
+	subset string
+}
+
+func (s *SubsetEndpointsAnalyzer) Analyze(c analysis.Context) {
+	endpoints := initHostEndpoints(c)
+
+	emptySubsets := map[hostSubset]bool{}
+	c.ForEach(gvk.DestinationRule, func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		drHost := host.Name(util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, dr.GetHost()))
+		eps, f := endpoints[drHost]
+		if !f || len(eps) == 0 {
+			// Without endpoints for the host, there is nothing subsets could match. This is not specific to subsets,
+			// or the endpoints are not known, for example for Services without selectors.
+			return true
+		}
+		for i, ss := range dr.GetSubsets() {
+			if len(ss.GetLabels()) == 0 || matchesAny(ss.GetLabels(), eps) {
+				continue
+			}
+			emptySubsets[hostSubset{host: drHost, subset: ss.GetName()}] = true
+			m := msg.NewDestinationRuleSubsetNoEndpoints(r, ss.GetName(), string(drHost), klabels.Set(ss.GetLabels()).String())
+			if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.DestinationRuleSubset, i)); ok {
+				m.Line = line
+			}
+			c.Report(gvk.DestinationRule, m)
+		}
+		return true
+	})
+
+	c.ForEach(gvk.VirtualService, func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		ns := r.Metadata.FullName.Namespace
+		check := func(rule string, i, j int, d *v1alpha3.Destination, weight int32, destinations int) {
+			if d.GetSubset() == "" {
+				return
+			}
+			// A single destination without weight receives all the traffic.
+			if weight == 0 && destinations == 1 {
+				weight = 100
+			}
+			dHost := host.Name(util.ConvertHostToFQDN(ns, d.GetHost()))
+			if weight == 0 || !emptySubsets[hostSubset{host: dHost, subset: d.GetSubset()}] {
+				return
+			}
+			m := msg.NewVirtualServiceRouteToEmptySubset(r, fmt.Sprintf("%s[%d]", rule, i), int(weight), d.GetSubset(), string(dHost))
+			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.DestinationHost, rule, i, j)); ok {
+				m.Line = line
+			}
+			c.Report(gvk.VirtualService, m)
+		}
+		for i, route := range vs.GetHttp() {
+			for j, rd := range route.GetRoute() {
+				check("http", i, j, rd.GetDestination(), rd.GetWeight(), len(route.GetRoute()))
+			}
+		}
+		for i, route := range vs.GetTls() {
+			for j, rd := range route.GetRoute() {
+				check("tls", i, j, rd.GetDestination(), rd.GetWeight(), len(route.GetRoute()))
+			}
+		}
+		for i, route := range vs.GetTcp() {
+			for j, rd := range route.GetRoute() {
+				check("tcp", i, j, rd.GetDestination(), rd.GetWeight(), len(route.GetRoute()))
+			}
+		}
+		return true
+	})
+}
+
+// initHostEndpoints returns the labels of the endpoints of each host with known endpoints: Services with a selector,
+// and ServiceEntries with a workload selector or inline endpoints.
+func initHostEndpoints(c analysis.Context) map[host.Name][]klabels.Set {
+	workloads := map[resource.Namespace][]klabels.Set{}
+	for _, w := range util.Workloads(c) {
+		ns := w.Resource.Metadata.FullName.Namespace
+		workloads[ns] = append(workloads[ns], w.Labels)
+	}
+	c.ForEach(gvk.WorkloadEntry, func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		workloads[ns] = append(workloads[ns], maps.MergeCopy(r.Message.(*v1alpha3.WorkloadEntry).GetLabels(), r.Metadata.Labels))
+		return true
+	})
+	selected := func(ns resource.Namespace, selector map[string]string) []klabels.Set {
+		var out []klabels.Set
+		for _, w := range workloads[ns] {
+			if klabels.SelectorFromSet(selector).Matches(w) {
+				out = append(out, w)
+			}
+		}
+		return out
+	}
+
+	endpoints := map[host.Name][]klabels.Set{}
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		svc := r.Message.(*corev1.ServiceSpec)
+		if len(svc.Selector) == 0 {
+			return true
+		}
+		ns := r.Metadata.FullName.Namespace
+		fqdn := host.Name(util.ConvertHostToFQDN(ns, r.Metadata.FullName.Name.String()))
+		endpoints[fqdn] = append(endpoints[fqdn], selected(ns, svc.Selector)...)
+		return true
+	})
+	c.ForEach(gvk.ServiceEntry, func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		var eps []klabels.Set
+		if se.GetWorkloadSelector() != nil {
+			eps = selected(r.Metadata.FullName.Namespace, se.GetWorkloadSelector().GetLabels())
+		} else if len(se.GetEndpoints()) > 0 {
+			for _, we := range se.GetEndpoints() {
+				eps = append(eps, we.GetLabels())
+			}
+		} else {
+			return true
+		}
+		for _, h := range se.GetHosts() {
+			if host.Name(h).IsWildCarded() {
+				continue
+			}
+			endpoints[host.Name(h)] = append(endpoints[host.Name(h)], eps...)
+		}
+		return true
+	})
+	return endpoints
+}
+
+func matchesAny(labels map[string]string, workloads []klabels.Set) bool {
+	selector := klabels.SelectorFromSet(labels)
+	for _, w := range workloads {
+		if selector.Matches(w) {
+			return true
+		}
+	}
+	return false
+}
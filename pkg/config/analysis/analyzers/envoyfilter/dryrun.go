@@ -0,0 +1,339 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	network "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/analysis/scope"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/protomarshal"
+)
+
+// DryRunAnalyzer applies the patches of EnvoyFilters to the configuration generated for a representative proxy of each
+// workload they select, and reports patches which change nothing or produce invalid Envoy configuration.
+// Generating the configuration is expensive, so the analyzer is only run when requested.
+type DryRunAnalyzer struct{}
+
+var _ analysis.Analyzer = &DryRunAnalyzer{}
+
+// generationInputs are the resources used to generate the configuration of proxies.
+var generationInputs = []config.GroupVersionKind{
+	gvk.VirtualService,
+	gvk.DestinationRule,
+	gvk.Gateway,
+	gvk.Sidecar,
+	gvk.ServiceEntry,
+	gvk.PeerAuthentication,
+	gvk.RequestAuthentication,
+	gvk.AuthorizationPolicy,
+	gvk.Telemetry,
+	gvk.WasmPlugin,
+}
+
+// Metadata implements analysis.Analyzer
+func (*DryRunAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "envoyfilter.DryRunAnalyzer",
+		Description: "Applies EnvoyFilters to the configuration generated for the workloads they select",
+		Inputs: append([]config.GroupVersionKind{
+			gvk.EnvoyFilter,
+			gvk.Pod,
+			gvk.Deployment,
+			gvk.Namespace,
+			gvk.Service,
+			gvk.MeshConfig,
+		}, generationInputs...),
+	}
+}
+
+// workload is a set of pods with the same labels, represented by a single proxy.
+type workload struct {
+	namespace string
+	name      string
+	kind      string
+	labels    map[string]string
+	router    bool
+}
+
+func (w workload) String() string {
+	return fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name)
+}
+
+// Analyze implements analysis.Analyzer
+func (a *DryRunAnalyzer) Analyze(c analysis.Context) {
+	var filters []*resource.Instance
+	c.ForEach(gvk.EnvoyFilter, func(r *resource.Instance) bool {
+		// EnvoyFilters attached by targetRefs apply to waypoints and Gateway API gateways, which are not simulated.
+		if len(r.Message.(*network.EnvoyFilter).GetTargetRefs()) == 0 {
+			filters = append(filters, r)
+		}
+		return true
+	})
+	if len(filters) == 0 {
+		return
+	}
+
+	meshConfig := mesh.DefaultMeshConfig()
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		meshConfig = r.Message.(*meshconfig.MeshConfig)
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	workloads := initWorkloads(c)
+	selected := map[*resource.Instance][]workload{}
+	for _, r := range filters {
+		for _, w := range workloads {
+			if selects(r, w, meshConfig.GetRootNamespace()) {
+				selected[r] = append(selected[r], w)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	var configs []config.Config
+	for _, g := range append([]config.GroupVersionKind{gvk.EnvoyFilter}, generationInputs...) {
+		c.ForEach(g, func(r *resource.Instance) bool {
+			configs = append(configs, toConfig(g, r))
+			return true
+		})
+	}
+	var services []corev1.Service
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		services = append(services, kubeService(r.Metadata.FullName.Name.String(), r.Metadata.FullName.Namespace.String(),
+			r.Metadata.Labels, r.Metadata.Annotations, r.Message.(*corev1.ServiceSpec)))
+		return true
+	})
+
+	gen, err := newGenerator(configs, services, meshConfig)
+	if err != nil {
+		scope.Analysis.Errorf("failed to initialize the configuration generation: %v", err)
+		return
+	}
+	defer gen.Close()
+	proxies := map[string]*proxyState{}
+	for _, r := range filters {
+		if len(selected[r]) == 0 {
+			continue
+		}
+		var states []*proxyState
+		for _, w := range selected[r] {
+			if proxies[w.String()] == nil {
+				proxies[w.String()] = &proxyState{workload: w, proxy: gen.addProxy(w)}
+			}
+			states = append(states, proxies[w.String()])
+		}
+		if err := a.dryRun(c, gen, r, states); err != nil {
+			scope.Analysis.Errorf("failed to dry-run EnvoyFilter %s: %v", r.Metadata.FullName, err)
+		}
+	}
+}
+
+// proxyState is a proxy, and the configuration generated for it with the patches applied so far.
+type proxyState struct {
+	workload workload
+	proxy    *model.Proxy
+	config   generatedConfig
+	valid    bool
+}
+
+// dryRun applies the patches of the EnvoyFilter one at a time, starting from the configuration generated without it.
+func (a *DryRunAnalyzer) dryRun(c analysis.Context, gen *generator, r *resource.Instance, proxies []*proxyState) error {
+	ef := r.Message.(*network.EnvoyFilter)
+	cfg := toConfig(gvk.EnvoyFilter, r)
+	// Restore the EnvoyFilter for the next ones, whatever the outcome.
+	defer func() {
+		_ = gen.setEnvoyFilter(cfg, ef)
+	}()
+
+	generate := func() error {
+		push, err := gen.Push()
+		if err != nil {
+			return err
+		}
+		for _, p := range proxies {
+			if p.config, err = gen.generate(p.proxy, push); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := gen.setEnvoyFilter(cfg, nil); err != nil {
+		return err
+	}
+	if err := generate(); err != nil {
+		return err
+	}
+	for _, p := range proxies {
+		p.valid = p.config.validate() == nil
+	}
+
+	for i, cp := range ef.GetConfigPatches() {
+		spec := protomarshal.Clone(ef)
+		spec.ConfigPatches = spec.ConfigPatches[:i+1]
+		if err := gen.setEnvoyFilter(cfg, spec); err != nil {
+			return err
+		}
+		previous := make([]generatedConfig, 0, len(proxies))
+		for _, p := range proxies {
+			previous = append(previous, p.config)
+		}
+		if err := generate(); err != nil {
+			return err
+		}
+
+		operation := fmt.Sprintf("%s %s", cp.GetApplyTo(), cp.GetPatch().GetOperation())
+		changed := false
+		invalidReported := false
+		for j, p := range proxies {
+			if p.config.equal(previous[j]) {
+				continue
+			}
+			changed = true
+			if !p.valid || invalidReported {
+				continue
+			}
+			if err := p.config.validate(); err != nil {
+				p.valid = false
+				invalidReported = true
+				m := msg.NewEnvoyFilterPatchInvalidConfig(r, i, operation, p.workload.String(), err.Error())
+				if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.EnvoyFilterConfigPatch, i)); ok {
+					m.Line = line
+				}
+				c.Report(gvk.EnvoyFilter, m)
+			}
+		}
+		if changed || !dryRunApplies(cp) {
+			continue
+		}
+		names := make([]string, 0, len(proxies))
+		for _, p := range proxies {
+			names = append(names, p.workload.String())
+		}
+		m := msg.NewEnvoyFilterPatchNoMatch(r, i, operation, strings.Join(names, ", "))
+		if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.EnvoyFilterConfigPatch, i)); ok {
+			m.Line = line
+		}
+		c.Report(gvk.EnvoyFilter, m)
+	}
+	return nil
+}
+
+// dryRunApplies returns whether the effect of the patch is visible in the generated configuration of the simulated
+// proxies. Extension configurations are served separately, and the version and metadata of the actual proxies are not
+// known.
+func dryRunApplies(cp *network.EnvoyFilter_EnvoyConfigObjectPatch) bool {
+	return cp.GetApplyTo() != network.EnvoyFilter_EXTENSION_CONFIG && cp.GetMatch().GetProxy() == nil
+}
+
+// selects returns whether the EnvoyFilter applies to the workload.
+func selects(r *resource.Instance, w workload, rootNamespace string) bool {
+	ns := r.Metadata.FullName.Namespace.String()
+	if ns != w.namespace && ns != rootNamespace {
+		return false
+	}
+	selector := r.Message.(*network.EnvoyFilter).GetWorkloadSelector().GetLabels()
+	return labels.Instance(selector).SubsetOf(w.labels)
+}
+
+// initWorkloads returns the workloads in the mesh. Pods created by a Deployment are represented by it.
+func initWorkloads(c analysis.Context) []workload {
+	var gatewaySelectors []klabels.Selector
+	c.ForEach(gvk.Gateway, func(r *resource.Instance) bool {
+		if s := r.Message.(*network.Gateway).GetSelector(); len(s) > 0 {
+			gatewaySelectors = append(gatewaySelectors, klabels.SelectorFromSet(s))
+		}
+		return true
+	})
+	isRouter := func(l map[string]string) bool {
+		for _, s := range gatewaySelectors {
+			if s.Matches(klabels.Set(l)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var deployments, pods []workload
+	seen := map[string]bool{}
+	for _, w := range util.Workloads(c) {
+		r := w.Resource
+		wl := workload{
+			namespace: r.Metadata.FullName.Namespace.String(),
+			name:      r.Metadata.FullName.Name.String(),
+			kind:      w.Kind,
+			labels:    maps.Clone(w.Labels),
+			router:    isRouter(w.Labels),
+		}
+		if w.Kind == "Deployment" {
+			if util.DeploymentInMesh(r, c) {
+				deployments = append(deployments, wl)
+			}
+			continue
+		}
+		if !util.PodInMesh(r, c) {
+			continue
+		}
+		// Pods with the same labels are represented by the first of them.
+		key := wl.namespace + "/" + klabels.Set(wl.labels).String()
+		if !seen[key] {
+			seen[key] = true
+			pods = append(pods, wl)
+		}
+	}
+	workloads := deployments
+	for _, p := range pods {
+		if slices.FindFunc(deployments, func(d workload) bool {
+			return d.namespace == p.namespace && labels.Instance(d.labels).SubsetOf(p.labels)
+		}) == nil {
+			workloads = append(workloads, p)
+		}
+	}
+	return workloads
+}
+
+func toConfig(g config.GroupVersionKind, r *resource.Instance) config.Config {
+	return config.Config{
+		Meta: config.Meta{
+			GroupVersionKind:  g,
+			Name:              r.Metadata.FullName.Name.String(),
+			Namespace:         r.Metadata.FullName.Namespace.String(),
+			Labels:            r.Metadata.Labels,
+			Annotations:       r.Metadata.Annotations,
+			CreationTimestamp: r.Metadata.CreateTime,
+			Generation:        r.Metadata.Generation,
+		},
+		Spec: r.Message,
+	}
+}
@@ -16,6 +16,8 @@ package envoyfilter
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	network "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pkg/config"
@@ -161,6 +163,8 @@ func (*EnvoyPatchAnalyzer) analyzeEnvoyFilterPatch(r *resource.Instance, c analy
 			// Also a relative operation (INSERT_BEFORE or INSERT_AFTER) was used so check if priority is set and if not set provide a warning
 			relativeOperationMsg(r, c, index, ef.Priority, patchFilterNames, instanceName)
 		}
+		analyzeInternalNames(r, c, index, patch)
+
 		// append the patchValueStr to the slice for next iteration if the proxyVersion is set
 		if patch.GetMatch() != nil {
 			if patch.Match.GetProxy() != nil {
@@ -173,3 +177,38 @@ func (*EnvoyPatchAnalyzer) analyzeEnvoyFilterPatch(r *resource.Instance, c analy
 	}
 	return patchFilterNames
 }
+
+// generatedListenerName matches the names of the listeners generated for an address and a port.
+var generatedListenerName = regexp.MustCompile(`^[0-9a-fA-F.:\[\]]+_[0-9]+$`)
+
+// analyzeInternalNames reports matches on names generated by Istio, rather than on the Envoy configuration they name.
+func analyzeInternalNames(r *resource.Instance, c analysis.Context, index int, patch *network.EnvoyFilter_EnvoyConfigObjectPatch) {
+	report := func(kind, name, hint string) {
+		message := msg.NewEnvoyFilterMatchesInternalName(r, index, kind, name, hint)
+		if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.EnvoyFilterConfigPatchMatch, index)); ok {
+			message.Line = line
+		}
+		c.Report(gvk.EnvoyFilter, message)
+	}
+
+	lm := patch.GetMatch().GetListener()
+	for _, name := range []string{
+		lm.GetListenerFilter(),
+		lm.GetFilterChain().GetFilter().GetName(),
+		lm.GetFilterChain().GetFilter().GetSubFilter().GetName(),
+	} {
+		// Filters of Istio extensions are added, renamed or removed as the telemetry and security features evolve.
+		if strings.HasPrefix(name, "istio.") || strings.HasPrefix(name, "istio_") {
+			report("filter", name, "Match the Envoy filters the patch depends on instead.")
+		}
+	}
+	if name := lm.GetName(); generatedListenerName.MatchString(name) {
+		report("listener", name, "Match the port number of the listener instead.")
+	}
+	if name := patch.GetMatch().GetRouteConfiguration().GetName(); strings.Contains(name, "|") {
+		report("route configuration", name, "Match the port number of the route configuration instead.")
+	}
+	if name := patch.GetMatch().GetCluster().GetName(); strings.Contains(name, "|") {
+		report("cluster", name, "Match the service, port number and subset of the cluster instead.")
+	}
+}
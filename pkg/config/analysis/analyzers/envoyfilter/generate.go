@@ -0,0 +1,278 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoyfilter
+
+import (
+	"fmt"
+	"strings"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	memregistry "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/version"
+)
+
+const analysisCluster = "analysis"
+
+// generatedConfig is the configuration generated for a proxy.
+type generatedConfig struct {
+	listeners []*listener.Listener
+	clusters  []*cluster.Cluster
+	routes    []*route.RouteConfiguration
+}
+
+func (g generatedConfig) equal(o generatedConfig) bool {
+	return equalProtos(g.listeners, o.listeners) && equalProtos(g.clusters, o.clusters) && equalProtos(g.routes, o.routes)
+}
+
+func equalProtos[T proto.Message](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate returns the first validation error of the generated resources, including the typed configs they embed.
+func (g generatedConfig) validate() error {
+	for _, l := range g.listeners {
+		if err := validateProto(l); err != nil {
+			return fmt.Errorf("listener %s: %v", l.GetName(), err)
+		}
+	}
+	for _, c := range g.clusters {
+		if err := validateProto(c); err != nil {
+			return fmt.Errorf("cluster %s: %v", c.GetName(), err)
+		}
+	}
+	for _, r := range g.routes {
+		if err := validateProto(r); err != nil {
+			return fmt.Errorf("route configuration %s: %v", r.GetName(), err)
+		}
+	}
+	return nil
+}
+
+type validator interface {
+	Validate() error
+}
+
+// validateProto validates m, and the messages embedded in its Any fields, which are not validated by m itself.
+func validateProto(m proto.Message) error {
+	if v, ok := m.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	var err error
+	m.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len() && err == nil; i++ {
+				err = validateEmbedded(v.List().Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				err = validateEmbedded(mv.Message())
+				return err == nil
+			})
+		case fd.Message() != nil:
+			err = validateEmbedded(v.Message())
+		}
+		return err == nil
+	})
+	return err
+}
+
+func validateEmbedded(m protoreflect.Message) error {
+	a, ok := m.Interface().(*anypb.Any)
+	if !ok {
+		return validateProto(m.Interface())
+	}
+	embedded, err := a.UnmarshalNew()
+	if err != nil {
+		// Types unknown to this binary cannot be validated.
+		return nil
+	}
+	return validateProto(embedded)
+}
+
+// generator generates the configuration of proxies from an in-memory copy of the analyzed resources. Services are
+// served by a memory registry, in which the simulated proxies are added as endpoints.
+type generator struct {
+	*simulation.Generator
+	store    model.ConfigStoreController
+	registry *memregistry.ServiceDiscovery
+	services []corev1.Service
+	nextIP   int
+}
+
+func newGenerator(configs []config.Config, services []corev1.Service, meshConfig *meshconfig.MeshConfig) (*generator, error) {
+	store := memory.NewSyncController(memory.MakeSkipValidation(collections.Pilot))
+	g := &generator{
+		Generator: simulation.NewGenerator(simulation.GeneratorOptions{
+			MeshConfig:  meshConfig,
+			ClusterID:   analysisCluster,
+			ConfigStore: store,
+		}),
+		store:    store,
+		registry: memregistry.NewServiceDiscovery(),
+		services: services,
+	}
+	g.registry.XdsUpdater = g.XDSUpdater
+	g.registry.ClusterID = analysisCluster
+	for _, svc := range services {
+		g.registry.AddService(kube.ConvertService(svc, constants.DefaultClusterLocalDomain, analysisCluster, meshConfig))
+	}
+	g.Discovery.AddRegistry(serviceregistry.Simple{
+		ClusterID:           analysisCluster,
+		ProviderID:          provider.Kubernetes,
+		DiscoveryController: g.registry,
+	})
+
+	g.Run()
+	for _, cfg := range configs {
+		if _, err := store.Create(cfg); err != nil {
+			g.Close()
+			return nil, err
+		}
+	}
+	if err := g.Sync(); err != nil {
+		g.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// setEnvoyFilter replaces the EnvoyFilter of the given name, removing it if spec is nil.
+func (g *generator) setEnvoyFilter(cfg config.Config, spec proto.Message) error {
+	if spec == nil {
+		return g.store.Delete(cfg.GroupVersionKind, cfg.Name, cfg.Namespace, nil)
+	}
+	cfg.Spec = spec
+	if g.store.Get(cfg.GroupVersionKind, cfg.Name, cfg.Namespace) == nil {
+		_, err := g.store.Create(cfg)
+		return err
+	}
+	_, err := g.store.Update(cfg)
+	return err
+}
+
+// addProxy returns a proxy for the workload, which is an endpoint of the Services selecting it.
+func (g *generator) addProxy(w workload) *model.Proxy {
+	g.nextIP++
+	ip := fmt.Sprintf("10.%d.%d.%d", g.nextIP>>16&0xff, g.nextIP>>8&0xff, g.nextIP&0xff)
+	for _, svc := range g.services {
+		if svc.Namespace != w.namespace || len(svc.Spec.Selector) == 0 || !labels.Instance(svc.Spec.Selector).SubsetOf(w.labels) {
+			continue
+		}
+		service := g.registry.GetService(kube.ServiceHostname(svc.Name, svc.Namespace, constants.DefaultClusterLocalDomain))
+		if service == nil {
+			continue
+		}
+		for _, sp := range svc.Spec.Ports {
+			port, ok := service.Ports.Get(sp.Name)
+			if !ok {
+				continue
+			}
+			targetPort := sp.Port
+			if sp.TargetPort.Type == intstr.Int && sp.TargetPort.IntVal != 0 {
+				targetPort = sp.TargetPort.IntVal
+			}
+			g.registry.AddInstance(&model.ServiceInstance{
+				Service:     service,
+				ServicePort: port,
+				Endpoint: &model.IstioEndpoint{
+					Addresses:       []string{ip},
+					EndpointPort:    uint32(targetPort),
+					ServicePortName: port.Name,
+					Labels:          w.labels,
+					Namespace:       w.namespace,
+				},
+			})
+		}
+	}
+
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		IPAddresses:     []string{ip},
+		ID:              fmt.Sprintf("%s.%s", w.name, w.namespace),
+		ConfigNamespace: w.namespace,
+		DNSDomain:       w.namespace + ".svc." + constants.DefaultClusterLocalDomain,
+		Labels:          w.labels,
+		Metadata: &model.NodeMetadata{
+			Namespace:    w.namespace,
+			Labels:       w.labels,
+			IstioVersion: version.Info.Version,
+		},
+	}
+	if w.router {
+		proxy.Type = model.Router
+	}
+	proxy.IstioVersion = model.ParseIstioVersion(proxy.Metadata.IstioVersion)
+	return proxy
+}
+
+// generate returns the configuration generated for the proxy with the given push context.
+func (g *generator) generate(proxy *model.Proxy, push *model.PushContext) (generatedConfig, error) {
+	g.InitProxy(proxy, push)
+	generated, err := g.Generate(proxy, push)
+	if err != nil {
+		return generatedConfig{}, err
+	}
+	out := generatedConfig{listeners: generated.Listeners, clusters: generated.Clusters, routes: generated.Routes}
+	// Resources are generated in map iteration order, so they are sorted to compare the outputs of successive runs.
+	slices.SortFunc(out.listeners, func(a, b *listener.Listener) int { return strings.Compare(a.GetName(), b.GetName()) })
+	slices.SortFunc(out.clusters, func(a, b *cluster.Cluster) int { return strings.Compare(a.GetName(), b.GetName()) })
+	slices.SortFunc(out.routes, func(a, b *route.RouteConfiguration) int { return strings.Compare(a.GetName(), b.GetName()) })
+	return out, nil
+}
+
+// kubeService returns the Kubernetes Service with the given metadata and spec.
+func kubeService(name, namespace string, lbls, annotations map[string]string, spec *corev1.ServiceSpec) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      lbls,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}
+}
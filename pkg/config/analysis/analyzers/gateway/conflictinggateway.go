@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 
+	"gomodules.xyz/jsonpatch/v2"
 	klabels "k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/api/networking/v1alpha3"
@@ -27,9 +28,11 @@ import (
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
 )
 
 // ConflictingGatewayAnalyzer checks a gateway's selector, port number and hosts.
@@ -107,16 +110,21 @@ func (*ConflictingGatewayAnalyzer) analyzeGateway(r *resource.Instance, c analys
 		return
 	}
 
-	for _, server := range gw.Servers {
+	for i, server := range gw.Servers {
 		var gateways []string
 		conflictingGWMatch := 0
+		conflictingHosts := sets.New[string]()
 		sPortNumber := strconv.Itoa(int(server.GetPort().GetNumber()))
 		for _, values := range hitSameGateways {
 			for gwNameKey, gwHostsBind := range values {
 				// both selector and port number are the same, then check hosts and bind
-				if gwName != gwNameKey && isGWConflict(server, gwHostsBind) {
+				if gwName == gwNameKey {
+					continue
+				}
+				if duplicates := gwConflictingHosts(server, gwHostsBind); len(duplicates) > 0 {
 					conflictingGWMatch++
 					gateways = append(gateways, gwNameKey)
+					conflictingHosts.InsertAll(duplicates...)
 				}
 			}
 		}
@@ -126,18 +134,39 @@ func (*ConflictingGatewayAnalyzer) analyzeGateway(r *resource.Instance, c analys
 			reportMsg := strings.Join(gateways, ",")
 			hostsMsg := strings.Join(server.GetHosts(), ",")
 			m := msg.NewConflictingGateways(r, reportMsg, sGWSelector, sPortNumber, hostsMsg)
+			// Both gateways are reported, only suggest to fix the one sorted last, so that hosts are not removed from both.
+			if gwName > gateways[len(gateways)-1] {
+				m.Fixes = append(m.Fixes, conflictingHostsFix(server, i, conflictingHosts))
+			}
 			c.Report(gvk.Gateway, m)
 		}
 	}
 }
 
-// isGWConflict implements gateway's hosts match
-func isGWConflict(server *v1alpha3.Server, knowHostsBind gatewayHostsBind) bool {
+// gwConflictingHosts implements gateway's hosts match
+func gwConflictingHosts(server *v1alpha3.Server, knowHostsBind gatewayHostsBind) []string {
 	newHostsBind := knowHostsBind
 	// CheckDuplicates returns all of the hosts provided that are already known
 	// If there were no duplicates, all hosts are added to the known hosts.
-	duplicates := model.CheckDuplicates(server.GetHosts(), server.GetBind(), newHostsBind)
-	return len(duplicates) > 0
+	return model.CheckDuplicates(server.GetHosts(), server.GetBind(), newHostsBind)
+}
+
+// conflictingHostsFix returns a fix removing the conflicting hosts from the server, or the server itself if all of its
+// hosts conflict.
+func conflictingHostsFix(server *v1alpha3.Server, i int, hosts sets.String) diag.Fix {
+	path := fmt.Sprintf("/spec/servers/%d", i)
+	patch := []jsonpatch.Operation{jsonpatch.NewOperation("test", path+"/hosts", server.GetHosts())}
+	if hosts.Len() == len(server.GetHosts()) {
+		patch = append(patch, jsonpatch.NewOperation("remove", path, nil))
+		return diag.NewFix(fmt.Sprintf("remove server %d", i), patch...)
+	}
+	// Remove from the end, so that removals do not change the index of the hosts removed after.
+	for j := len(server.GetHosts()) - 1; j >= 0; j-- {
+		if hosts.Contains(server.GetHosts()[j]) {
+			patch = append(patch, jsonpatch.NewOperation("remove", fmt.Sprintf("%s/hosts/%d", path, j), nil))
+		}
+	}
+	return diag.NewFix(fmt.Sprintf("remove hosts %s from server %d", strings.Join(sets.SortedList(hosts), ","), i), patch...)
 }
 
 // gatewayHostsBind: key host, value bind
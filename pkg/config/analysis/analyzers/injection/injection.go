@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 
+	"gomodules.xyz/jsonpatch/v2"
 	v1 "k8s.io/api/core/v1"
 
 	"istio.io/api/annotation"
@@ -26,6 +27,7 @@ import (
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/resource"
@@ -112,6 +114,7 @@ func (a *Analyzer) Analyze(c analysis.Context) {
 			}
 
 			m := msg.NewNamespaceNotInjected(r, ns, ns)
+			m.Fixes = append(m.Fixes, injectionLabelFix(r))
 
 			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.MetadataName)); ok {
 				m.Line = line
@@ -178,3 +181,14 @@ func GetEnableNamespacesByDefaultFromInjectedConfigMap(cm *v1.ConfigMap) bool {
 	injectionEnable := injectedCMValues[util.InjectorWebhookConfigKey].(map[string]any)[util.InjectorWebhookConfigValue]
 	return injectionEnable.(bool)
 }
+
+// injectionLabelFix returns a fix enabling injection for the namespace.
+func injectionLabelFix(r *resource.Instance) diag.Fix {
+	description := fmt.Sprintf("label the namespace with %s=%s", util.InjectionLabelName, util.InjectionLabelEnableValue)
+	if len(r.Metadata.Labels) == 0 {
+		return diag.NewFix(description, jsonpatch.NewOperation("add", "/metadata/labels",
+			map[string]string{util.InjectionLabelName: util.InjectionLabelEnableValue}))
+	}
+	return diag.NewFix(description, jsonpatch.NewOperation("add",
+		"/metadata/labels/"+diag.JSONPointerEscape(util.InjectionLabelName), util.InjectionLabelEnableValue))
+}
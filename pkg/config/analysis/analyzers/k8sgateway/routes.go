@@ -0,0 +1,520 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sgateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "sigs.k8s.io/gateway-api/apis/v1"
+	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"istio.io/istio/pilot/pkg/config/kube/gateway"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/model/kstatus"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// RouteAnalyzer checks that Gateway API routes attach to their parents, that references across namespaces are
+// permitted by ReferenceGrants, and that routes on the same listener do not conflict. Attachment and references are
+// resolved by the conversion istiod uses, so the results match the statuses istiod would write.
+type RouteAnalyzer struct{}
+
+var _ analysis.Analyzer = &RouteAnalyzer{}
+
+func (a *RouteAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "k8sgateway.RouteAnalyzer",
+		Description: "Checks that Gateway API routes attach to their parents and that their references are permitted",
+		Inputs: []config.GroupVersionKind{
+			gvk.GatewayClass,
+			gvk.KubernetesGateway,
+			gvk.HTTPRoute,
+			gvk.GRPCRoute,
+			gvk.TCPRoute,
+			gvk.TLSRoute,
+			gvk.ReferenceGrant,
+			gvk.ServiceEntry,
+		},
+	}
+}
+
+// Analyze implements analysis.Analyzer
+func (a *RouteAnalyzer) Analyze(c analysis.Context) {
+	instances := map[config.GroupVersionKind]map[resource.FullName]*resource.Instance{}
+	// Namespaces are not available when analyzing files, so the namespaces of the resources are used. This is enough
+	// for listeners selecting namespaces by name.
+	namespaces := map[string]*corev1.Namespace{}
+	configs := func(g config.GroupVersionKind) []config.Config {
+		var out []config.Config
+		instances[g] = map[resource.FullName]*resource.Instance{}
+		c.ForEach(g, func(r *resource.Instance) bool {
+			instances[g][r.Metadata.FullName] = r
+			ns := r.Metadata.FullName.Namespace.String()
+			namespaces[ns] = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+			out = append(out, config.Config{
+				Meta: config.Meta{
+					GroupVersionKind:  g,
+					Name:              r.Metadata.FullName.Name.String(),
+					Namespace:         ns,
+					Labels:            r.Metadata.Labels,
+					Annotations:       r.Metadata.Annotations,
+					CreationTimestamp: r.Metadata.CreateTime,
+					Generation:        r.Metadata.Generation,
+				},
+				Spec:   r.Message,
+				Status: kstatus.Wrap(newStatus(g)),
+			})
+			return true
+		})
+		return out
+	}
+	input := gateway.GatewayResources{
+		GatewayClass:   configs(gvk.GatewayClass),
+		Gateway:        configs(gvk.KubernetesGateway),
+		HTTPRoute:      configs(gvk.HTTPRoute),
+		GRPCRoute:      configs(gvk.GRPCRoute),
+		TCPRoute:       configs(gvk.TCPRoute),
+		TLSRoute:       configs(gvk.TLSRoute),
+		ReferenceGrant: configs(gvk.ReferenceGrant),
+		ServiceEntry:   configs(gvk.ServiceEntry),
+		Namespaces:     namespaces,
+		Domain:         constants.DefaultClusterLocalDomain,
+	}
+	input.Context = gateway.NewGatewayContext(backendServices(input), "Kubernetes")
+	gateway.ConvertResources(input)
+
+	for _, cfg := range input.Gateway {
+		a.analyzeGateway(c, instances[gvk.KubernetesGateway][fullName(cfg)], cfg)
+	}
+	gateways := map[resource.FullName]*k8s.GatewaySpec{}
+	for _, cfg := range input.Gateway {
+		gateways[fullName(cfg)] = cfg.Spec.(*k8s.GatewaySpec)
+	}
+	for g, routes := range map[config.GroupVersionKind][]config.Config{
+		gvk.HTTPRoute: input.HTTPRoute,
+		gvk.GRPCRoute: input.GRPCRoute,
+		gvk.TCPRoute:  input.TCPRoute,
+		gvk.TLSRoute:  input.TLSRoute,
+	} {
+		for _, cfg := range routes {
+			a.analyzeRouteParents(c, g, instances[g][fullName(cfg)], cfg, gateways)
+		}
+	}
+	// Routes are sorted by precedence by the conversion.
+	a.analyzeConflicts(c, gvk.HTTPRoute, instances, input.HTTPRoute)
+	a.analyzeConflicts(c, gvk.GRPCRoute, instances, input.GRPCRoute)
+}
+
+func (a *RouteAnalyzer) analyzeGateway(c analysis.Context, r *resource.Instance, cfg config.Config) {
+	gw := cfg.Spec.(*k8s.GatewaySpec)
+	status := cfg.Status.(*kstatus.WrappedStatus).Unwrap().(*k8s.GatewayStatus)
+	for _, ls := range status.Listeners {
+		cond := kstatus.GetCondition(ls.Conditions, string(k8s.ListenerConditionResolvedRefs))
+		if cond.Status != metav1.ConditionFalse || cond.Reason != string(k8s.ListenerReasonRefNotPermitted) {
+			continue
+		}
+		m := msg.NewGatewayAPIReferenceNotPermitted(r, cond.Message)
+		for i, l := range gw.Listeners {
+			if l.Name != ls.Name {
+				continue
+			}
+			if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.GatewayAPIListener, i)); ok {
+				m.Line = line
+			}
+		}
+		c.Report(gvk.KubernetesGateway, m)
+	}
+}
+
+func (a *RouteAnalyzer) analyzeRouteParents(c analysis.Context, g config.GroupVersionKind, r *resource.Instance, cfg config.Config,
+	gateways map[resource.FullName]*k8s.GatewaySpec,
+) {
+	refs := parentRefs(cfg.Spec)
+	parents := routeParents(cfg.Status.(*kstatus.WrappedStatus).Unwrap())
+	report := func(m diag.Message, ref k8s.ParentReference) {
+		for i, sr := range refs {
+			if sameParent(sr, ref, cfg.Namespace) {
+				if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.GatewayAPIParentRef, i)); ok {
+					m.Line = line
+				}
+			}
+		}
+		c.Report(g, m)
+	}
+
+	reported := sets.New[string]()
+	for _, ps := range parents {
+		accepted := kstatus.GetCondition(ps.Conditions, string(k8s.RouteConditionAccepted))
+		if accepted.Status == metav1.ConditionFalse {
+			gw := gateways[parentName(ps.ParentRef, cfg.Namespace)]
+			if accepted.Reason == string(k8s.RouteReasonNotAllowedByListeners) && gw != nil && selectsNamespacesByLabel(gw) {
+				// Namespace labels are not known, so the namespaces selected by the listeners are not either.
+				continue
+			}
+			report(msg.NewGatewayAPIRouteNotAccepted(r, parentString(ps.ParentRef, cfg.Namespace), conditionMessage(accepted), accepted.Reason),
+				ps.ParentRef)
+		}
+		resolved := kstatus.GetCondition(ps.Conditions, string(k8s.RouteConditionResolvedRefs))
+		if resolved.Status == metav1.ConditionFalse && resolved.Reason == string(k8s.RouteReasonRefNotPermitted) &&
+			!reported.InsertContains(resolved.Message) {
+			c.Report(g, msg.NewGatewayAPIReferenceNotPermitted(r, resolved.Message))
+		}
+	}
+
+	// The conversion ignores references to Gateways it does not know about, as they may belong to another controller.
+	// Only report Gateways which do not exist at all.
+	for _, ref := range refs {
+		if !isGatewayRef(ref) {
+			continue
+		}
+		if _, f := gateways[parentName(ref, cfg.Namespace)]; f {
+			continue
+		}
+		report(msg.NewGatewayAPIRouteNotAccepted(r, parentString(ref, cfg.Namespace), "parent Gateway not found",
+			string(k8s.RouteReasonNoMatchingParent)), ref)
+	}
+}
+
+// routeMatch is a match of a route, on one of the parents the route is attached to.
+type routeMatch struct {
+	route     string
+	parent    k8s.ParentReference
+	hostnames string
+	key       string
+}
+
+// analyzeConflicts reports matches of routes which are ignored, because a route with precedence has the same match
+// for the same hostnames on the same listener.
+func (a *RouteAnalyzer) analyzeConflicts(c analysis.Context, g config.GroupVersionKind,
+	instances map[config.GroupVersionKind]map[resource.FullName]*resource.Instance, routes []config.Config,
+) {
+	var seen []routeMatch
+	for _, cfg := range routes {
+		r := instances[g][fullName(cfg)]
+		name := fmt.Sprintf("%s %s/%s", g.Kind, cfg.Namespace, cfg.Name)
+
+		var hostnames []k8s.Hostname
+		var keys [][]string
+		switch spec := cfg.Spec.(type) {
+		case *k8s.HTTPRouteSpec:
+			hostnames = spec.Hostnames
+			for _, rule := range spec.Rules {
+				matches := rule.Matches
+				if len(matches) == 0 {
+					matches = []k8s.HTTPRouteMatch{{}}
+				}
+				var rk []string
+				for _, m := range matches {
+					rk = append(rk, httpMatchKey(m))
+				}
+				keys = append(keys, rk)
+			}
+		case *k8s.GRPCRouteSpec:
+			hostnames = spec.Hostnames
+			for _, rule := range spec.Rules {
+				matches := rule.Matches
+				if len(matches) == 0 {
+					matches = []k8s.GRPCRouteMatch{{}}
+				}
+				var rk []string
+				for _, m := range matches {
+					rk = append(rk, grpcMatchKey(m))
+				}
+				keys = append(keys, rk)
+			}
+		}
+		hosts := make([]string, 0, len(hostnames))
+		for _, h := range hostnames {
+			hosts = append(hosts, strings.ToLower(string(h)))
+		}
+		sort.Strings(hosts)
+		hostsKey := strings.Join(hosts, ",")
+
+		var current []routeMatch
+		reported := sets.New[string]()
+		for _, ps := range routeParents(cfg.Status.(*kstatus.WrappedStatus).Unwrap()) {
+			if kstatus.GetCondition(ps.Conditions, string(k8s.RouteConditionAccepted)).Status != metav1.ConditionTrue {
+				continue
+			}
+			parent := normalizeParentRef(ps.ParentRef, cfg.Namespace)
+			for i, rk := range keys {
+				for j, key := range rk {
+					current = append(current, routeMatch{route: name, parent: parent, hostnames: hostsKey, key: key})
+					for _, o := range seen {
+						if o.key != key || o.hostnames != hostsKey || !parentsOverlap(o.parent, parent) {
+							continue
+						}
+						if reported.InsertContains(fmt.Sprintf("%d/%d", i, j)) {
+							break
+						}
+						shownHosts := hostsKey
+						if shownHosts == "" {
+							shownHosts = "*"
+						}
+						m := msg.NewGatewayAPIRouteConflict(r, j, i, parentString(ps.ParentRef, cfg.Namespace), shownHosts, o.route)
+						if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.GatewayAPIRouteMatch, i, j)); ok {
+							m.Line = line
+						} else if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.GatewayAPIRouteRule, i)); ok {
+							m.Line = line
+						}
+						c.Report(g, m)
+						break
+					}
+				}
+			}
+		}
+		seen = append(seen, current...)
+	}
+}
+
+// backendServices returns a PushContext with a Service for each backend referenced by the routes. Services are not
+// available when analyzing files, and missing backends are not what this analyzer checks, so all backends are
+// assumed to exist. Otherwise, errors for missing backends would hide errors for references that are not permitted.
+func backendServices(r gateway.GatewayResources) *model.PushContext {
+	ps := model.NewPushContext()
+	add := func(routeNamespace string, ref k8s.BackendObjectReference) {
+		ns := ptr.OrDefault((*string)(ref.Namespace), routeNamespace)
+		hostnames := []string{
+			fmt.Sprintf("%s.%s.svc.%s", ref.Name, ns, r.Domain),
+			fmt.Sprintf("%s.%s.svc.clusterset.local", ref.Name, ns),
+			string(ref.Name),
+		}
+		for _, h := range hostnames {
+			hn := host.Name(h)
+			if ps.ServiceIndex.HostnameAndNamespace[hn] == nil {
+				ps.ServiceIndex.HostnameAndNamespace[hn] = map[string]*model.Service{}
+			}
+			ps.ServiceIndex.HostnameAndNamespace[hn][ns] = &model.Service{
+				Hostname:   hn,
+				Attributes: model.ServiceAttributes{Name: string(ref.Name), Namespace: ns},
+			}
+		}
+	}
+	for _, cfg := range r.HTTPRoute {
+		for _, rule := range cfg.Spec.(*k8s.HTTPRouteSpec).Rules {
+			for _, b := range rule.BackendRefs {
+				add(cfg.Namespace, b.BackendObjectReference)
+			}
+			for _, f := range rule.Filters {
+				if f.RequestMirror != nil {
+					add(cfg.Namespace, f.RequestMirror.BackendRef)
+				}
+			}
+		}
+	}
+	for _, cfg := range r.GRPCRoute {
+		for _, rule := range cfg.Spec.(*k8s.GRPCRouteSpec).Rules {
+			for _, b := range rule.BackendRefs {
+				add(cfg.Namespace, b.BackendObjectReference)
+			}
+		}
+	}
+	for _, cfg := range r.TCPRoute {
+		for _, rule := range cfg.Spec.(*k8salpha.TCPRouteSpec).Rules {
+			for _, b := range rule.BackendRefs {
+				add(cfg.Namespace, b.BackendObjectReference)
+			}
+		}
+	}
+	for _, cfg := range r.TLSRoute {
+		for _, rule := range cfg.Spec.(*k8salpha.TLSRouteSpec).Rules {
+			for _, b := range rule.BackendRefs {
+				add(cfg.Namespace, b.BackendObjectReference)
+			}
+		}
+	}
+	return ps
+}
+
+// conditionMessage returns the message of the condition. The conversion joins the errors of each listener of a parent,
+// which are often the same.
+func conditionMessage(cond metav1.Condition) string {
+	seen := sets.New[string]()
+	return strings.Join(slices.Filter(strings.Split(cond.Message, "; "), func(m string) bool {
+		return !seen.InsertContains(m)
+	}), "; ")
+}
+
+func newStatus(g config.GroupVersionKind) config.Status {
+	switch g {
+	case gvk.GatewayClass:
+		return &k8s.GatewayClassStatus{}
+	case gvk.KubernetesGateway:
+		return &k8s.GatewayStatus{}
+	case gvk.HTTPRoute:
+		return &k8s.HTTPRouteStatus{}
+	case gvk.GRPCRoute:
+		return &k8s.GRPCRouteStatus{}
+	case gvk.TCPRoute:
+		return &k8salpha.TCPRouteStatus{}
+	case gvk.TLSRoute:
+		return &k8salpha.TLSRouteStatus{}
+	}
+	return nil
+}
+
+func parentRefs(spec config.Spec) []k8s.ParentReference {
+	switch s := spec.(type) {
+	case *k8s.HTTPRouteSpec:
+		return s.ParentRefs
+	case *k8s.GRPCRouteSpec:
+		return s.ParentRefs
+	case *k8salpha.TCPRouteSpec:
+		return s.ParentRefs
+	case *k8salpha.TLSRouteSpec:
+		return s.ParentRefs
+	}
+	return nil
+}
+
+func routeParents(status config.Status) []k8s.RouteParentStatus {
+	switch s := status.(type) {
+	case *k8s.HTTPRouteStatus:
+		return s.Parents
+	case *k8s.GRPCRouteStatus:
+		return s.Parents
+	case *k8salpha.TCPRouteStatus:
+		return s.Parents
+	case *k8salpha.TLSRouteStatus:
+		return s.Parents
+	}
+	return nil
+}
+
+func fullName(cfg config.Config) resource.FullName {
+	return resource.NewFullName(resource.Namespace(cfg.Namespace), resource.LocalName(cfg.Name))
+}
+
+func isGatewayRef(ref k8s.ParentReference) bool {
+	return ptr.OrDefault((*string)(ref.Group), gvk.KubernetesGateway.Group) == gvk.KubernetesGateway.Group &&
+		ptr.OrDefault((*string)(ref.Kind), gvk.KubernetesGateway.Kind) == gvk.KubernetesGateway.Kind
+}
+
+func parentName(ref k8s.ParentReference, routeNamespace string) resource.FullName {
+	return resource.NewFullName(resource.Namespace(ptr.OrDefault((*string)(ref.Namespace), routeNamespace)), resource.LocalName(ref.Name))
+}
+
+func normalizeParentRef(ref k8s.ParentReference, routeNamespace string) k8s.ParentReference {
+	out := ref
+	out.Group = ptr.Of(k8s.Group(ptr.OrDefault((*string)(ref.Group), gvk.KubernetesGateway.Group)))
+	out.Kind = ptr.Of(k8s.Kind(ptr.OrDefault((*string)(ref.Kind), gvk.KubernetesGateway.Kind)))
+	out.Namespace = ptr.Of(k8s.Namespace(ptr.OrDefault((*string)(ref.Namespace), routeNamespace)))
+	return out
+}
+
+// parentsOverlap returns true if the parent references may select the same listener.
+func parentsOverlap(a, b k8s.ParentReference) bool {
+	if *a.Group != *b.Group || *a.Kind != *b.Kind || *a.Namespace != *b.Namespace || a.Name != b.Name {
+		return false
+	}
+	if a.SectionName != nil && b.SectionName != nil && *a.SectionName != *b.SectionName {
+		return false
+	}
+	if a.Port != nil && b.Port != nil && *a.Port != *b.Port {
+		return false
+	}
+	return true
+}
+
+func sameParent(a, b k8s.ParentReference, routeNamespace string) bool {
+	a, b = normalizeParentRef(a, routeNamespace), normalizeParentRef(b, routeNamespace)
+	return *a.Group == *b.Group && *a.Kind == *b.Kind && *a.Namespace == *b.Namespace && a.Name == b.Name &&
+		ptr.Equal(a.SectionName, b.SectionName) && ptr.Equal(a.Port, b.Port)
+}
+
+func parentString(ref k8s.ParentReference, routeNamespace string) string {
+	s := fmt.Sprintf("%s %s", ptr.OrDefault((*string)(ref.Kind), gvk.KubernetesGateway.Kind), parentName(ref, routeNamespace))
+	if ref.SectionName != nil {
+		s += "/" + string(*ref.SectionName)
+	}
+	if ref.Port != nil {
+		s += fmt.Sprintf(":%d", *ref.Port)
+	}
+	return s
+}
+
+// selectsNamespacesByLabel returns true if a listener of the Gateway allows routes from namespaces selected by labels
+// other than the namespace name.
+func selectsNamespacesByLabel(gw *k8s.GatewaySpec) bool {
+	for _, l := range gw.Listeners {
+		if l.AllowedRoutes == nil {
+			continue
+		}
+		ns := l.AllowedRoutes.Namespaces
+		if ns == nil || ptr.OrEmpty(ns.From) != k8s.NamespacesFromSelector || ns.Selector == nil {
+			continue
+		}
+		for k := range ns.Selector.MatchLabels {
+			if k != corev1.LabelMetadataName {
+				return true
+			}
+		}
+		for _, e := range ns.Selector.MatchExpressions {
+			if e.Key != corev1.LabelMetadataName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func httpMatchKey(m k8s.HTTPRouteMatch) string {
+	pathType, path := k8s.PathMatchPathPrefix, "/"
+	if m.Path != nil {
+		pathType = ptr.OrDefault(m.Path.Type, pathType)
+		path = ptr.OrDefault(m.Path.Value, path)
+	}
+	var headers, params []string
+	for _, h := range m.Headers {
+		headers = append(headers, fmt.Sprintf("%s:%s=%s",
+			ptr.OrDefault(h.Type, k8s.HeaderMatchExact), strings.ToLower(string(h.Name)), h.Value))
+	}
+	for _, q := range m.QueryParams {
+		params = append(params, fmt.Sprintf("%s:%s=%s", ptr.OrDefault(q.Type, k8s.QueryParamMatchExact), q.Name, q.Value))
+	}
+	sort.Strings(headers)
+	sort.Strings(params)
+	return fmt.Sprintf("%s:%s %s %v %v", pathType, path, ptr.OrEmpty(m.Method), headers, params)
+}
+
+func grpcMatchKey(m k8s.GRPCRouteMatch) string {
+	var method string
+	if m.Method != nil {
+		method = fmt.Sprintf("%s:%s/%s", ptr.OrDefault(m.Method.Type, k8s.GRPCMethodMatchExact),
+			ptr.OrEmpty(m.Method.Service), ptr.OrEmpty(m.Method.Method))
+	}
+	var headers []string
+	for _, h := range m.Headers {
+		headers = append(headers, fmt.Sprintf("%s:%s=%s",
+			ptr.OrDefault(h.Type, k8s.GRPCHeaderMatchExact), strings.ToLower(string(h.Name)), h.Value))
+	}
+	sort.Strings(headers)
+	return fmt.Sprintf("%s %v", method, headers)
+}
@@ -16,16 +16,20 @@ package service
 
 import (
 	"fmt"
+	"strings"
 
+	"gomodules.xyz/jsonpatch/v2"
 	v1 "k8s.io/api/core/v1"
 
 	"istio.io/api/label"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/diag"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/constants"
 	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/gvk"
 )
@@ -76,6 +80,8 @@ func (s *PortNameAnalyzer) analyzeService(r *resource.Instance, c analysis.Conte
 
 			if svc.Type == "ExternalName" {
 				m = msg.NewExternalNameServiceTypeInvalidPortName(r)
+			} else if fix, ok := portNameFix(svc, i); ok {
+				m.Fixes = append(m.Fixes, fix)
 			}
 
 			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.PortInPorts, i)); ok {
@@ -85,3 +91,43 @@ func (s *PortNameAnalyzer) analyzeService(r *resource.Instance, c analysis.Conte
 		}
 	}
 }
+
+// wellKnownPortProtocols are the protocols assumed for the ports of services without a valid protocol in their name,
+// when suggesting a new name.
+var wellKnownPortProtocols = map[int32]protocol.Instance{
+	80:    protocol.HTTP,
+	443:   protocol.HTTPS,
+	3306:  protocol.MySQL,
+	6379:  protocol.Redis,
+	8080:  protocol.HTTP,
+	8443:  protocol.HTTPS,
+	9080:  protocol.HTTP,
+	27017: protocol.Mongo,
+	50051: protocol.GRPC,
+}
+
+// portNameFix returns a fix prefixing the name of the port with its protocol, if the protocol can be assumed from the
+// port number.
+func portNameFix(svc *v1.ServiceSpec, i int) (diag.Fix, bool) {
+	port := svc.Ports[i]
+	p, ok := wellKnownPortProtocols[port.Port]
+	if !ok {
+		return diag.Fix{}, false
+	}
+	name := strings.ToLower(string(p))
+	if port.Name != "" {
+		name += "-" + port.Name
+	}
+	for _, other := range svc.Ports {
+		if other.Name == name {
+			return diag.Fix{}, false
+		}
+	}
+	path := fmt.Sprintf("/spec/ports/%d", i)
+	patch := []jsonpatch.Operation{jsonpatch.NewOperation("test", path+"/port", port.Port)}
+	if port.Name != "" {
+		patch = append(patch, jsonpatch.NewOperation("test", path+"/name", port.Name))
+	}
+	patch = append(patch, jsonpatch.NewOperation("add", path+"/name", name))
+	return diag.NewFix(fmt.Sprintf("rename port %d to %q", port.Port, name), patch...), true
+}
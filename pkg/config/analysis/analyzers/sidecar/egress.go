@@ -0,0 +1,309 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/annotation"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// EgressAnalyzer validates, per namespace, that:
+// * the hosts used by the VirtualServices, DestinationRules and ServiceEntries of the namespace are imported by the
+// egress listeners of the sidecar resources in the namespace
+// * the hosts imported by egress listeners refer to existing namespaces and services. Namespaces are not checked when
+// no Namespace resources are known, as when analyzing files without them.
+type EgressAnalyzer struct{}
+
+var _ analysis.Analyzer = &EgressAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *EgressAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "sidecar.EgressAnalyzer",
+		Description: "Validates that sidecars import the hosts used by the configuration of their namespace, " +
+			"and that their egress hosts refer to existing namespaces and services",
+		Inputs: []config.GroupVersionKind{
+			gvk.Sidecar,
+			gvk.VirtualService,
+			gvk.DestinationRule,
+			gvk.ServiceEntry,
+			gvk.Service,
+			gvk.Namespace,
+		},
+	}
+}
+
+// hostProvider is a Service or ServiceEntry providing a host.
+type hostProvider struct {
+	host      host.Name
+	namespace string
+	exportTo  []string
+}
+
+func (p hostProvider) visibleTo(ns string) bool {
+	if len(p.exportTo) == 0 {
+		return true
+	}
+	for _, e := range p.exportTo {
+		switch e {
+		case util.ExportToAllNamespaces:
+			return true
+		case util.ExportToNamespaceLocal:
+			if p.namespace == ns {
+				return true
+			}
+		default:
+			if e == ns {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// egressHost is a host imported by an egress listener, of the form namespace/dnsName.
+type egressHost struct {
+	namespace string
+	host      host.Name
+}
+
+func (e egressHost) imports(p hostProvider) bool {
+	return (e.namespace == util.Wildcard || e.namespace == p.namespace) && p.host.SubsetOf(e.host)
+}
+
+// hostUse records the resources of a namespace using a host.
+type hostUse struct {
+	host   host.Name
+	usedBy []string
+}
+
+// Analyze implements Analyzer
+func (a *EgressAnalyzer) Analyze(c analysis.Context) {
+	namespaces := sets.New[string]()
+	var providers []hostProvider
+	c.ForEach(gvk.Namespace, func(r *resource.Instance) bool {
+		namespaces.Insert(r.Metadata.FullName.Name.String())
+		return true
+	})
+	checkNamespaces := namespaces.Len() > 0
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		namespaces.Insert(ns.String())
+		var exportTo []string
+		if anno := r.Metadata.Annotations[annotation.NetworkingExportTo.Name]; anno != "" {
+			for _, e := range strings.Split(anno, ",") {
+				exportTo = append(exportTo, strings.TrimSpace(e))
+			}
+		}
+		providers = append(providers, hostProvider{
+			host:      host.Name(util.ConvertHostToFQDN(ns, r.Metadata.FullName.Name.String())),
+			namespace: ns.String(),
+			exportTo:  exportTo,
+		})
+		return true
+	})
+	c.ForEach(gvk.ServiceEntry, func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		ns := r.Metadata.FullName.Namespace.String()
+		namespaces.Insert(ns)
+		for _, h := range se.GetHosts() {
+			providers = append(providers, hostProvider{host: host.Name(h), namespace: ns, exportTo: se.GetExportTo()})
+		}
+		return true
+	})
+
+	uses := map[resource.Namespace][]*hostUse{}
+	addUse := func(ns resource.Namespace, h string, usedBy string) {
+		fqdn := host.Name(util.ConvertHostToFQDN(ns, h))
+		for _, u := range uses[ns] {
+			if u.host == fqdn {
+				if u.usedBy[len(u.usedBy)-1] != usedBy {
+					u.usedBy = append(u.usedBy, usedBy)
+				}
+				return
+			}
+		}
+		uses[ns] = append(uses[ns], &hostUse{host: fqdn, usedBy: []string{usedBy}})
+	}
+	c.ForEach(gvk.VirtualService, func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		ns := r.Metadata.FullName.Namespace
+		namespaces.Insert(ns.String())
+		if !appliesToMesh(vs.GetGateways()) {
+			return true
+		}
+		name := "VirtualService " + r.Metadata.FullName.Name.String()
+		for _, h := range vs.GetHosts() {
+			addUse(ns, h, name)
+		}
+		for _, d := range destinations(vs) {
+			addUse(ns, d.GetHost(), name)
+		}
+		return true
+	})
+	c.ForEach(gvk.DestinationRule, func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		ns := r.Metadata.FullName.Namespace
+		namespaces.Insert(ns.String())
+		addUse(ns, dr.GetHost(), "DestinationRule "+r.Metadata.FullName.Name.String())
+		return true
+	})
+	c.ForEach(gvk.ServiceEntry, func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		ns := r.Metadata.FullName.Namespace
+		for _, h := range se.GetHosts() {
+			addUse(ns, h, "ServiceEntry "+r.Metadata.FullName.Name.String())
+		}
+		return true
+	})
+
+	// Resources may only be created in existing namespaces.
+	c.ForEach(gvk.Sidecar, func(r *resource.Instance) bool {
+		namespaces.Insert(r.Metadata.FullName.Namespace.String())
+		return true
+	})
+
+	c.ForEach(gvk.Sidecar, func(r *resource.Instance) bool {
+		s := r.Message.(*v1alpha3.Sidecar)
+		ns := r.Metadata.FullName.Namespace.String()
+		// Without egress listeners, all the hosts are imported.
+		if len(s.GetEgress()) == 0 {
+			return true
+		}
+
+		var imported []egressHost
+		for i, e := range s.GetEgress() {
+			for j, h := range e.GetHosts() {
+				parts := strings.SplitN(h, "/", 2)
+				if len(parts) != 2 || parts[0] == "~" {
+					continue
+				}
+				eh := egressHost{namespace: parts[0], host: host.Name(parts[1])}
+				if eh.namespace == util.ExportToNamespaceLocal {
+					eh.namespace = ns
+				}
+				imported = append(imported, eh)
+
+				var m *reference
+				if checkNamespaces && eh.namespace != util.Wildcard && !namespaces.Contains(eh.namespace) {
+					m = &reference{"egress host namespace", eh.namespace}
+				} else if !eh.host.IsWildCarded() && !providesHost(providers, eh, ns) {
+					m = &reference{"egress host", h}
+				}
+				if m == nil {
+					continue
+				}
+				message := msg.NewReferencedResourceNotFound(r, m.kind, m.value)
+				if line, ok := util.ErrorLine(r, fmt.Sprintf(util.SidecarEgressHost, i, j)); ok {
+					message.Line = line
+				}
+				c.Report(gvk.Sidecar, message)
+			}
+		}
+
+		for _, u := range uses[r.Metadata.FullName.Namespace] {
+			var candidates []hostProvider
+			for _, p := range providers {
+				if p.visibleTo(ns) && u.host.SubsetOf(p.host) {
+					candidates = append(candidates, p)
+				}
+			}
+			// Hosts which are not provided by any service are reported by other analyzers.
+			if len(candidates) == 0 || importsAny(imported, candidates) {
+				continue
+			}
+			m := msg.NewSidecarEgressHostNotImported(r, string(u.host), strings.Join(u.usedBy, ", "))
+			if line, ok := util.FirstErrorLine(r, util.SidecarEgress); ok {
+				m.Line = line
+			}
+			c.Report(gvk.Sidecar, m)
+		}
+		return true
+	})
+}
+
+type reference struct {
+	kind  string
+	value string
+}
+
+func providesHost(providers []hostProvider, eh egressHost, sidecarNamespace string) bool {
+	for _, p := range providers {
+		if (eh.namespace == util.Wildcard || eh.namespace == p.namespace) && p.visibleTo(sidecarNamespace) && eh.host.SubsetOf(p.host) {
+			return true
+		}
+	}
+	return false
+}
+
+func importsAny(imported []egressHost, providers []hostProvider) bool {
+	for _, e := range imported {
+		for _, p := range providers {
+			if e.imports(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func appliesToMesh(gateways []string) bool {
+	if len(gateways) == 0 {
+		return true
+	}
+	for _, g := range gateways {
+		if g == util.MeshGateway {
+			return true
+		}
+	}
+	return false
+}
+
+func destinations(vs *v1alpha3.VirtualService) []*v1alpha3.Destination {
+	var out []*v1alpha3.Destination
+	for _, r := range vs.GetHttp() {
+		for _, rd := range r.GetRoute() {
+			out = append(out, rd.GetDestination())
+		}
+		if r.GetMirror() != nil {
+			out = append(out, r.GetMirror())
+		}
+		for _, m := range r.GetMirrors() {
+			out = append(out, m.GetDestination())
+		}
+	}
+	for _, r := range vs.GetTls() {
+		for _, rd := range r.GetRoute() {
+			out = append(out, rd.GetDestination())
+		}
+	}
+	for _, r := range vs.GetTcp() {
+		for _, rd := range r.GetRoute() {
+			out = append(out, rd.GetDestination())
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+
+	telemetryapi "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+var (
+	celEnv, _ = cel.NewEnv()
+
+	// knownAttributes are the roots of the attributes available to the expressions evaluated by the proxies.
+	knownAttributes = sets.New(
+		"request", "response", "connection", "upstream", "source", "destination",
+		"metadata", "filter_state", "upstream_filter_state", "xds", "node",
+		"upstream_peer", "downstream_peer",
+		"cluster_name", "cluster_metadata", "listener_direction", "listener_metadata",
+		"route_name", "route_metadata", "upstream_host_metadata",
+	)
+
+	// knownMetrics are the names of the standard metrics, as Istio metrics or as reported to Prometheus.
+	knownMetrics = func() sets.String {
+		s := sets.New(
+			"requests_total", "request_duration_milliseconds", "request_bytes", "response_bytes",
+			"tcp_connections_opened_total", "tcp_connections_closed_total", "tcp_sent_bytes_total", "tcp_received_bytes_total",
+			"request_messages_total", "response_messages_total",
+		)
+		for name := range telemetryapi.MetricSelector_IstioMetric_value {
+			s.Insert(name)
+		}
+		return s
+	}()
+
+	// knownTags are the tags of the standard metrics.
+	knownTags = sets.New(
+		"reporter",
+		"source_workload", "source_workload_namespace", "source_principal", "source_app", "source_version",
+		"source_cluster", "source_canonical_service", "source_canonical_revision",
+		"destination_workload", "destination_workload_namespace", "destination_principal", "destination_app",
+		"destination_version", "destination_service", "destination_service_name", "destination_service_namespace",
+		"destination_cluster", "destination_canonical_service", "destination_canonical_revision",
+		"request_protocol", "response_code", "grpc_response_status", "response_flags", "connection_security_policy",
+	)
+)
+
+// ExpressionAnalyzer validates that the access log filters and metric overrides of telemetry resources can be parsed,
+// and reference metrics, tags and attributes known to the proxies. The proxies drop the logs and metrics whose
+// expressions fail to evaluate.
+type ExpressionAnalyzer struct{}
+
+var _ analysis.Analyzer = &ExpressionAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *ExpressionAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "telemetry.ExpressionAnalyzer",
+		Description: "Validates that the access log filters and metric overrides of telemetry resources can be parsed, " +
+			"and reference known metrics, tags and attributes",
+		Inputs: []config.GroupVersionKind{
+			gvk.Telemetry,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ExpressionAnalyzer) Analyze(c analysis.Context) {
+	c.ForEach(gvk.Telemetry, func(r *resource.Instance) bool {
+		telemetry := r.Message.(*telemetryapi.Telemetry)
+
+		for i, logging := range telemetry.GetAccessLogging() {
+			if expr := logging.GetFilter().GetExpression(); expr != "" {
+				analyzeExpression(c, r, "access log filter", expr, fmt.Sprintf(util.TelemetryAccessLogFilter, i))
+			}
+		}
+
+		for i, metrics := range telemetry.GetMetrics() {
+			for j, override := range metrics.GetOverrides() {
+				if custom := override.GetMatch().GetCustomMetric(); custom != "" && !knownMetrics.Contains(custom) {
+					m := msg.NewTelemetryUnknownReference(r, "metrics override", "metric", custom)
+					if line, ok := util.FirstErrorLine(r, fmt.Sprintf(util.TelemetryMetricsOverrideMatch, i, j)); ok {
+						m.Line = line
+					}
+					c.Report(gvk.Telemetry, m)
+				}
+
+				for _, tag := range slices.Sort(maps.Keys(override.GetTagOverrides())) {
+					path := fmt.Sprintf(util.TelemetryTagOverride, i, j, tag)
+					to := override.GetTagOverrides()[tag]
+					switch to.GetOperation() {
+					case telemetryapi.MetricsOverrides_TagOverride_UPSERT:
+						if to.GetValue() != "" {
+							analyzeExpression(c, r, fmt.Sprintf("tag override %s", tag), to.GetValue(), path)
+						}
+					case telemetryapi.MetricsOverrides_TagOverride_REMOVE:
+						// Tags which are not reported cannot be removed.
+						if !knownTags.Contains(tag) {
+							m := msg.NewTelemetryUnknownReference(r, "metrics override", "tag", tag)
+							if line, ok := util.FirstErrorLine(r, path); ok {
+								m.Line = line
+							}
+							c.Report(gvk.Telemetry, m)
+						}
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+// analyzeExpression reports the expression if it cannot be parsed, or for each unknown attribute it references.
+func analyzeExpression(c analysis.Context, r *resource.Instance, field, expr, path string) {
+	line, hasLine := util.FirstErrorLine(r, path)
+	parsed, issues := celEnv.Parse(expr)
+	if issues.Err() != nil {
+		// The first line describes the error, the others point at it in the expression.
+		reason, _, _ := strings.Cut(issues.Err().Error(), "\n")
+		m := msg.NewInvalidTelemetryExpression(r, field, expr, reason)
+		if hasLine {
+			m.Line = line
+		}
+		c.Report(gvk.Telemetry, m)
+		return
+	}
+
+	for _, attribute := range unknownAttributes(parsed.NativeRep()) {
+		m := msg.NewTelemetryUnknownReference(r, field, "attribute", attribute)
+		if hasLine {
+			m.Line = line
+		}
+		c.Report(gvk.Telemetry, m)
+	}
+}
+
+// unknownAttributes returns the identifiers of the expression which are neither known attributes, nor variables of
+// its comprehensions.
+func unknownAttributes(ast *celast.AST) []string {
+	root := celast.NavigateAST(ast)
+	variables := sets.New[string]()
+	for _, e := range celast.MatchDescendants(root, celast.KindMatcher(celast.ComprehensionKind)) {
+		variables.InsertAll(e.AsComprehension().IterVar(), e.AsComprehension().AccuVar())
+	}
+	unknown := sets.New[string]()
+	for _, e := range celast.MatchDescendants(root, celast.KindMatcher(celast.IdentKind)) {
+		if name := e.AsIdent(); !knownAttributes.Contains(name) && !variables.Contains(name) {
+			unknown.Insert(name)
+		}
+	}
+	return sets.SortedList(unknown)
+}
@@ -0,0 +1,161 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	telemetryapi "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// ProviderServiceAnalyzer validates that the providers used by telemetry resources send data to services which exist
+// in the service registry.
+type ProviderServiceAnalyzer struct{}
+
+var _ analysis.Analyzer = &ProviderServiceAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *ProviderServiceAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "telemetry.ProviderServiceAnalyzer",
+		Description: "Validates that the providers used by telemetry resources send data to existing services",
+		Inputs: []config.GroupVersionKind{
+			gvk.Telemetry,
+			gvk.MeshConfig,
+			gvk.Service,
+			gvk.ServiceEntry,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ProviderServiceAnalyzer) Analyze(c analysis.Context) {
+	meshConfig := fetchMeshConfig(c)
+	services := map[string]string{}
+	for _, p := range meshConfig.GetExtensionProviders() {
+		if svc := providerService(p); svc != "" {
+			services[p.GetName()] = svc
+		}
+	}
+	if len(services) == 0 {
+		return
+	}
+
+	// Hostnames and the namespaces they are defined in, as indexed by istiod.
+	hosts := map[string]sets.String{}
+	addHost := func(host, namespace string) {
+		if hosts[host] == nil {
+			hosts[host] = sets.New[string]()
+		}
+		hosts[host].Insert(namespace)
+	}
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		addHost(util.ConvertHostToFQDN(r.Metadata.FullName.Namespace, r.Metadata.FullName.Name.String()),
+			r.Metadata.FullName.Namespace.String())
+		return true
+	})
+	c.ForEach(gvk.ServiceEntry, func(r *resource.Instance) bool {
+		for _, h := range r.Message.(*v1alpha3.ServiceEntry).GetHosts() {
+			addHost(h, r.Metadata.FullName.Namespace.String())
+		}
+		return true
+	})
+
+	c.ForEach(gvk.Telemetry, func(r *resource.Instance) bool {
+		telemetry := r.Message.(*telemetryapi.Telemetry)
+		analyze := func(field string, index int, providers []*telemetryapi.ProviderRef) {
+			for i, p := range providers {
+				svc, ok := services[p.GetName()]
+				if !ok {
+					continue
+				}
+				reason := lookupService(hosts, svc)
+				if reason == "" {
+					continue
+				}
+				m := msg.NewTelemetryProviderServiceNotFound(r, p.GetName(), svc, reason)
+				if line, ok := util.ErrorLine(r, fmt.Sprintf(util.TelemetryProvider, field, index, i)); ok {
+					m.Line = line
+				}
+				c.Report(gvk.Telemetry, m)
+			}
+		}
+		for i, tracing := range telemetry.GetTracing() {
+			analyze("tracing", i, tracing.GetProviders())
+		}
+		for i, logging := range telemetry.GetAccessLogging() {
+			analyze("accessLogging", i, logging.GetProviders())
+		}
+		for i, metrics := range telemetry.GetMetrics() {
+			analyze("metrics", i, metrics.GetProviders())
+		}
+		return true
+	})
+}
+
+// lookupService resolves the service of a provider as istiod does, and returns why it cannot be resolved, if it cannot.
+// The service is either <namespace>/<hostname>, or a hostname defined in a single namespace.
+func lookupService(hosts map[string]sets.String, svc string) string {
+	if namespace, hostname, ok := strings.Cut(svc, "/"); ok {
+		if hosts[hostname].Contains(namespace) {
+			return ""
+		}
+		return "is not found in the service registry"
+	}
+	switch namespaces := hosts[svc]; namespaces.Len() {
+	case 0:
+		return "is not found in the service registry"
+	case 1:
+		return ""
+	default:
+		return fmt.Sprintf("is found in multiple namespaces (%s), so it must be set as <namespace>/<hostname>",
+			strings.Join(sets.SortedList(namespaces), ", "))
+	}
+}
+
+// providerService returns the service the provider sends data to, if any.
+func providerService(p *v1alpha1.MeshConfig_ExtensionProvider) string {
+	switch provider := p.GetProvider().(type) {
+	case *v1alpha1.MeshConfig_ExtensionProvider_Zipkin:
+		return provider.Zipkin.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_Lightstep:
+		return provider.Lightstep.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_Datadog:
+		return provider.Datadog.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_Skywalking:
+		return provider.Skywalking.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_Opencensus:
+		return provider.Opencensus.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_Opentelemetry:
+		return provider.Opentelemetry.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_EnvoyHttpAls:
+		return provider.EnvoyHttpAls.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_EnvoyTcpAls:
+		return provider.EnvoyTcpAls.GetService()
+	case *v1alpha1.MeshConfig_ExtensionProvider_EnvoyOtelAls:
+		return provider.EnvoyOtelAls.GetService()
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"fmt"
+	"strconv"
+
+	"istio.io/api/mesh/v1alpha1"
+	telemetryapi "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// TracingSamplingAnalyzer validates that the random sampling percentages of telemetry resources are applied, and that
+// mesh-wide telemetry resources do not silently override the sampling percentage of the mesh config.
+type TracingSamplingAnalyzer struct{}
+
+var _ analysis.Analyzer = &TracingSamplingAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *TracingSamplingAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "telemetry.TracingSamplingAnalyzer",
+		Description: "Validates that the tracing sampling percentages of telemetry resources are consistent with the mesh config",
+		Inputs: []config.GroupVersionKind{
+			gvk.Telemetry,
+			gvk.MeshConfig,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *TracingSamplingAnalyzer) Analyze(c analysis.Context) {
+	meshConfig := fetchMeshConfig(c)
+	providers := map[string]*v1alpha1.MeshConfig_ExtensionProvider{}
+	for _, p := range meshConfig.GetExtensionProviders() {
+		providers[p.GetName()] = p
+	}
+	meshSampling := meshConfig.GetDefaultConfig().GetTracing().GetSampling()
+
+	c.ForEach(gvk.Telemetry, func(r *resource.Instance) bool {
+		telemetry := r.Message.(*telemetryapi.Telemetry)
+		meshWide := r.Metadata.FullName.Namespace.String() == meshConfig.GetRootNamespace() &&
+			telemetry.GetSelector() == nil && telemetry.GetTargetRef() == nil && len(telemetry.GetTargetRefs()) == 0
+
+		for i, tracing := range telemetry.GetTracing() {
+			if tracing.GetRandomSamplingPercentage() == nil || tracing.GetDisableSpanReporting().GetValue() {
+				continue
+			}
+			percentage := tracing.GetRandomSamplingPercentage().GetValue()
+
+			// Only the first provider is used for tracing.
+			provider := ""
+			if len(tracing.GetProviders()) > 0 {
+				provider = tracing.GetProviders()[0].GetName()
+			} else if len(meshConfig.GetDefaultProviders().GetTracing()) > 0 {
+				provider = meshConfig.GetDefaultProviders().GetTracing()[0]
+			}
+
+			var reason string
+			switch {
+			case provider == "":
+				reason = "has no effect, as no tracing provider is set by the Telemetry or in meshConfig.defaultProviders.tracing"
+			case providers[provider].GetOpentelemetry().GetSampling() != nil:
+				reason = fmt.Sprintf("is ignored, as provider %q samples all spans with its own sampler", provider)
+			case meshWide && meshSampling != 0 && meshSampling != percentage:
+				reason = fmt.Sprintf("overrides the sampling percentage %s%% set for the whole mesh in "+
+					"meshConfig.defaultConfig.tracing.sampling", formatPercentage(meshSampling))
+			default:
+				continue
+			}
+
+			m := msg.NewTelemetryTracingSamplingConflict(r, formatPercentage(percentage), reason)
+			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.TelemetryTracingSampling, i)); ok {
+				m.Line = line
+			}
+			c.Report(gvk.Telemetry, m)
+		}
+		return true
+	})
+}
+
+func formatPercentage(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
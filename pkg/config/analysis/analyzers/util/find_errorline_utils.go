@@ -38,6 +38,10 @@ const (
 	// Required parameters: http index, mirror index.
 	MirrorsHost = "{.spec.http[%d].mirrors[%d].host}"
 
+	// Path for a http route in VirtualService.
+	// Required parameters: http index.
+	VirtualServiceHTTPRoute = "{.spec.http[%d]}"
+
 	// Path for VirtualService gateway.
 	// Required parameters: gateway index.
 	VSGateway = "{.spec.gateways[%d]}"
@@ -106,13 +110,104 @@ const (
 	// Required parameters: portLevelSettings index.
 	DestinationRuleTLSPortLevelCert = "{.spec.trafficPolicy.portLevelSettings[%d].tls.caCertificates}"
 
+	// Path for DestinationRule tls mode.
+	// Required parameters: none.
+	DestinationRuleTLSMode = "{.spec.trafficPolicy.tls.mode}"
+
+	// Path for DestinationRule port-level tls mode.
+	// Required parameters: portLevelSettings index.
+	DestinationRuleTLSPortLevelMode = "{.spec.trafficPolicy.portLevelSettings[%d].tls.mode}"
+
+	// Path for DestinationRule subset tls mode.
+	// Required parameters: subset index.
+	DestinationRuleSubsetTLSMode = "{.spec.subsets[%d].trafficPolicy.tls.mode}"
+
+	// Path for DestinationRule subset port-level tls mode.
+	// Required parameters: subset index, portLevelSettings index.
+	DestinationRuleSubsetTLSPortLevelMode = "{.spec.subsets[%d].trafficPolicy.portLevelSettings[%d].tls.mode}"
+
+	// Path for DestinationRule subset.
+	// Required parameters: subset index.
+	DestinationRuleSubset = "{.spec.subsets[%d]}"
+
 	// Path for ConfigPatch in envoyFilter
 	// Required parameters: envoyFilter config patch index
 	EnvoyFilterConfigPath = "{.spec.configPatches[%d].patch.value}"
 
+	// Path for a ConfigPatch in envoyFilter
+	// Required parameters: envoyFilter config patch index
+	EnvoyFilterConfigPatch = "{.spec.configPatches[%d]}"
+
+	// Path for the match of a ConfigPatch in envoyFilter
+	// Required parameters: envoyFilter config patch index
+	EnvoyFilterConfigPatchMatch = "{.spec.configPatches[%d].match}"
+
 	// Path for selector in telemetry.
 	// Required parameters: selector label.
 	TelemetrySelector = "{.spec.selector.matchLabels.%s}"
+
+	// Path for the random sampling percentage in telemetry.
+	// Required parameters: tracing index.
+	TelemetryTracingSampling = "{.spec.tracing[%d].randomSamplingPercentage}"
+
+	// Path for an access logging filter expression in telemetry.
+	// Required parameters: access logging index.
+	TelemetryAccessLogFilter = "{.spec.accessLogging[%d].filter.expression}"
+
+	// Path for the match of a metrics override in telemetry.
+	// Required parameters: metrics index, override index.
+	TelemetryMetricsOverrideMatch = "{.spec.metrics[%d].overrides[%d].match}"
+
+	// Path for a tag override in telemetry.
+	// Required parameters: metrics index, override index, tag name.
+	TelemetryTagOverride = "{.spec.metrics[%d].overrides[%d].tagOverrides.%s}"
+
+	// Path for a provider in telemetry.
+	// Required parameters: telemetry field (tracing, accessLogging or metrics), field index, provider index.
+	TelemetryProvider = "{.spec.%s[%d].providers[%d].name}"
+
+	// Path for a rule in authorizationPolicy.
+	// Required parameters: rule index.
+	AuthorizationPolicyRule = "{.spec.rules[%d]}"
+
+	// Path for a principal in authorizationPolicy.
+	// Required parameters: rule index, from index, principal index.
+	AuthorizationPolicyPrincipal = "{.spec.rules[%d].from[%d].source.principals[%d]}"
+
+	// Path for a negated principal in authorizationPolicy.
+	// Required parameters: rule index, from index, principal index.
+	AuthorizationPolicyNotPrincipal = "{.spec.rules[%d].from[%d].source.notPrincipals[%d]}"
+
+	// Path for a condition value in authorizationPolicy.
+	// Required parameters: rule index, when index, value index.
+	AuthorizationPolicyWhenValue = "{.spec.rules[%d].when[%d].values[%d]}"
+
+	// Path for a negated condition value in authorizationPolicy.
+	// Required parameters: rule index, when index, value index.
+	AuthorizationPolicyWhenNotValue = "{.spec.rules[%d].when[%d].notValues[%d]}"
+
+	// Path for a listener in a Gateway API Gateway.
+	// Required parameters: listener index.
+	GatewayAPIListener = "{.spec.listeners[%d]}"
+
+	// Path for a parent reference in a Gateway API route.
+	// Required parameters: parentRef index.
+	GatewayAPIParentRef = "{.spec.parentRefs[%d]}"
+
+	// Path for a match in a Gateway API route.
+	// Required parameters: rule index, match index.
+	GatewayAPIRouteMatch = "{.spec.rules[%d].matches[%d]}"
+
+	// Path for a rule in a Gateway API route.
+	// Required parameters: rule index.
+	GatewayAPIRouteRule = "{.spec.rules[%d]}"
+
+	// Path for the egress listeners in sidecar.
+	SidecarEgress = "{.spec.egress}"
+
+	// Path for an egress host in sidecar.
+	// Required parameters: egress index, host index.
+	SidecarEgressHost = "{.spec.egress[%d].hosts[%d]}"
 )
 
 // ErrorLine returns the line number of the input path key in the resource
@@ -125,6 +220,24 @@ func ErrorLine(r *resource.Instance, path string) (line int, found bool) {
 	return line, true
 }
 
+// FirstErrorLine returns the first line of the fields nested under the input path key in the resource. Only scalar
+// fields are recorded in the field map, so this is used to locate structured fields, such as list items.
+func FirstErrorLine(r *resource.Instance, path string) (line int, found bool) {
+	if line, ok := ErrorLine(r, path); ok {
+		return line, true
+	}
+	prefix := strings.TrimSuffix(path, "}")
+	for k, l := range r.Origin.FieldMap() {
+		if !strings.HasPrefix(k, prefix+".") && !strings.HasPrefix(k, prefix+"[") {
+			continue
+		}
+		if !found || l < line {
+			line, found = l, true
+		}
+	}
+	return line, found
+}
+
 // ExtractLabelFromSelectorString returns the label of the match in the k8s labels.Selector
 func ExtractLabelFromSelectorString(s string) string {
 	equalIndex := strings.Index(s, "=")
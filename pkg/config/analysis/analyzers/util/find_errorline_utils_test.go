@@ -92,3 +92,25 @@ func TestConstants(t *testing.T) {
 		g.Expect(fieldMap[v]).To(Equal(1))
 	}
 }
+
+func TestFirstErrorLine(t *testing.T) {
+	g := NewWithT(t)
+	r := &resource.Instance{Origin: &legacykube.Origin{FieldsMap: map[string]int{
+		"{.spec.rules[0].from[0].source.namespaces[0]}": 12,
+		"{.spec.rules[0].to[0].operation.paths[0]}":     15,
+		"{.spec.rules[1].to[0].operation.paths[0]}":     18,
+		"{.spec.rules[10].to[0].operation.paths[0]}":    30,
+		"{.spec.action}": 8,
+	}}}
+	line, ok := FirstErrorLine(r, fmt.Sprintf(AuthorizationPolicyRule, 0))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(line).To(Equal(12))
+	line, ok = FirstErrorLine(r, fmt.Sprintf(AuthorizationPolicyRule, 1))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(line).To(Equal(18))
+	line, ok = FirstErrorLine(r, "{.spec.action}")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(line).To(Equal(8))
+	_, ok = FirstErrorLine(r, fmt.Sprintf(AuthorizationPolicyRule, 2))
+	g.Expect(ok).To(BeFalse())
+}
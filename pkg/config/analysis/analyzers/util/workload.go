@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// Workload is a Pod, or the pod template of a Deployment.
+type Workload struct {
+	// Resource is the Pod or Deployment.
+	Resource *resource.Instance
+	// Kind is "Pod" or "Deployment".
+	Kind   string
+	Labels map[string]string
+	Spec   *corev1.PodSpec
+}
+
+// Workloads returns the Pods, followed by the pod templates of the Deployments. Pods are not available when analyzing
+// files, so the pod templates of Deployments are used as well. Analyzers using it must declare both kinds as inputs.
+func Workloads(c analysis.Context) []Workload {
+	var out []Workload
+	c.ForEach(gvk.Pod, func(r *resource.Instance) bool {
+		out = append(out, Workload{Resource: r, Kind: "Pod", Labels: r.Metadata.Labels, Spec: r.Message.(*corev1.PodSpec)})
+		return true
+	})
+	c.ForEach(gvk.Deployment, func(r *resource.Instance) bool {
+		d := r.Message.(*appsv1.DeploymentSpec)
+		out = append(out, Workload{Resource: r, Kind: "Deployment", Labels: d.Template.Labels, Spec: &d.Template.Spec})
+		return true
+	})
+	return out
+}
+
+// ResolveTargetPort returns the container port a service port forwards to for the pod, and false if the named target
+// port is not declared by any of its containers.
+func ResolveTargetPort(port corev1.ServicePort, pod *corev1.PodSpec) (uint32, bool) {
+	switch {
+	case port.TargetPort.StrVal != "":
+		for _, c := range pod.Containers {
+			for _, cp := range c.Ports {
+				if cp.Name == port.TargetPort.StrVal {
+					return uint32(cp.ContainerPort), true
+				}
+			}
+		}
+		return 0, false
+	case port.TargetPort.IntVal != 0:
+		return uint32(port.TargetPort.IntVal), true
+	default:
+		return uint32(port.Port), true
+	}
+}
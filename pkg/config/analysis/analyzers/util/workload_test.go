@@ -0,0 +1,47 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestResolveTargetPort(t *testing.T) {
+	g := NewWithT(t)
+
+	pod := &corev1.PodSpec{Containers: []corev1.Container{{
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+	}}}
+	resolve := func(port corev1.ServicePort) []any {
+		p, ok := ResolveTargetPort(port, pod)
+		return []any{p, ok}
+	}
+
+	// No target port
+	g.Expect(resolve(corev1.ServicePort{Port: 80})).To(Equal([]any{uint32(80), true}))
+
+	// Numeric target port
+	g.Expect(resolve(corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt32(9090)})).To(Equal([]any{uint32(9090), true}))
+
+	// Named target port
+	g.Expect(resolve(corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")})).To(Equal([]any{uint32(8080), true}))
+
+	// Named target port not declared by the containers
+	g.Expect(resolve(corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("grpc")})).To(Equal([]any{uint32(0), false}))
+}
@@ -0,0 +1,192 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/slices"
+)
+
+// predicate is a condition on a single attribute of a request. A nil match only requires the attribute to be present.
+type predicate struct {
+	attribute string
+	match     *v1alpha3.StringMatch
+	// negated predicates require the attribute not to match.
+	negated    bool
+	ignoreCase bool
+}
+
+// matchModel is the set of requests matched by a HTTPMatchRequest: all the predicates must be satisfied.
+type matchModel struct {
+	predicates []predicate
+	// gateways the match is restricted to, if any.
+	gateways []string
+}
+
+func exact(v string) *v1alpha3.StringMatch {
+	return &v1alpha3.StringMatch{MatchType: &v1alpha3.StringMatch_Exact{Exact: v}}
+}
+
+func newMatchModel(m *v1alpha3.HTTPMatchRequest) matchModel {
+	mm := matchModel{gateways: m.GetGateways()}
+	add := func(attribute string, match *v1alpha3.StringMatch, negated bool) {
+		mm.predicates = append(mm.predicates, predicate{attribute: attribute, match: match, negated: negated})
+	}
+	if m.GetUri() != nil {
+		mm.predicates = append(mm.predicates, predicate{attribute: "uri", match: m.GetUri(), ignoreCase: m.GetIgnoreUriCase()})
+	}
+	if m.GetScheme() != nil {
+		add("scheme", m.GetScheme(), false)
+	}
+	if m.GetMethod() != nil {
+		add("method", m.GetMethod(), false)
+	}
+	if m.GetAuthority() != nil {
+		add("authority", m.GetAuthority(), false)
+	}
+	for k, v := range m.GetHeaders() {
+		add("headers."+k, emptyToPresence(v), false)
+	}
+	for k, v := range m.GetWithoutHeaders() {
+		add("headers."+k, emptyToPresence(v), true)
+	}
+	for k, v := range m.GetQueryParams() {
+		add("queryParams."+k, emptyToPresence(v), false)
+	}
+	if m.GetPort() != 0 {
+		add("port", exact(strconv.Itoa(int(m.GetPort()))), false)
+	}
+	for k, v := range m.GetSourceLabels() {
+		add("sourceLabels."+k, exact(v), false)
+	}
+	if m.GetSourceNamespace() != "" {
+		add("sourceNamespace", exact(m.GetSourceNamespace()), false)
+	}
+	return mm
+}
+
+// emptyToPresence returns nil for string matches without a match type, which only check that a header or query
+// parameter is present.
+func emptyToPresence(m *v1alpha3.StringMatch) *v1alpha3.StringMatch {
+	if m.GetMatchType() == nil {
+		return nil
+	}
+	return m
+}
+
+// newMatchModels returns the models of the matches of a route. A route without matches matches all requests.
+func newMatchModels(matches []*v1alpha3.HTTPMatchRequest) []matchModel {
+	if len(matches) == 0 {
+		return []matchModel{{}}
+	}
+	return slices.Map(matches, newMatchModel)
+}
+
+// covers returns true if every request matched by o is also matched by m. The check is conservative: it may return
+// false for matches that do cover each other, for example when they use different regular expressions.
+func (m matchModel) covers(o matchModel) bool {
+	if len(m.gateways) > 0 {
+		if len(o.gateways) == 0 {
+			return false
+		}
+		for _, gw := range o.gateways {
+			if !slices.Contains(m.gateways, gw) {
+				return false
+			}
+		}
+	}
+	for _, p := range m.predicates {
+		implied := false
+		for _, q := range o.predicates {
+			if q.implies(p) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return false
+		}
+	}
+	return true
+}
+
+// implies returns true if every request satisfying q also satisfies p.
+func (q predicate) implies(p predicate) bool {
+	if q.attribute != p.attribute || q.negated != p.negated {
+		return false
+	}
+	if q.negated {
+		// Not matching q implies not matching p if everything p matches is matched by q.
+		return stringMatchCovers(q.match, q.ignoreCase, p.match, p.ignoreCase)
+	}
+	return stringMatchCovers(p.match, p.ignoreCase, q.match, q.ignoreCase)
+}
+
+// stringMatchCovers returns true if every value matched by o is also matched by m. A nil match matches any value.
+func stringMatchCovers(m *v1alpha3.StringMatch, mIgnoreCase bool, o *v1alpha3.StringMatch, oIgnoreCase bool) bool {
+	if m == nil || matchesAnything(m) {
+		return true
+	}
+	if o == nil {
+		return false
+	}
+	if oIgnoreCase && !mIgnoreCase {
+		return false
+	}
+	fold := func(s string) string {
+		if mIgnoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch mt := m.GetMatchType().(type) {
+	case *v1alpha3.StringMatch_Exact:
+		return o.GetExact() != "" && fold(o.GetExact()) == fold(mt.Exact)
+	case *v1alpha3.StringMatch_Prefix:
+		switch ot := o.GetMatchType().(type) {
+		case *v1alpha3.StringMatch_Exact:
+			return strings.HasPrefix(fold(ot.Exact), fold(mt.Prefix))
+		case *v1alpha3.StringMatch_Prefix:
+			return strings.HasPrefix(fold(ot.Prefix), fold(mt.Prefix))
+		}
+	case *v1alpha3.StringMatch_Regex:
+		switch ot := o.GetMatchType().(type) {
+		case *v1alpha3.StringMatch_Exact:
+			if mIgnoreCase {
+				return false
+			}
+			re, err := regexp.Compile("^(?:" + mt.Regex + ")$")
+			return err == nil && re.MatchString(ot.Exact)
+		case *v1alpha3.StringMatch_Regex:
+			return ot.Regex == mt.Regex
+		}
+	}
+	return false
+}
+
+// matchesAnything returns true for string matches accepting any value.
+func matchesAnything(m *v1alpha3.StringMatch) bool {
+	switch mt := m.GetMatchType().(type) {
+	case *v1alpha3.StringMatch_Prefix:
+		return mt.Prefix == ""
+	case *v1alpha3.StringMatch_Regex:
+		return mt.Regex == ".*"
+	}
+	return false
+}
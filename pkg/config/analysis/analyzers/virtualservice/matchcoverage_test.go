@@ -0,0 +1,131 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"testing"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func prefix(v string) *v1alpha3.StringMatch {
+	return &v1alpha3.StringMatch{MatchType: &v1alpha3.StringMatch_Prefix{Prefix: v}}
+}
+
+func regex(v string) *v1alpha3.StringMatch {
+	return &v1alpha3.StringMatch{MatchType: &v1alpha3.StringMatch_Regex{Regex: v}}
+}
+
+func TestStringMatchCovers(t *testing.T) {
+	assert.Equal(t, stringMatchCovers(nil, false, exact("/foo"), false), true)
+	assert.Equal(t, stringMatchCovers(exact("/foo"), false, nil, false), false)
+	assert.Equal(t, stringMatchCovers(prefix(""), false, nil, false), true)
+	assert.Equal(t, stringMatchCovers(regex(".*"), false, prefix("/foo"), false), true)
+	assert.Equal(t, stringMatchCovers(exact("/foo"), false, exact("/foo"), false), true)
+	assert.Equal(t, stringMatchCovers(exact("/foo"), false, exact("/FOO"), false), false)
+	assert.Equal(t, stringMatchCovers(exact("/foo"), true, exact("/FOO"), false), true)
+	assert.Equal(t, stringMatchCovers(exact("/foo"), false, exact("/foo"), true), false)
+	assert.Equal(t, stringMatchCovers(prefix("/foo"), false, exact("/foo/bar"), false), true)
+	assert.Equal(t, stringMatchCovers(prefix("/foo"), false, prefix("/foobar"), false), true)
+	assert.Equal(t, stringMatchCovers(prefix("/foo/bar"), false, prefix("/foo"), false), false)
+	assert.Equal(t, stringMatchCovers(prefix("/foo"), false, regex("/foo.*"), false), false)
+	assert.Equal(t, stringMatchCovers(regex("/v[0-9]+"), false, exact("/v12"), false), true)
+	assert.Equal(t, stringMatchCovers(regex("/v[0-9]+"), false, exact("/v12/foo"), false), false)
+	assert.Equal(t, stringMatchCovers(regex("/v[0-9]+"), false, regex("/v[0-9]+"), false), true)
+	assert.Equal(t, stringMatchCovers(regex("/v[0-9]+"), false, prefix("/v1"), false), false)
+}
+
+func TestMatchCovers(t *testing.T) {
+	cases := []struct {
+		name string
+		m    *v1alpha3.HTTPMatchRequest
+		// other is covered by m, if want is true
+		other *v1alpha3.HTTPMatchRequest
+		want  bool
+	}{
+		{
+			name:  "empty match matches everything",
+			m:     &v1alpha3.HTTPMatchRequest{},
+			other: &v1alpha3.HTTPMatchRequest{Uri: prefix("/foo"), Port: 80},
+			want:  true,
+		},
+		{
+			name:  "restricted match does not cover empty match",
+			m:     &v1alpha3.HTTPMatchRequest{Uri: prefix("/foo")},
+			other: &v1alpha3.HTTPMatchRequest{},
+			want:  false,
+		},
+		{
+			name:  "additional header",
+			m:     &v1alpha3.HTTPMatchRequest{Uri: prefix("/foo")},
+			other: &v1alpha3.HTTPMatchRequest{Uri: exact("/foo/bar"), Headers: map[string]*v1alpha3.StringMatch{"x-canary": exact("true")}},
+			want:  true,
+		},
+		{
+			name:  "header presence",
+			m:     &v1alpha3.HTTPMatchRequest{Headers: map[string]*v1alpha3.StringMatch{"x-canary": {}}},
+			other: &v1alpha3.HTTPMatchRequest{Headers: map[string]*v1alpha3.StringMatch{"x-canary": exact("true")}},
+			want:  true,
+		},
+		{
+			name:  "header value does not cover presence",
+			m:     &v1alpha3.HTTPMatchRequest{Headers: map[string]*v1alpha3.StringMatch{"x-canary": exact("true")}},
+			other: &v1alpha3.HTTPMatchRequest{Headers: map[string]*v1alpha3.StringMatch{"x-canary": {}}},
+			want:  false,
+		},
+		{
+			name:  "without headers",
+			m:     &v1alpha3.HTTPMatchRequest{WithoutHeaders: map[string]*v1alpha3.StringMatch{"x-debug": exact("true")}},
+			other: &v1alpha3.HTTPMatchRequest{WithoutHeaders: map[string]*v1alpha3.StringMatch{"x-debug": {}}},
+			want:  true,
+		},
+		{
+			name:  "without headers does not cover headers",
+			m:     &v1alpha3.HTTPMatchRequest{WithoutHeaders: map[string]*v1alpha3.StringMatch{"x-debug": {}}},
+			other: &v1alpha3.HTTPMatchRequest{Headers: map[string]*v1alpha3.StringMatch{"x-debug": exact("true")}},
+			want:  false,
+		},
+		{
+			name:  "source labels subset",
+			m:     &v1alpha3.HTTPMatchRequest{SourceLabels: map[string]string{"app": "a"}},
+			other: &v1alpha3.HTTPMatchRequest{SourceLabels: map[string]string{"app": "a", "version": "v1"}},
+			want:  true,
+		},
+		{
+			name:  "different port",
+			m:     &v1alpha3.HTTPMatchRequest{Port: 80},
+			other: &v1alpha3.HTTPMatchRequest{Port: 8080},
+			want:  false,
+		},
+		{
+			name:  "gateways subset",
+			m:     &v1alpha3.HTTPMatchRequest{Gateways: []string{"a", "b"}},
+			other: &v1alpha3.HTTPMatchRequest{Gateways: []string{"b"}},
+			want:  true,
+		},
+		{
+			name:  "gateways do not cover all gateways",
+			m:     &v1alpha3.HTTPMatchRequest{Gateways: []string{"a"}},
+			other: &v1alpha3.HTTPMatchRequest{},
+			want:  false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, newMatchModel(tc.m).covers(newMatchModel(tc.other)), tc.want)
+		})
+	}
+}
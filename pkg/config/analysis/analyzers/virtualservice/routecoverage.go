@@ -0,0 +1,214 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/slices"
+)
+
+// RouteCoverageAnalyzer checks for HTTP routes that can never be reached because routes ordered before them, including
+// the routes of delegate VirtualServices, match all of their requests.
+type RouteCoverageAnalyzer struct{}
+
+var _ analysis.Analyzer = &RouteCoverageAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *RouteCoverageAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.RouteCoverageAnalyzer",
+		Description: "Checks for VirtualService HTTP routes shadowed by earlier routes",
+		Inputs: []config.GroupVersionKind{
+			gvk.VirtualService,
+		},
+	}
+}
+
+type routeRef struct {
+	r     *resource.Instance
+	index int
+	name  string
+}
+
+// label returns how the route is referred to in messages reported on the VirtualService from.
+func (ref routeRef) label(from *resource.Instance) string {
+	l := fmt.Sprintf("#%d", ref.index)
+	if ref.name != "" {
+		l = fmt.Sprintf("%q", ref.name)
+	}
+	if ref.r != from {
+		l += " of VirtualService " + ref.r.Metadata.FullName.String()
+	}
+	return l
+}
+
+// routeEntry is a route in the ordered list of routes of a VirtualService, once delegates are merged.
+type routeEntry struct {
+	routeRef
+	matches []matchModel
+}
+
+type delegateRouteKey struct {
+	vs    resource.FullName
+	index int
+}
+
+// delegateRoute tracks whether a route of a delegate VirtualService is reachable from any of the routes delegating to
+// it.
+type delegateRoute struct {
+	routeRef
+	reachable  bool
+	shadowedBy []string
+}
+
+// Analyze implements Analyzer
+func (a *RouteCoverageAnalyzer) Analyze(c analysis.Context) {
+	delegates := map[delegateRouteKey]*delegateRoute{}
+	var delegateKeys []delegateRouteKey
+
+	c.ForEach(gvk.VirtualService, func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		// Delegate VirtualServices are analyzed as part of the VirtualServices delegating to them.
+		if len(vs.GetHosts()) == 0 {
+			return true
+		}
+
+		var entries []routeEntry
+		for i, route := range vs.GetHttp() {
+			ref := routeRef{r: r, index: i, name: route.GetName()}
+			matches := newMatchModels(route.GetMatch())
+			if by := shadowedBy(entries, matches); by != nil {
+				report(c, ref, labels(by, r))
+				continue
+			}
+			if route.GetDelegate() == nil {
+				entries = append(entries, routeEntry{routeRef: ref, matches: matches})
+				continue
+			}
+
+			dr := findDelegate(c, r, route.GetDelegate())
+			if dr == nil {
+				continue
+			}
+			for j, sub := range dr.Message.(*v1alpha3.VirtualService).GetHttp() {
+				merged, conflict := model.MergeHTTPMatchRequests(route.GetMatch(), sub.GetMatch())
+				if conflict {
+					// The route is ignored by istiod.
+					continue
+				}
+				subRef := routeRef{r: dr, index: j, name: sub.GetName()}
+				subMatches := newMatchModels(merged)
+
+				key := delegateRouteKey{vs: dr.Metadata.FullName, index: j}
+				d, f := delegates[key]
+				if !f {
+					d = &delegateRoute{routeRef: subRef}
+					delegates[key] = d
+					delegateKeys = append(delegateKeys, key)
+				}
+				if by := shadowedBy(entries, subMatches); by != nil {
+					for _, l := range labels(by, dr) {
+						if !slices.Contains(d.shadowedBy, l) {
+							d.shadowedBy = append(d.shadowedBy, l)
+						}
+					}
+				} else {
+					d.reachable = true
+				}
+				entries = append(entries, routeEntry{routeRef: subRef, matches: subMatches})
+			}
+		}
+		return true
+	})
+
+	for _, key := range delegateKeys {
+		if d := delegates[key]; !d.reachable {
+			report(c, d.routeRef, d.shadowedBy)
+		}
+	}
+}
+
+// shadowedBy returns the routes matching all the requests matched by matches, or nil if some requests are not matched
+// by any of the routes.
+func shadowedBy(entries []routeEntry, matches []matchModel) []routeRef {
+	var by []routeRef
+	for _, m := range matches {
+		var covering *routeRef
+	entries:
+		for i := range entries {
+			for _, em := range entries[i].matches {
+				if em.covers(m) {
+					covering = &entries[i].routeRef
+					break entries
+				}
+			}
+		}
+		if covering == nil {
+			return nil
+		}
+		if !containsRef(by, *covering) {
+			by = append(by, *covering)
+		}
+	}
+	return by
+}
+
+func findDelegate(c analysis.Context, r *resource.Instance, d *v1alpha3.Delegate) *resource.Instance {
+	ns := r.Metadata.FullName.Namespace
+	if d.GetNamespace() != "" {
+		ns = resource.Namespace(d.GetNamespace())
+	}
+	dr := c.Find(gvk.VirtualService, resource.NewFullName(ns, resource.LocalName(d.GetName())))
+	if dr == nil || len(dr.Message.(*v1alpha3.VirtualService).GetHosts()) > 0 {
+		// Missing or invalid delegates are ignored by istiod.
+		return nil
+	}
+	return dr
+}
+
+func labels(refs []routeRef, from *resource.Instance) []string {
+	out := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		out = append(out, ref.label(from))
+	}
+	return out
+}
+
+func containsRef(refs []routeRef, ref routeRef) bool {
+	for _, o := range refs {
+		if o.r == ref.r && o.index == ref.index {
+			return true
+		}
+	}
+	return false
+}
+
+func report(c analysis.Context, ref routeRef, shadowedBy []string) {
+	m := msg.NewVirtualServiceShadowedRoute(ref.r, ref.label(ref.r), strings.Join(shadowedBy, ", "))
+	if line, ok := util.FirstErrorLine(ref.r, fmt.Sprintf(util.VirtualServiceHTTPRoute, ref.index)); ok {
+		m.Line = line
+	}
+	c.Report(gvk.VirtualService, m)
+}
@@ -0,0 +1,44 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"strings"
+
+	"gomodules.xyz/jsonpatch/v2"
+)
+
+// Fix is a suggested remediation for a message.
+type Fix struct {
+	// Description is a short summary of the change made by the fix.
+	Description string `json:"description"`
+
+	// Patch is a JSON patch against the resource associated with the message, in its Kubernetes representation. Patches
+	// should start with "test" operations on the values they rely on, so they are not applied to a modified resource.
+	Patch []jsonpatch.Operation `json:"patch"`
+}
+
+// NewFix returns a new Fix instance.
+func NewFix(description string, patch ...jsonpatch.Operation) Fix {
+	return Fix{
+		Description: description,
+		Patch:       patch,
+	}
+}
+
+// JSONPointerEscape escapes a key for use as a reference token in a JSON pointer, as defined in RFC 6901.
+func JSONPointerEscape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
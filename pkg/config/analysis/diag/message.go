@@ -63,6 +63,9 @@ type Message struct {
 
 	// Line is the line number of the error place in the message
 	Line int
+
+	// Fixes are optional remediations for the message
+	Fixes []Fix
 }
 
 // Unstructured returns this message as a JSON-style unstructured map
@@ -89,6 +92,10 @@ func (m *Message) Unstructured(includeOrigin bool) map[string]any {
 	}
 	result["documentationUrl"] = fmt.Sprintf("%s/%s/%s", url.ConfigAnalysis, strings.ToLower(m.Type.Code()), docQueryString)
 
+	if len(m.Fixes) > 0 {
+		result["fixes"] = m.Fixes
+	}
+
 	return result
 }
 
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"gomodules.xyz/jsonpatch/v2"
 
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/url"
@@ -53,6 +54,19 @@ func TestMessage_Unstructured(t *testing.T) {
 
 	g.Expect(m.Unstructured(true)).To((HaveKey("origin")))
 	g.Expect(m.Unstructured(false)).To(Not(HaveKey("origin")))
+	g.Expect(m.Unstructured(false)).To(Not(HaveKey("fixes")))
+
+	m.Fixes = append(m.Fixes, NewFix("use cheddar", jsonpatch.NewOperation("replace", "/spec/cheese", "cheddar")))
+	g.Expect(m.Unstructured(false)["fixes"]).To(Equal([]Fix{{
+		Description: "use cheddar",
+		Patch:       []jsonpatch.Operation{{Operation: "replace", Path: "/spec/cheese", Value: "cheddar"}},
+	}}))
+}
+
+func TestJSONPointerEscape(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(JSONPointerEscape("istio-injection")).To(Equal("istio-injection"))
+	g.Expect(JSONPointerEscape("istio.io/rev~1")).To(Equal("istio.io~1rev~01"))
 }
 
 func TestMessageWithDocRef(t *testing.T) {
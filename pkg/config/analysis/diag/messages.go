@@ -83,3 +83,22 @@ func (ms *Messages) FilterOutLowerThan(outputLevel Level) Messages {
 	}
 	return outputMessages
 }
+
+// OverrideLevels returns a copy of the messages, with the level of messages whose code is in levels replaced by the
+// corresponding level.
+func (ms *Messages) OverrideLevels(levels map[string]Level) Messages {
+	types := map[string]*MessageType{}
+	out := make(Messages, 0, len(*ms))
+	for _, m := range *ms {
+		if l, ok := levels[m.Type.Code()]; ok && l != m.Type.Level() {
+			mt, ok := types[m.Type.Code()]
+			if !ok {
+				mt = NewMessageType(l, m.Type.Code(), m.Type.Template())
+				types[m.Type.Code()] = mt
+			}
+			m.Type = mt
+		}
+		out = append(out, m)
+	}
+	return out
+}
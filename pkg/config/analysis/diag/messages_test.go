@@ -185,3 +185,35 @@ func TestMessages_FilterOutAll(t *testing.T) {
 
 	g.Expect(filteredMsgs).To(Equal(expectedMsgs))
 }
+
+func TestMessages_OverrideLevels(t *testing.T) {
+	g := NewWithT(t)
+
+	firstMsg := NewMessage(
+		NewMessageType(Warning, "B1", "Template: %q"),
+		MockResource("A"),
+		"A",
+	)
+	secondMsg := NewMessage(
+		NewMessageType(Warning, "B1", "Template: %q"),
+		MockResource("B"),
+		"B",
+	)
+	thirdMsg := NewMessage(
+		NewMessageType(Error, "C1", "Template: %q"),
+		MockResource("C"),
+		"C",
+	)
+
+	msgs := Messages{firstMsg, secondMsg, thirdMsg}
+	overridden := msgs.OverrideLevels(map[string]Level{"B1": Error, "D1": Info})
+
+	g.Expect(overridden).To(HaveLen(3))
+	g.Expect(overridden[0].Type.Level()).To(Equal(Error))
+	g.Expect(overridden[1].Type.Level()).To(Equal(Error))
+	g.Expect(overridden[0].Type.Code()).To(Equal("B1"))
+	g.Expect(overridden[0].String()).To(Equal(`Error [B1] (A) Template: "A"`))
+	g.Expect(overridden[2]).To(Equal(thirdMsg))
+	// The original messages are left untouched
+	g.Expect(msgs[0].Type.Level()).To(Equal(Warning))
+}
@@ -321,6 +321,13 @@ func (sa *IstiodAnalyzer) addReaderKubeSourceInternal(readers []ReaderSource, in
 	return errs
 }
 
+// RemoveReaderKubeSource removes the resources added from the reader with the given name by AddReaderKubeSource.
+func (sa *IstiodAnalyzer) RemoveReaderKubeSource(name string) {
+	if sa.fileSource != nil {
+		sa.fileSource.RemoveContent(name)
+	}
+}
+
 // AddRunningKubeSource adds a source based on a running k8s cluster to the current IstiodAnalyzer
 // Also tries to get mesh config from the running cluster, if it can
 func (sa *IstiodAnalyzer) AddRunningKubeSource(c kubelib.Client) {
@@ -503,6 +510,16 @@ func (sa *IstiodAnalyzer) RegisterEventHandler(kind config.GroupVersionKind, han
 	for _, store := range sa.stores {
 		store.RegisterEventHandler(kind, handler)
 	}
+	for id, store := range sa.multiClusterStores {
+		// The store of the primary cluster aggregates the stores above.
+		if id == sa.cluster {
+			continue
+		}
+		store.RegisterEventHandler(kind, handler)
+	}
+	if sa.fileSource != nil {
+		sa.fileSource.RegisterEventHandler(kind, handler)
+	}
 }
 
 func (sa *IstiodAnalyzer) Schemas() collection.Schemas {
@@ -251,6 +251,82 @@ var (
 	// MultiClusterInconsistentService defines a diag.MessageType for message "MultiClusterInconsistentService".
 	// Description: The services live in different clusters under multi-cluster deployment model are inconsistent
 	MultiClusterInconsistentService = diag.NewMessageType(diag.Warning, "IST0170", "The service %v in namespace %q is inconsistent across clusters %q, which can lead to undefined behaviors. The inconsistent behaviors are: %v.")
+
+	// MTLSPolicyConflict defines a diag.MessageType for message "MTLSPolicyConflict".
+	// Description: A DestinationRule and a PeerAuthentication have incompatible TLS modes for the same workloads
+	MTLSPolicyConflict = diag.NewMessageType(diag.Error, "IST0171", "DestinationRule for host %s uses TLS mode %s on port %d, but PeerAuthentication %s requires mTLS mode %s for the destination workloads. Requests will fail.")
+
+	// AuthorizationPolicyDeadAllowRule defines a diag.MessageType for message "AuthorizationPolicyDeadAllowRule".
+	// Description: An ALLOW rule of an AuthorizationPolicy can never allow a request, because a DENY rule matches all its requests
+	AuthorizationPolicyDeadAllowRule = diag.NewMessageType(diag.Warning, "IST0172", "Rule %d of this ALLOW policy can never allow a request: every request it matches is denied by rule %d of DENY policy %s.")
+
+	// AuthorizationPolicyRedundantRule defines a diag.MessageType for message "AuthorizationPolicyRedundantRule".
+	// Description: An ALLOW rule of an AuthorizationPolicy only matches requests already allowed by another rule
+	AuthorizationPolicyRedundantRule = diag.NewMessageType(diag.Info, "IST0173", "Rule %d of this ALLOW policy is redundant: every request it matches is already allowed by rule %d of policy %s.")
+
+	// AuthorizationPolicyHTTPFieldsOnTCPPort defines a diag.MessageType for message "AuthorizationPolicyHTTPFieldsOnTCPPort".
+	// Description: An AuthorizationPolicy rule uses HTTP-only fields, but applies to TCP ports
+	AuthorizationPolicyHTTPFieldsOnTCPPort = diag.NewMessageType(diag.Warning, "IST0174", "Rule %d uses HTTP-only fields (%s), but applies to TCP port(s) %s of %s. %s")
+
+	// AuthorizationPolicyUnmatchablePrincipal defines a diag.MessageType for message "AuthorizationPolicyUnmatchablePrincipal".
+	// Description: An AuthorizationPolicy principal can never match a workload identity of the mesh
+	AuthorizationPolicyUnmatchablePrincipal = diag.NewMessageType(diag.Warning, "IST0175", "Principal %q in rule %d can never match: %s.")
+
+	// VirtualServiceShadowedRoute defines a diag.MessageType for message "VirtualServiceShadowedRoute".
+	// Description: A VirtualService HTTP route can never be reached because earlier routes match all of its requests
+	VirtualServiceShadowedRoute = diag.NewMessageType(diag.Warning, "IST0176", "HTTP route %s can never be reached: all of its requests are matched by earlier route(s) %s.")
+
+	// DestinationRuleSubsetNoEndpoints defines a diag.MessageType for message "DestinationRuleSubsetNoEndpoints".
+	// Description: A DestinationRule subset does not match any endpoint of its host
+	DestinationRuleSubsetNoEndpoints = diag.NewMessageType(diag.Warning, "IST0177", "Subset %q of host %s matches no endpoints: none of the workloads of the host have the labels %s.")
+
+	// VirtualServiceRouteToEmptySubset defines a diag.MessageType for message "VirtualServiceRouteToEmptySubset".
+	// Description: A VirtualService routes traffic to a DestinationRule subset without endpoints
+	VirtualServiceRouteToEmptySubset = diag.NewMessageType(diag.Warning, "IST0178", "Route %s sends %d%% of its traffic to subset %q of host %s, which matches no endpoints.")
+
+	// GatewayAPIRouteNotAccepted defines a diag.MessageType for message "GatewayAPIRouteNotAccepted".
+	// Description: A Gateway API route is not accepted by one of its parents
+	GatewayAPIRouteNotAccepted = diag.NewMessageType(diag.Error, "IST0179", "Route is not accepted by parent %s: %s (%s).")
+
+	// GatewayAPIReferenceNotPermitted defines a diag.MessageType for message "GatewayAPIReferenceNotPermitted".
+	// Description: A Gateway API resource references a resource in another namespace, but no ReferenceGrant allows it
+	GatewayAPIReferenceNotPermitted = diag.NewMessageType(diag.Error, "IST0180", "Reference not permitted by any ReferenceGrant: %s.")
+
+	// GatewayAPIRouteConflict defines a diag.MessageType for message "GatewayAPIRouteConflict".
+	// Description: A Gateway API route match is ignored because an older route has the same match on the same listener
+	GatewayAPIRouteConflict = diag.NewMessageType(diag.Warning, "IST0181", "Match %d of rule %d is ignored on parent %s for hostnames %s: %s has the same match and takes precedence.")
+
+	// SidecarEgressHostNotImported defines a diag.MessageType for message "SidecarEgressHostNotImported".
+	// Description: A host used by the configuration of a namespace is not imported by the Sidecar of the namespace
+	SidecarEgressHostNotImported = diag.NewMessageType(diag.Warning, "IST0182", "Host %s is not imported by any egress listener of the Sidecar, but is used by %s.")
+
+	// EnvoyFilterPatchNoMatch defines a diag.MessageType for message "EnvoyFilterPatchNoMatch".
+	// Description: An EnvoyFilter patch does not change the configuration generated for any of the workloads it selects
+	EnvoyFilterPatchNoMatch = diag.NewMessageType(diag.Warning, "IST0183", "Patch %d (%s) of the EnvoyFilter does not change the configuration generated for any of the selected workloads: %s.")
+
+	// EnvoyFilterPatchInvalidConfig defines a diag.MessageType for message "EnvoyFilterPatchInvalidConfig".
+	// Description: An EnvoyFilter patch produces Envoy configuration which does not pass validation
+	EnvoyFilterPatchInvalidConfig = diag.NewMessageType(diag.Error, "IST0184", "Patch %d (%s) of the EnvoyFilter produces invalid Envoy configuration for %s: %s")
+
+	// EnvoyFilterMatchesInternalName defines a diag.MessageType for message "EnvoyFilterMatchesInternalName".
+	// Description: An EnvoyFilter patch matches a name generated by Istio, which may change between versions
+	EnvoyFilterMatchesInternalName = diag.NewMessageType(diag.Warning, "IST0185", "Patch %d of the EnvoyFilter matches %s %q, which is generated by Istio and may change between versions. %s")
+
+	// TelemetryTracingSamplingConflict defines a diag.MessageType for message "TelemetryTracingSamplingConflict".
+	// Description: The tracing sampling percentage of a Telemetry conflicts with the mesh configuration
+	TelemetryTracingSamplingConflict = diag.NewMessageType(diag.Warning, "IST0186", "The random sampling percentage %s%% of the Telemetry %s.")
+
+	// InvalidTelemetryExpression defines a diag.MessageType for message "InvalidTelemetryExpression".
+	// Description: A CEL expression of a Telemetry cannot be parsed
+	InvalidTelemetryExpression = diag.NewMessageType(diag.Error, "IST0187", "The %s expression %q of the Telemetry cannot be parsed: %s")
+
+	// TelemetryUnknownReference defines a diag.MessageType for message "TelemetryUnknownReference".
+	// Description: A Telemetry references a metric, tag or attribute unknown to the proxies, so the data is dropped
+	TelemetryUnknownReference = diag.NewMessageType(diag.Warning, "IST0188", "The %s of the Telemetry references unknown %s %q.")
+
+	// TelemetryProviderServiceNotFound defines a diag.MessageType for message "TelemetryProviderServiceNotFound".
+	// Description: A provider used by a Telemetry sends data to a service which cannot be resolved
+	TelemetryProviderServiceNotFound = diag.NewMessageType(diag.Error, "IST0189", "Provider %q used by the Telemetry sends data to service %q, which %s.")
 )
 
 // All returns a list of all known message types.
@@ -317,6 +393,25 @@ func All() []*diag.MessageType {
 		UnknownUpgradeCompatibility,
 		UpdateIncompatibility,
 		MultiClusterInconsistentService,
+		MTLSPolicyConflict,
+		AuthorizationPolicyDeadAllowRule,
+		AuthorizationPolicyRedundantRule,
+		AuthorizationPolicyHTTPFieldsOnTCPPort,
+		AuthorizationPolicyUnmatchablePrincipal,
+		VirtualServiceShadowedRoute,
+		DestinationRuleSubsetNoEndpoints,
+		VirtualServiceRouteToEmptySubset,
+		GatewayAPIRouteNotAccepted,
+		GatewayAPIReferenceNotPermitted,
+		GatewayAPIRouteConflict,
+		SidecarEgressHostNotImported,
+		EnvoyFilterPatchNoMatch,
+		EnvoyFilterPatchInvalidConfig,
+		EnvoyFilterMatchesInternalName,
+		TelemetryTracingSamplingConflict,
+		InvalidTelemetryExpression,
+		TelemetryUnknownReference,
+		TelemetryProviderServiceNotFound,
 	}
 }
 
@@ -919,3 +1014,216 @@ func NewMultiClusterInconsistentService(r *resource.Instance, serviceName string
 		error,
 	)
 }
+
+// NewMTLSPolicyConflict returns a new diag.Message based on MTLSPolicyConflict.
+func NewMTLSPolicyConflict(r *resource.Instance, host string, clientMode string, port int, peerAuthentication string, serverMode string) diag.Message {
+	return diag.NewMessage(
+		MTLSPolicyConflict,
+		r,
+		host,
+		clientMode,
+		port,
+		peerAuthentication,
+		serverMode,
+	)
+}
+
+// NewAuthorizationPolicyDeadAllowRule returns a new diag.Message based on AuthorizationPolicyDeadAllowRule.
+func NewAuthorizationPolicyDeadAllowRule(r *resource.Instance, rule int, denyRule int, denyPolicy string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyDeadAllowRule,
+		r,
+		rule,
+		denyRule,
+		denyPolicy,
+	)
+}
+
+// NewAuthorizationPolicyRedundantRule returns a new diag.Message based on AuthorizationPolicyRedundantRule.
+func NewAuthorizationPolicyRedundantRule(r *resource.Instance, rule int, otherRule int, otherPolicy string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyRedundantRule,
+		r,
+		rule,
+		otherRule,
+		otherPolicy,
+	)
+}
+
+// NewAuthorizationPolicyHTTPFieldsOnTCPPort returns a new diag.Message based on AuthorizationPolicyHTTPFieldsOnTCPPort.
+func NewAuthorizationPolicyHTTPFieldsOnTCPPort(r *resource.Instance, rule int, fields string, ports string, workloads string, effect string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyHTTPFieldsOnTCPPort,
+		r,
+		rule,
+		fields,
+		ports,
+		workloads,
+		effect,
+	)
+}
+
+// NewAuthorizationPolicyUnmatchablePrincipal returns a new diag.Message based on AuthorizationPolicyUnmatchablePrincipal.
+func NewAuthorizationPolicyUnmatchablePrincipal(r *resource.Instance, principal string, rule int, reason string) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyUnmatchablePrincipal,
+		r,
+		principal,
+		rule,
+		reason,
+	)
+}
+
+// NewVirtualServiceShadowedRoute returns a new diag.Message based on VirtualServiceShadowedRoute.
+func NewVirtualServiceShadowedRoute(r *resource.Instance, route string, shadowedBy string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceShadowedRoute,
+		r,
+		route,
+		shadowedBy,
+	)
+}
+
+// NewDestinationRuleSubsetNoEndpoints returns a new diag.Message based on DestinationRuleSubsetNoEndpoints.
+func NewDestinationRuleSubsetNoEndpoints(r *resource.Instance, subset string, host string, labels string) diag.Message {
+	return diag.NewMessage(
+		DestinationRuleSubsetNoEndpoints,
+		r,
+		subset,
+		host,
+		labels,
+	)
+}
+
+// NewVirtualServiceRouteToEmptySubset returns a new diag.Message based on VirtualServiceRouteToEmptySubset.
+func NewVirtualServiceRouteToEmptySubset(r *resource.Instance, route string, weight int, subset string, host string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceRouteToEmptySubset,
+		r,
+		route,
+		weight,
+		subset,
+		host,
+	)
+}
+
+// NewGatewayAPIRouteNotAccepted returns a new diag.Message based on GatewayAPIRouteNotAccepted.
+func NewGatewayAPIRouteNotAccepted(r *resource.Instance, parent string, message string, reason string) diag.Message {
+	return diag.NewMessage(
+		GatewayAPIRouteNotAccepted,
+		r,
+		parent,
+		message,
+		reason,
+	)
+}
+
+// NewGatewayAPIReferenceNotPermitted returns a new diag.Message based on GatewayAPIReferenceNotPermitted.
+func NewGatewayAPIReferenceNotPermitted(r *resource.Instance, detail string) diag.Message {
+	return diag.NewMessage(
+		GatewayAPIReferenceNotPermitted,
+		r,
+		detail,
+	)
+}
+
+// NewGatewayAPIRouteConflict returns a new diag.Message based on GatewayAPIRouteConflict.
+func NewGatewayAPIRouteConflict(r *resource.Instance, match int, rule int, parent string, hostnames string, route string) diag.Message {
+	return diag.NewMessage(
+		GatewayAPIRouteConflict,
+		r,
+		match,
+		rule,
+		parent,
+		hostnames,
+		route,
+	)
+}
+
+// NewSidecarEgressHostNotImported returns a new diag.Message based on SidecarEgressHostNotImported.
+func NewSidecarEgressHostNotImported(r *resource.Instance, host string, usedBy string) diag.Message {
+	return diag.NewMessage(
+		SidecarEgressHostNotImported,
+		r,
+		host,
+		usedBy,
+	)
+}
+
+// NewEnvoyFilterPatchNoMatch returns a new diag.Message based on EnvoyFilterPatchNoMatch.
+func NewEnvoyFilterPatchNoMatch(r *resource.Instance, patch int, operation string, workloads string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterPatchNoMatch,
+		r,
+		patch,
+		operation,
+		workloads,
+	)
+}
+
+// NewEnvoyFilterPatchInvalidConfig returns a new diag.Message based on EnvoyFilterPatchInvalidConfig.
+func NewEnvoyFilterPatchInvalidConfig(r *resource.Instance, patch int, operation string, workload string, reason string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterPatchInvalidConfig,
+		r,
+		patch,
+		operation,
+		workload,
+		reason,
+	)
+}
+
+// NewEnvoyFilterMatchesInternalName returns a new diag.Message based on EnvoyFilterMatchesInternalName.
+func NewEnvoyFilterMatchesInternalName(r *resource.Instance, patch int, kind string, name string, hint string) diag.Message {
+	return diag.NewMessage(
+		EnvoyFilterMatchesInternalName,
+		r,
+		patch,
+		kind,
+		name,
+		hint,
+	)
+}
+
+// NewTelemetryTracingSamplingConflict returns a new diag.Message based on TelemetryTracingSamplingConflict.
+func NewTelemetryTracingSamplingConflict(r *resource.Instance, percentage string, reason string) diag.Message {
+	return diag.NewMessage(
+		TelemetryTracingSamplingConflict,
+		r,
+		percentage,
+		reason,
+	)
+}
+
+// NewInvalidTelemetryExpression returns a new diag.Message based on InvalidTelemetryExpression.
+func NewInvalidTelemetryExpression(r *resource.Instance, field string, expression string, reason string) diag.Message {
+	return diag.NewMessage(
+		InvalidTelemetryExpression,
+		r,
+		field,
+		expression,
+		reason,
+	)
+}
+
+// NewTelemetryUnknownReference returns a new diag.Message based on TelemetryUnknownReference.
+func NewTelemetryUnknownReference(r *resource.Instance, field string, kind string, name string) diag.Message {
+	return diag.NewMessage(
+		TelemetryUnknownReference,
+		r,
+		field,
+		kind,
+		name,
+	)
+}
+
+// NewTelemetryProviderServiceNotFound returns a new diag.Message based on TelemetryProviderServiceNotFound.
+func NewTelemetryProviderServiceNotFound(r *resource.Instance, provider string, service string, reason string) diag.Message {
+	return diag.NewMessage(
+		TelemetryProviderServiceNotFound,
+		r,
+		provider,
+		service,
+		reason,
+	)
+}
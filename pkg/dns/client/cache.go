@@ -0,0 +1,178 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/miekg/dns"
+)
+
+// maxCacheTTL bounds how long an upstream response is cached, regardless of the TTL set by the upstream.
+const maxCacheTTL = time.Hour
+
+// responseCache caches upstream responses for the duration of their TTL. Negative responses (NXDOMAIN, or
+// NOERROR without answers) are cached for the duration given by the SOA record in the authority section,
+// following RFC 2308; negative responses without a SOA record are not cached.
+type responseCache struct {
+	entries *lru.Cache[cacheKey, *cachedResponse]
+	now     func() time.Time
+}
+
+// cacheKey identifies requests that are answered identically by the upstream.
+type cacheKey struct {
+	name  string
+	qtype uint16
+	class uint16
+	// The presence of EDNS, and the DNSSEC OK bit, change the content of the response.
+	edns bool
+	do   bool
+}
+
+type cachedResponse struct {
+	msg      *dns.Msg
+	stored   time.Time
+	expires  time.Time
+	upstream string
+	negative bool
+}
+
+func newResponseCache(size int) *responseCache {
+	entries, err := lru.New[cacheKey, *cachedResponse](size)
+	if err != nil {
+		// Only happens for a non-positive size, which callers exclude.
+		panic(err)
+	}
+	return &responseCache{entries: entries, now: time.Now}
+}
+
+// cacheable returns false for requests whose responses are specific to the client. With the EDNS Client Subnet
+// option (RFC 7871), the upstream may tailor the response to the subnet of the client.
+func cacheable(req *dns.Msg) bool {
+	opt := req.IsEdns0()
+	if opt == nil {
+		return true
+	}
+	for _, o := range opt.Option {
+		if o.Option() == dns.EDNS0SUBNET {
+			return false
+		}
+	}
+	return true
+}
+
+func keyForRequest(req *dns.Msg) cacheKey {
+	q := req.Question[0]
+	k := cacheKey{name: strings.ToLower(q.Name), qtype: q.Qtype, class: q.Qclass}
+	if opt := req.IsEdns0(); opt != nil {
+		k.edns = true
+		k.do = opt.Do()
+	}
+	return k
+}
+
+// get returns a cached response to req, with TTLs decremented by the time spent in the cache, along with the
+// upstream that produced it.
+func (c *responseCache) get(req *dns.Msg) (*dns.Msg, *cachedResponse) {
+	key := keyForRequest(req)
+	entry, f := c.entries.Get(key)
+	if !f {
+		return nil, nil
+	}
+	now := c.now()
+	if !now.Before(entry.expires) {
+		c.entries.Remove(key)
+		return nil, nil
+	}
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	response := entry.msg.Copy()
+	response.Id = req.Id
+	// Keep the question exactly as asked, as some clients randomize the case of the name.
+	response.Question = req.Question
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if rr.Header().Ttl > elapsed {
+				rr.Header().Ttl -= elapsed
+			} else {
+				rr.Header().Ttl = 0
+			}
+		}
+	}
+	return response, entry
+}
+
+// add caches the response to req, if it is cacheable.
+func (c *responseCache) add(req, response *dns.Msg, upstream string) {
+	if response == nil || response.Truncated || len(response.Question) == 0 {
+		return
+	}
+	ttl, negative, ok := cacheTTL(response)
+	if !ok || ttl == 0 {
+		return
+	}
+	if ttl > maxCacheTTL {
+		ttl = maxCacheTTL
+	}
+	now := c.now()
+	c.entries.Add(keyForRequest(req), &cachedResponse{
+		msg:      response.Copy(),
+		stored:   now,
+		expires:  now.Add(ttl),
+		upstream: upstream,
+		negative: negative,
+	})
+}
+
+// cacheTTL returns how long the response can be cached, and whether it is a negative response.
+func cacheTTL(response *dns.Msg) (time.Duration, bool, bool) {
+	switch response.Rcode {
+	case dns.RcodeSuccess:
+		if len(response.Answer) == 0 {
+			ttl, ok := negativeTTL(response)
+			return ttl, true, ok
+		}
+	case dns.RcodeNameError:
+		ttl, ok := negativeTTL(response)
+		return ttl, true, ok
+	default:
+		// Errors such as SERVFAIL or REFUSED are usually transient, and are not cached.
+		return 0, false, false
+	}
+	minTTL := ^uint32(0)
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			minTTL = min(minTTL, rr.Header().Ttl)
+		}
+	}
+	return time.Duration(minTTL) * time.Second, false, true
+}
+
+// negativeTTL returns the TTL of a negative response: the minimum of the SOA record TTL and its MINIMUM field.
+func negativeTTL(response *dns.Msg) (time.Duration, bool) {
+	for _, rr := range response.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return time.Duration(min(soa.Hdr.Ttl, soa.Minttl)) * time.Second, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,258 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/atomic"
+)
+
+func soa(zone string, ttl, minttl uint32) dns.RR {
+	return &dns.SOA{
+		Hdr:    dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:     "ns." + zone,
+		Mbox:   "admin." + zone,
+		Minttl: minttl,
+	}
+}
+
+func reply(req *dns.Msg, rcode int, answer []dns.RR, ns ...dns.RR) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Rcode = rcode
+	m.Answer = answer
+	m.Ns = ns
+	return m
+}
+
+func withTTL(rrs []dns.RR, ttl uint32) []dns.RR {
+	for _, rr := range rrs {
+		rr.Header().Ttl = ttl
+	}
+	return rrs
+}
+
+func TestResponseCache(t *testing.T) {
+	now := time.Now()
+	c := newResponseCache(10)
+	c.now = func() time.Time { return now }
+	query := func(name string, qtype uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(name, qtype)
+		return m
+	}
+
+	cases := []struct {
+		name     string
+		req      *dns.Msg
+		response func(req *dns.Msg) *dns.Msg
+		// wantTTL is how long the response is cached; zero means it is not cached.
+		wantTTL      time.Duration
+		wantNegative bool
+	}{
+		{
+			name: "positive response uses minimum TTL",
+			req:  query("example.com.", dns.TypeA),
+			response: func(req *dns.Msg) *dns.Msg {
+				return reply(req, dns.RcodeSuccess, append(
+					withTTL(a("example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 60),
+					withTTL(a("example.com.", []netip.Addr{netip.MustParseAddr("2.2.2.2")}), 20)...))
+			},
+			wantTTL: 20 * time.Second,
+		},
+		{
+			name: "NXDOMAIN uses SOA minimum",
+			req:  query("missing.example.com.", dns.TypeA),
+			response: func(req *dns.Msg) *dns.Msg {
+				return reply(req, dns.RcodeNameError, nil, soa("example.com.", 300, 30))
+			},
+			wantTTL:      30 * time.Second,
+			wantNegative: true,
+		},
+		{
+			name: "NODATA uses SOA TTL",
+			req:  query("example.com.", dns.TypeAAAA),
+			response: func(req *dns.Msg) *dns.Msg {
+				return reply(req, dns.RcodeSuccess, nil, soa("example.com.", 10, 30))
+			},
+			wantTTL:      10 * time.Second,
+			wantNegative: true,
+		},
+		{
+			name: "NXDOMAIN without SOA",
+			req:  query("nosoa.example.com.", dns.TypeA),
+			response: func(req *dns.Msg) *dns.Msg {
+				return reply(req, dns.RcodeNameError, nil)
+			},
+		},
+		{
+			name: "SERVFAIL",
+			req:  query("servfail.example.com.", dns.TypeA),
+			response: func(req *dns.Msg) *dns.Msg {
+				return reply(req, dns.RcodeServerFailure, nil, soa("example.com.", 300, 300))
+			},
+		},
+		{
+			name: "truncated",
+			req:  query("truncated.example.com.", dns.TypeA),
+			response: func(req *dns.Msg) *dns.Msg {
+				m := reply(req, dns.RcodeSuccess, a("truncated.example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}))
+				m.Truncated = true
+				return m
+			},
+		},
+		{
+			name: "TTL is capped",
+			req:  query("long.example.com.", dns.TypeA),
+			response: func(req *dns.Msg) *dns.Msg {
+				return reply(req, dns.RcodeSuccess, withTTL(a("long.example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 86400))
+			},
+			wantTTL: maxCacheTTL,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := now
+			t.Cleanup(func() { now = start })
+			c.add(tc.req, tc.response(tc.req), "upstream:53")
+
+			// Clients may randomize the case of the name, and use another ID.
+			again := tc.req.Copy()
+			again.Id++
+			again.Question[0].Name = strings.ToUpper(again.Question[0].Name[:1]) + again.Question[0].Name[1:]
+			got, entry := c.get(again)
+			if tc.wantTTL == 0 {
+				if got != nil {
+					t.Fatalf("expected response not to be cached, got %v", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("expected response to be cached")
+			}
+			if got.Id != again.Id || got.Question[0].Name != again.Question[0].Name {
+				t.Errorf("expected reply to match the request, got %v", got)
+			}
+			if entry.negative != tc.wantNegative || entry.upstream != "upstream:53" {
+				t.Errorf("unexpected cache entry %+v", entry)
+			}
+
+			// TTLs are decremented by the time spent in the cache.
+			elapsed := tc.wantTTL - time.Second
+			now = now.Add(elapsed)
+			got, _ = c.get(again)
+			if got == nil {
+				t.Fatalf("expected response to be cached until it expires")
+			}
+			orig := tc.response(tc.req)
+			want := append(orig.Answer, orig.Ns...)
+			for i, rr := range append(got.Answer, got.Ns...) {
+				if wantTTL := want[i].Header().Ttl - uint32(elapsed/time.Second); rr.Header().Ttl != wantTTL {
+					t.Errorf("expected TTL %d, got %v", wantTTL, rr)
+				}
+			}
+
+			now = now.Add(time.Second)
+			if got, _ := c.get(again); got != nil {
+				t.Errorf("expected response to expire, got %v", got)
+			}
+		})
+	}
+}
+
+func TestResponseCacheEDNS(t *testing.T) {
+	c := newResponseCache(10)
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeA)
+	c.add(req, reply(req, dns.RcodeSuccess, a("example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")})), "upstream:53")
+
+	edns := req.Copy()
+	edns.SetEdns0(1232, true)
+	if got, _ := c.get(edns); got != nil {
+		t.Errorf("expected a response to a request without EDNS not to be used for a request with EDNS")
+	}
+	if got, _ := c.get(req); got == nil {
+		t.Errorf("expected cached response")
+	}
+}
+
+// makeCountingUpstream starts an upstream that answers all A queries with 1.1.1.1, and NXDOMAIN for names starting
+// with "missing".
+func makeCountingUpstream(t *testing.T) (string, *atomic.Int32) {
+	queries := atomic.NewInt32(0)
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		queries.Inc()
+		name := req.Question[0].Name
+		if strings.HasPrefix(name, "missing") {
+			_ = w.WriteMsg(reply(req, dns.RcodeNameError, nil, soa("example.com.", 60, 60)))
+			return
+		}
+		_ = w.WriteMsg(reply(req, dns.RcodeSuccess, withTTL(a(name, []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 60)))
+	})}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return pc.LocalAddr().String(), queries
+}
+
+func TestDNSUpstreamCache(t *testing.T) {
+	upstream, queries := makeCountingUpstream(t)
+	d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", false, 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.resolvConfServers = []string{upstream}
+	d.StartDNS()
+	fillTable(d)
+	t.Cleanup(d.Close)
+
+	c := dns.Client{Timeout: 3 * time.Second}
+	resolve := func(name string, wantRcode int) {
+		t.Helper()
+		m := new(dns.Msg)
+		m.SetQuestion(name, dns.TypeA)
+		res, _, err := c.Exchange(m, d.dnsProxies[0].Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Rcode != wantRcode {
+			t.Fatalf("got rcode %v for %s, want %v", res.Rcode, name, wantRcode)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		resolve("www.bing.com.", dns.RcodeSuccess)
+		// Names with bad ndots settings usually end up as NXDOMAIN
+		resolve("missing.ns1.svc.cluster.local.svc.cluster.local.", dns.RcodeNameError)
+	}
+	if got := queries.Load(); got != 2 {
+		t.Errorf("expected upstream to be queried once per name, got %d queries", got)
+	}
+	// Names in the name table never reach the upstream or cache
+	resolve("productpage.ns1.svc.cluster.local.", dns.RcodeSuccess)
+	if got := queries.Load(); got != 2 {
+		t.Errorf("expected no upstream query, got %d queries", got)
+	}
+}
@@ -16,6 +16,7 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/netip"
@@ -59,6 +60,11 @@ type LocalDNSServer struct {
 
 	respondBeforeSync         bool
 	forwardToUpstreamParallel bool
+
+	// cache holds upstream responses. It is nil if caching is disabled.
+	cache *responseCache
+	// upstreamTLSConfig is set if the upstream servers use DNS-over-TLS.
+	upstreamTLSConfig *tls.Config
 }
 
 // LookupTable is borrowed from https://github.com/coredns/coredns/blob/master/plugin/hosts/hostsfile.go
@@ -87,11 +93,19 @@ const (
 	defaultTTLInSeconds = 30
 )
 
-func NewLocalDNSServer(proxyNamespace, proxyDomain string, addr string, forwardToUpstreamParallel bool) (*LocalDNSServer, error) {
+// NewLocalDNSServer creates a DNS server answering queries for names in the NameTable, and forwarding others to the
+// upstream servers from resolv.conf, or to the DNS-over-TLS servers in upstreamTLS if set.
+// If cacheSize is positive, up to that many upstream responses are cached.
+func NewLocalDNSServer(proxyNamespace, proxyDomain string, addr string, forwardToUpstreamParallel bool,
+	cacheSize int, upstreamTLS *UpstreamTLS,
+) (*LocalDNSServer, error) {
 	h := &LocalDNSServer{
 		proxyNamespace:            proxyNamespace,
 		forwardToUpstreamParallel: forwardToUpstreamParallel,
 	}
+	if cacheSize > 0 {
+		h.cache = newResponseCache(cacheSize)
+	}
 
 	// proxyDomain could contain the namespace making it redundant.
 	// we just need the .svc.cluster.local piece
@@ -141,6 +155,12 @@ func NewLocalDNSServer(proxyNamespace, proxyDomain string, addr string, forwardT
 		}
 		h.searchNamespaces = dnsConfig.Search
 	}
+	if upstreamTLS != nil && len(upstreamTLS.Servers) > 0 {
+		if h.upstreamTLSConfig, err = upstreamTLS.tlsConfig(); err != nil {
+			return nil, err
+		}
+		h.resolvConfServers = upstreamTLS.servers()
+	}
 
 	log.WithLabels("search", h.searchNamespaces, "servers", h.resolvConfServers).Debugf("initialized DNS")
 
@@ -226,15 +246,32 @@ func (h *LocalDNSServer) BuildAlternateHosts(nt *dnsProto.NameTable,
 	}
 }
 
-// upstream sends the request to the upstream server, with associated logs and metrics
+// upstream sends the request to the upstream server, with associated logs and metrics.
+// If caching is enabled, a cached response from a previous request is returned instead, if there is one.
 func (h *LocalDNSServer) upstream(proxy *dnsProxy, req *dns.Msg, hostname string) *dns.Msg {
+	useCache := h.cache != nil && cacheable(req)
+	if useCache {
+		if response, entry := h.cache.get(req); response != nil {
+			result := cacheHit
+			if entry.negative {
+				result = cacheNegativeHit
+			}
+			cacheLookups.With(resultLabel.Value(result), upstreamLabel.Value(entry.upstream)).Increment()
+			log.Debugf("cached upstream response for hostname %q : %v", hostname, response)
+			return response
+		}
+	}
 	upstreamRequests.Increment()
 	start := time.Now()
 	// We did not find the host in our internal cache. Query upstream and return the response as is.
 	log.Debugf("response for hostname %q not found in dns proxy, querying upstream", hostname)
-	response := h.queryUpstream(proxy.upstreamClient, req, log)
+	response, upstream := h.queryUpstream(proxy.upstreamClient, req, log)
 	requestDuration.Record(time.Since(start).Seconds())
 	log.Debugf("upstream response for hostname %q : %v", hostname, response)
+	if useCache {
+		cacheLookups.With(resultLabel.Value(cacheMiss), upstreamLabel.Value(upstream)).Increment()
+		h.cache.add(req, response, upstream)
+	}
 	return response
 }
 
@@ -389,27 +426,35 @@ func (h *LocalDNSServer) Close() {
 	}
 }
 
-func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg, scope *istiolog.Scope) *dns.Msg {
+// queryUpstream sends the request to the upstream servers, and returns the response along with the upstream server
+// that answered. If all servers fail, a SERVFAIL response is returned without an upstream.
+func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg, scope *istiolog.Scope) (*dns.Msg, string) {
 	if h.forwardToUpstreamParallel {
 		return h.queryUpstreamParallel(upstreamClient, req, scope)
 	}
 
-	var response *dns.Msg
 	servers := slices.Clone(h.resolvConfServers)
 	roundRobinShuffle(servers)
 	for _, upstream := range servers {
-		cResponse, _, err := upstreamClient.Exchange(req, upstream)
+		cResponse, err := exchange(context.Background(), upstreamClient, req, upstream)
 		if err == nil {
-			response = cResponse
-			break
+			return cResponse, upstream
 		}
 		scope.Infof("upstream failure: %v", err)
 	}
+	return serverFailure(req), ""
+}
 
-	if response == nil {
-		response = serverFailure(req)
+// exchange sends the request to a single upstream server, recording per upstream metrics.
+func exchange(ctx context.Context, upstreamClient *dns.Client, req *dns.Msg, upstream string) (*dns.Msg, error) {
+	start := time.Now()
+	response, _, err := upstreamClient.ExchangeContext(ctx, req, upstream)
+	if err != nil {
+		upstreamQueryFailures.With(upstreamLabel.Value(upstream)).Increment()
+		return nil, err
 	}
-	return response
+	upstreamQueryDuration.With(upstreamLabel.Value(upstream)).Record(time.Since(start).Seconds())
+	return response, nil
 }
 
 // queryUpstreamParallel will send parallel queries to all nameservers and return first successful response immediately.
@@ -422,21 +467,25 @@ func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg,
 //     response—or defer to the operating system, which we have no control over.
 //   - systemd-resolved: which is used as a default resolver in many Linux distributions nowadays also performs parallel
 //     lookups for multiple DNS servers and returns the first successful response.
-func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *dns.Msg, scope *istiolog.Scope) *dns.Msg {
+func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *dns.Msg, scope *istiolog.Scope) (*dns.Msg, string) {
 	// Guarantee that the ctx we use below is done when this function returns.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	responseCh := make(chan *dns.Msg)
+	type upstreamResponse struct {
+		msg      *dns.Msg
+		upstream string
+	}
+	responseCh := make(chan upstreamResponse)
 	errCh := make(chan error)
 
 	queryOne := func(upstream string) {
 		// Note: After DialContext in ExchangeContext is called, this function cannot be cancelled by context.
-		cResponse, _, err := upstreamClient.ExchangeContext(ctx, req, upstream)
+		cResponse, err := exchange(ctx, upstreamClient, req, upstream)
 		if err == nil {
 			// Only reserve first response and ignore others.
 			select {
-			case responseCh <- cResponse:
+			case responseCh <- upstreamResponse{msg: cResponse, upstream: upstream}:
 			case <-ctx.Done():
 			}
 			return
@@ -457,13 +506,13 @@ func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *
 		select {
 		case response := <-responseCh:
 			// We got the first response.
-			return response
+			return response.msg, response.upstream
 		case <-errCh:
 			errorsCount++
 			// All servers returned error - return failure.
 			if errorsCount == len(h.resolvConfServers) {
 				scope.Infof("all upstream failed")
-				return serverFailure(req)
+				return serverFailure(req), ""
 			}
 		}
 	}
@@ -19,6 +19,9 @@ import (
 )
 
 var (
+	upstreamLabel = monitoring.CreateLabel("upstream")
+	resultLabel   = monitoring.CreateLabel("result")
+
 	requests = monitoring.NewSum(
 		"dns_requests_total",
 		"Total number of DNS requests.",
@@ -39,4 +42,26 @@ var (
 		"Total time in seconds Istio takes to get DNS response from upstream.",
 		[]float64{.001, .005, 0.01, 0.1, 1, 5},
 	)
+
+	upstreamQueryDuration = monitoring.NewDistribution(
+		"dns_upstream_query_duration_seconds",
+		"Time in seconds taken by an upstream server to respond to a DNS query.",
+		[]float64{.001, .005, 0.01, 0.1, 1, 5},
+	)
+
+	upstreamQueryFailures = monitoring.NewSum(
+		"dns_upstream_query_failures_total",
+		"Total number of DNS queries to an upstream server that failed.",
+	)
+
+	cacheLookups = monitoring.NewSum(
+		"dns_cache_lookups_total",
+		"Total number of lookups of upstream DNS responses in the cache, by result and the upstream that answered.",
+	)
+)
+
+const (
+	cacheHit         = "hit"
+	cacheNegativeHit = "negative_hit"
+	cacheMiss        = "miss"
 )
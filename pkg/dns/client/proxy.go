@@ -45,6 +45,11 @@ func newDNSProxy(protocol, addr string, resolver *LocalDNSServer) (*dnsProxy, er
 		protocol: protocol,
 		resolver: resolver,
 	}
+	if resolver.upstreamTLSConfig != nil {
+		// Queries from both UDP and TCP clients are forwarded over TLS.
+		p.upstreamClient.Net = "tcp-tls"
+		p.upstreamClient.TLSConfig = resolver.upstreamTLSConfig
+	}
 
 	var err error
 	p.serveMux.Handle(".", p)
@@ -0,0 +1,70 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+)
+
+// defaultDoTPort is the port of DNS-over-TLS servers (RFC 7858) when none is specified.
+const defaultDoTPort = "853"
+
+// UpstreamTLS configures the DNS proxy to forward queries to DNS-over-TLS (RFC 7858) servers, instead of the
+// servers in resolv.conf.
+type UpstreamTLS struct {
+	// Servers are the addresses of the DNS-over-TLS servers, as host or host:port. The port defaults to 853.
+	Servers []string
+	// CACertFile is the path to the PEM encoded CA certificates used to verify the servers. If unset, the system
+	// roots are used.
+	CACertFile string
+	// ServerName is the name used to verify the server certificates. If unset, the host of the server address is used.
+	ServerName string
+}
+
+// servers returns the upstream addresses, with the default port added where it is missing.
+func (u *UpstreamTLS) servers() []string {
+	res := make([]string, 0, len(u.Servers))
+	for _, s := range u.Servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, defaultDoTPort)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// tlsConfig builds the client TLS configuration used to connect to the servers.
+func (u *UpstreamTLS) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: u.ServerName,
+	}
+	if u.CACertFile != "" {
+		ca, err := os.ReadFile(u.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DNS upstream CA certificates: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse DNS upstream CA certificates %s", u.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
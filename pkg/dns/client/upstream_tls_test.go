@@ -0,0 +1,193 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// newTestCert creates a self-signed certificate for 127.0.0.1, and writes it to a file.
+func newTestCert(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dns.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"dns.test"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certFile
+}
+
+// makeDoTUpstream starts a DNS-over-TLS server answering A queries with 1.1.1.1. EDNS0 options of the request are
+// echoed in the response.
+func makeDoTUpstream(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{Listener: l, Net: "tcp-tls", Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := reply(req, dns.RcodeSuccess, a(req.Question[0].Name, []netip.Addr{netip.MustParseAddr("1.1.1.1")}))
+		if opt := req.IsEdns0(); opt != nil {
+			resp.SetEdns0(opt.UDPSize(), opt.Do())
+			resp.IsEdns0().Option = opt.Option
+		}
+		_ = w.WriteMsg(resp)
+	})}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return l.Addr().String()
+}
+
+func TestUpstreamTLSServers(t *testing.T) {
+	u := &UpstreamTLS{Servers: []string{"1.1.1.1", "dns.example.com:8853", "2606:4700::1111"}}
+	want := []string{"1.1.1.1:853", "dns.example.com:8853", "[2606:4700::1111]:853"}
+	if got := u.servers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDNSUpstreamTLS(t *testing.T) {
+	cert, caFile := newTestCert(t)
+	upstream := makeDoTUpstream(t, cert)
+	_, otherCAFile := newTestCert(t)
+
+	cases := []struct {
+		name      string
+		tls       *UpstreamTLS
+		wantRcode int
+	}{
+		{
+			name:      "verified",
+			tls:       &UpstreamTLS{Servers: []string{upstream}, CACertFile: caFile},
+			wantRcode: dns.RcodeSuccess,
+		},
+		{
+			name:      "verified with server name",
+			tls:       &UpstreamTLS{Servers: []string{upstream}, CACertFile: caFile, ServerName: "dns.test"},
+			wantRcode: dns.RcodeSuccess,
+		},
+		{
+			name:      "untrusted CA",
+			tls:       &UpstreamTLS{Servers: []string{upstream}, CACertFile: otherCAFile},
+			wantRcode: dns.RcodeServerFailure,
+		},
+		{
+			name:      "server name mismatch",
+			tls:       &UpstreamTLS{Servers: []string{upstream}, CACertFile: caFile, ServerName: "other.test"},
+			wantRcode: dns.RcodeServerFailure,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", false, 0, tc.tls)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.StartDNS()
+			fillTable(d)
+			t.Cleanup(d.Close)
+
+			for _, proxy := range d.dnsProxies {
+				req := new(dns.Msg)
+				req.SetQuestion("www.bing.com.", dns.TypeA)
+				req.SetEdns0(1232, false)
+				subnet := &dns.EDNS0_SUBNET{
+					Code:          dns.EDNS0SUBNET,
+					Family:        1,
+					SourceNetmask: 24,
+					Address:       net.ParseIP("192.0.2.0").To4(),
+				}
+				req.IsEdns0().Option = append(req.IsEdns0().Option, subnet)
+
+				c := dns.Client{Net: proxy.protocol, Timeout: 3 * time.Second}
+				res, _, err := c.Exchange(req, proxy.Address())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Rcode != tc.wantRcode {
+					t.Fatalf("%s: got rcode %v, want %v", proxy.protocol, res.Rcode, tc.wantRcode)
+				}
+				if tc.wantRcode != dns.RcodeSuccess {
+					continue
+				}
+				if len(res.Answer) != 1 {
+					t.Fatalf("%s: expected one answer, got %v", proxy.protocol, res.Answer)
+				}
+				opt := res.IsEdns0()
+				if opt == nil || len(opt.Option) != 1 || opt.Option[0].String() != subnet.String() {
+					t.Errorf("%s: expected client subnet to be passed through, got %v", proxy.protocol, opt)
+				}
+			}
+		})
+	}
+
+	if _, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", false, 0,
+		&UpstreamTLS{Servers: []string{upstream}, CACertFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Errorf("expected error for missing CA file")
+	}
+}
+
+func TestCacheableClientSubnet(t *testing.T) {
+	req := new(dns.Msg)
+	req.SetQuestion("www.bing.com.", dns.TypeA)
+	if !cacheable(req) {
+		t.Errorf("expected request without EDNS to be cacheable")
+	}
+	req.SetEdns0(1232, false)
+	if !cacheable(req) {
+		t.Errorf("expected request with EDNS to be cacheable")
+	}
+	req.IsEdns0().Option = append(req.IsEdns0().Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1})
+	if cacheable(req) {
+		t.Errorf("expected request with client subnet not to be cacheable")
+	}
+}
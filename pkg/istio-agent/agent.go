@@ -166,6 +166,12 @@ type AgentOptions struct {
 	DNSAddr string
 	// DNSForwardParallel indicates whether the agent should send parallel DNS queries to all upstream nameservers.
 	DNSForwardParallel bool
+
+	// DNSCacheSize is the maximum number of upstream DNS responses cached by the agent. Zero disables caching.
+	DNSCacheSize int
+
+	// DNSUpstreamTLS, if set, configures the agent to forward DNS queries to DNS-over-TLS servers.
+	DNSUpstreamTLS *dnsClient.UpstreamTLS
 	// ProxyType is the type of proxy we are configured to handle
 	ProxyType model.NodeType
 	// ProxyNamespace to use for local dns resolution
@@ -540,7 +546,7 @@ func (a *Agent) startFileWatcher(ctx context.Context, filePath string, handler f
 func (a *Agent) initLocalDNSServer() (err error) {
 	if a.isDNSServerEnabled() {
 		if a.localDNSServer, err = dnsClient.NewLocalDNSServer(a.cfg.ProxyNamespace, a.cfg.ProxyDomain, a.cfg.DNSAddr,
-			a.cfg.DNSForwardParallel); err != nil {
+			a.cfg.DNSForwardParallel, a.cfg.DNSCacheSize, a.cfg.DNSUpstreamTLS); err != nil {
 			return err
 		}
 		a.localDNSServer.StartDNS()
@@ -17,6 +17,7 @@ package krt
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"istio.io/istio/pkg/kube/controllers"
 	"istio.io/istio/pkg/kube/kclient"
@@ -57,6 +58,8 @@ type manyCollection[I, O any] struct {
 	// These are keyed by the internal uid() function on collections.
 	// Note this does not include `parent`, which is the *primary* dependency declared outside of transformation functions.
 	collectionDependencies sets.Set[collectionUID]
+	// dependencyNames stores the names of the collections in collectionDependencies, for debugging.
+	dependencyNames sets.String
 	// Stores a map of I -> secondary dependencies (added via Fetch)
 	objectDependencies map[Key[I]][]*dependency
 	// internal indexes
@@ -71,6 +74,11 @@ type manyCollection[I, O any] struct {
 	augmentation func(a any) any
 	synced       chan struct{}
 	stop         <-chan struct{}
+
+	// restoredFromSnapshot is set if the initial state was restored from a snapshot. See WithSnapshot.
+	restoredFromSnapshot bool
+
+	metrics *collectionMetrics
 }
 
 type collectionIndex[I, O any] struct {
@@ -152,6 +160,10 @@ type multiIndex[I, O any] struct {
 }
 
 func (h *manyCollection[I, O]) Synced() Syncer {
+	if h.restoredFromSnapshot {
+		// We are serving the snapshot state until our inputs are synced, at which point it will be reconciled.
+		return alwaysSynced{}
+	}
 	return channelSyncer{
 		name:   h.collectionName,
 		synced: h.synced,
@@ -192,9 +204,91 @@ func (h *manyCollection[I, O]) dump() CollectionDump {
 		Outputs:         eraseMap(h.collectionState.outputs),
 		Inputs:          inputs,
 		InputCollection: h.parent.(internalCollection[I]).name(),
+		Status:          h.status(),
+	}
+}
+
+func (h *manyCollection[I, O]) status() string {
+	select {
+	case <-h.synced:
+		return collectionStatusSynced
+	default:
+	}
+	if h.restoredFromSnapshot {
+		return collectionStatusServingFromSnapshot
+	}
+	return collectionStatusSyncing
+}
+
+// restoreSnapshot populates the collection from a previous snapshot, if there is one, and registers the collection
+// to be included in future snapshots.
+func (h *manyCollection[I, O]) restoreSnapshot(store *SnapshotStore) {
+	store.register(h.collectionName, snapshotCollection{
+		outputs: func() (any, bool) {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			return snapshotFile[O]{Outputs: maps.Clone(h.collectionState.outputs)}, h.status() == collectionStatusSynced
+		},
+	})
+	outputs, ok := loadSnapshot[O](store, h.collectionName)
+	if !ok {
+		return
+	}
+	h.collectionState.outputs = outputs
+	h.restoredFromSnapshot = true
+	// Consider ourselves initialized, so handlers registered before the initial sync are sent the restored state.
+	h.eventHandlers.MarkInitialized()
+	h.log.WithLabels("items", len(outputs)).Infof("restored from snapshot")
+}
+
+// reconcileSnapshot removes any outputs restored from a snapshot that were not recomputed from the initial state of
+// our inputs. Outputs that were recomputed have already been compared against the snapshot state during the initial sync.
+// This should be called with recomputeMu acquired.
+func (h *manyCollection[I, O]) reconcileSnapshot() {
+	h.mu.Lock()
+	live := sets.New[Key[O]]()
+	for _, oKeys := range h.collectionState.mappings {
+		live.Merge(oKeys)
+	}
+	var events []Event[O]
+	for oKey, oldRes := range h.collectionState.outputs {
+		if live.Contains(oKey) {
+			continue
+		}
+		events = append(events, Event[O]{
+			Event: controllers.EventDelete,
+			Old:   &oldRes,
+		})
+		delete(h.collectionState.outputs, oKey)
+		for _, index := range h.indexes {
+			index.delete(oldRes, oKey)
+		}
+	}
+	h.mu.Unlock()
+
+	h.log.WithLabels("stale", len(events)).Infof("reconciled snapshot")
+	if len(events) == 0 {
+		return
+	}
+	handlers := h.eventHandlers.Get()
+	h.metrics.emitted(len(events), len(handlers))
+	for _, handler := range handlers {
+		handler(slices.Clone(events), false)
 	}
 }
 
+// nolint: unused // (not true, its to implement an interface)
+func (h *manyCollection[I, O]) dependencies() (primary string, secondary []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.parent.(internalCollection[I]).name(), sets.SortedList(h.dependencyNames)
+}
+
+// nolint: unused // (not true, its to implement an interface)
+func (h *manyCollection[I, O]) stats() *collectionMetrics {
+	return h.metrics
+}
+
 // nolint: unused // (not true, its to implement an interface)
 func (h *manyCollection[I, O]) augment(a any) any {
 	if h.augmentation != nil {
@@ -265,7 +359,9 @@ func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
 		iKey := GetKey(i)
 
 		ctx := &collectionDependencyTracker[I, O]{h, nil, iKey}
+		t0 := time.Now()
 		results := slices.GroupUnique(h.transformation(ctx, i), GetKey[O])
+		h.metrics.recomputed(t0)
 		recomputedResults[idx] = results
 		// Update the I -> Dependency mapping
 		h.objectDependencies[iKey] = ctx.d
@@ -351,6 +447,7 @@ func (h *manyCollection[I, O]) onPrimaryInputEventLocked(items []Event[I]) {
 		return
 	}
 	handlers := h.eventHandlers.Get()
+	h.metrics.emitted(len(events), len(handlers))
 
 	if h.log.DebugEnabled() {
 		h.log.WithLabels("events", len(events), "handlers", len(handlers)).Debugf("calling handlers")
@@ -436,6 +533,7 @@ func newManyCollection[I, O any](cc Collection[I], hf TransformationMulti[I, O],
 		log:                    log.WithLabels("owner", opts.name),
 		parent:                 c,
 		collectionDependencies: sets.New[collectionUID](),
+		dependencyNames:        sets.New[string](),
 		objectDependencies:     map[Key[I]][]*dependency{},
 		collectionState: multiIndex[I, O]{
 			inputs:   map[Key[I]]I{},
@@ -446,6 +544,10 @@ func newManyCollection[I, O any](cc Collection[I], hf TransformationMulti[I, O],
 		augmentation:  opts.augmentation,
 		synced:        make(chan struct{}),
 		stop:          opts.stop,
+		metrics:       newCollectionMetrics(opts.name),
+	}
+	if opts.snapshot != nil {
+		h.restoreSnapshot(opts.snapshot)
 	}
 	maybeRegisterCollectionForDebugging(h, opts.debugger)
 	go func() {
@@ -474,6 +576,9 @@ func newManyCollection[I, O any](cc Collection[I], hf TransformationMulti[I, O],
 			h.recomputeMu.Unlock()
 			return
 		}
+		if h.restoredFromSnapshot {
+			h.reconcileSnapshot()
+		}
 		h.recomputeMu.Unlock()
 		close(h.synced)
 		h.log.Infof("%v synced", h.name())
@@ -651,6 +756,9 @@ func (i *collectionDependencyTracker[I, O]) registerDependency(
 	// For any new collections we depend on, start watching them if its the first time we have watched them.
 	if !i.collectionDependencies.InsertContains(d.id) {
 		i.log.WithLabels("collection", d.collectionName).Debugf("register new dependency")
+		i.mu.Lock()
+		i.dependencyNames.Insert(d.collectionName)
+		i.mu.Unlock()
 		syncer.WaitUntilSynced(i.stop)
 		register(func(o []Event[any], initialSync bool) {
 			i.onSecondaryDependencyEvent(d.id, o)
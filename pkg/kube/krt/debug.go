@@ -16,6 +16,8 @@ package krt
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -40,6 +42,8 @@ type CollectionDump struct {
 	InputCollection string `json:"inputCollection,omitempty"`
 	// Map of input key -> info
 	Inputs map[string]InputDump `json:"inputs,omitempty"`
+	// Status of the collection: syncing, serving-from-snapshot, or synced
+	Status string `json:"status,omitempty"`
 }
 type InputDump struct {
 	Outputs      []string `json:"outputs,omitempty"`
@@ -48,6 +52,90 @@ type InputDump struct {
 type DebugCollection struct {
 	name string
 	dump func() CollectionDump
+	// graph is set for collections that derive from other collections
+	graph graphCollection
+}
+
+// graphCollection is implemented by collections that can report their dependencies.
+type graphCollection interface {
+	// dependencies returns the name of the primary input collection, and of all collections fetched by the transformation.
+	dependencies() (primary string, secondary []string)
+	stats() *collectionMetrics
+}
+
+const (
+	// DependencyPrimary is an edge from the input collection of a transformation.
+	DependencyPrimary = "primary"
+	// DependencyFetch is an edge from a collection read with Fetch during a transformation.
+	DependencyFetch = "fetch"
+)
+
+// DependencyGraph describes the collections registered with a DebugHandler, and the dependencies between them.
+type DependencyGraph struct {
+	Collections []GraphCollection `json:"collections"`
+	Edges       []GraphEdge       `json:"edges"`
+}
+
+// GraphCollection is a node in the DependencyGraph.
+type GraphCollection struct {
+	Name string `json:"name"`
+	// Recomputes is the number of times the transformation function ran
+	Recomputes uint64 `json:"recomputes,omitempty"`
+	// Events is the number of events the collection produced
+	Events uint64 `json:"events,omitempty"`
+	// HandlerEvents is the number of events delivered to handlers, so the fan-out of the collection's events
+	HandlerEvents uint64 `json:"handlerEvents,omitempty"`
+}
+
+// GraphEdge is a dependency of To on From. Events flow from From to To.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is one of DependencyPrimary or DependencyFetch
+	Type string `json:"type"`
+}
+
+// Graph builds the DependencyGraph of all registered collections.
+// Dependencies added by Fetch are only known once the transformation that fetches them has run.
+func (p *DebugHandler) Graph() DependencyGraph {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	g := DependencyGraph{Collections: []GraphCollection{}, Edges: []GraphEdge{}}
+	for _, c := range p.debugCollections {
+		node := GraphCollection{Name: c.name}
+		if c.graph != nil {
+			m := c.graph.stats()
+			node.Recomputes = m.recomputeCount.Load()
+			node.Events = m.eventCount.Load()
+			node.HandlerEvents = m.handlerEventCount.Load()
+			primary, secondary := c.graph.dependencies()
+			g.Edges = append(g.Edges, GraphEdge{From: primary, To: c.name, Type: DependencyPrimary})
+			for _, dep := range secondary {
+				g.Edges = append(g.Edges, GraphEdge{From: dep, To: c.name, Type: DependencyFetch})
+			}
+		}
+		g.Collections = append(g.Collections, node)
+	}
+	return g
+}
+
+// DOT renders the graph in the Graphviz DOT language. Fetch dependencies are drawn as dashed edges.
+func (g DependencyGraph) DOT() string {
+	sb := strings.Builder{}
+	sb.WriteString("digraph krt {\n")
+	for _, c := range g.Collections {
+		fmt.Fprintf(&sb, "  %q [label=%q];\n", c.Name,
+			fmt.Sprintf("%s\nrecomputes=%d events=%d handlerEvents=%d", c.Name, c.Recomputes, c.Events, c.HandlerEvents))
+	}
+	for _, e := range g.Edges {
+		if e.Type == DependencyFetch {
+			fmt.Fprintf(&sb, "  %q -> %q [style=dashed];\n", e.From, e.To)
+		} else {
+			fmt.Fprintf(&sb, "  %q -> %q;\n", e.From, e.To)
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
 }
 
 func (p DebugCollection) MarshalJSON() ([]byte, error) {
@@ -65,9 +153,11 @@ func maybeRegisterCollectionForDebugging[T any](c Collection[T], handler *DebugH
 	cc := c.(internalCollection[T])
 	handler.mu.Lock()
 	defer handler.mu.Unlock()
+	gc, _ := c.(graphCollection)
 	handler.debugCollections = append(handler.debugCollections, DebugCollection{
-		name: cc.name(),
-		dump: cc.dump,
+		name:  cc.name(),
+		dump:  cc.dump,
+		graph: gc,
 	})
 }
 
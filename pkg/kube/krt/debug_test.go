@@ -0,0 +1,102 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt_test
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/kube/kclient/clienttest"
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	stop := test.NewStop(t)
+	c := kube.NewFakeClient(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "namespace", Labels: map[string]string{"app": "foo"}},
+			Status:     corev1.PodStatus{PodIP: "1.2.3.4"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "namespace"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "foo"}},
+		},
+	)
+	debugger := &krt.DebugHandler{}
+	opts := func(name string) []krt.CollectionOption {
+		return []krt.CollectionOption{krt.WithName(name), krt.WithDebugging(debugger), krt.WithStop(stop)}
+	}
+	pods := krt.NewInformer[*corev1.Pod](c, opts("Pods")...)
+	services := krt.NewInformer[*corev1.Service](c, opts("Services")...)
+	c.RunAndWait(stop)
+	SimplePods := krt.NewCollection(pods, func(ctx krt.HandlerContext, i *corev1.Pod) *SimplePod {
+		return &SimplePod{Named: NewNamed(i), Labeled: NewLabeled(i.Labels), IP: i.Status.PodIP}
+	}, opts("SimplePods")...)
+	SimpleServices := krt.NewCollection(services, func(ctx krt.HandlerContext, i *corev1.Service) *SimpleService {
+		return &SimpleService{Named: NewNamed(i), Selector: i.Spec.Selector}
+	}, opts("SimpleServices")...)
+	SimpleEndpoints := krt.NewManyCollection(SimpleServices, func(ctx krt.HandlerContext, svc SimpleService) []SimpleEndpoint {
+		pods := krt.Fetch(ctx, SimplePods, krt.FilterLabel(svc.Selector))
+		return slices.Map(pods, func(pod SimplePod) SimpleEndpoint {
+			return SimpleEndpoint{Pod: pod.Name, Service: svc.Name, Namespace: svc.Namespace, IP: pod.IP}
+		})
+	}, opts("SimpleEndpoints")...)
+	assert.Equal(t, SimpleEndpoints.Synced().WaitUntilSynced(stop), true)
+	tt := assert.NewTracker[string](t)
+	SimpleEndpoints.Register(TrackerHandler[SimpleEndpoint](tt))
+	tt.WaitOrdered("add/namespace/svc/pod")
+
+	pc := clienttest.Wrap(t, kclient.New[*corev1.Pod](c))
+	pc.UpdateStatus(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "namespace", Labels: map[string]string{"app": "foo"}},
+		Status:     corev1.PodStatus{PodIP: "1.2.3.5"},
+	})
+	tt.WaitOrdered("update/namespace/svc/pod")
+
+	g := debugger.Graph()
+	assert.Equal(t, g.Edges, []krt.GraphEdge{
+		{From: "Pods", To: "SimplePods", Type: krt.DependencyPrimary},
+		{From: "Services", To: "SimpleServices", Type: krt.DependencyPrimary},
+		{From: "SimpleServices", To: "SimpleEndpoints", Type: krt.DependencyPrimary},
+		{From: "SimplePods", To: "SimpleEndpoints", Type: krt.DependencyFetch},
+	})
+	nodes := map[string]krt.GraphCollection{}
+	for _, n := range g.Collections {
+		nodes[n.Name] = n
+	}
+	assert.Equal(t, len(nodes), 5)
+	// Initial computation, then a recompute triggered by the pod change
+	assert.Equal(t, nodes["SimplePods"].Recomputes, uint64(2))
+	assert.Equal(t, nodes["SimpleEndpoints"].Recomputes, uint64(2))
+	assert.Equal(t, nodes["SimpleServices"].Recomputes, uint64(1))
+	// The add is computed before any handler is registered; the update is delivered to the single handler
+	assert.Equal(t, nodes["SimpleEndpoints"].Events, uint64(2))
+	assert.Equal(t, nodes["SimpleEndpoints"].HandlerEvents, uint64(1))
+
+	dot := g.DOT()
+	for _, want := range []string{`"SimpleServices" -> "SimpleEndpoints";`, `"SimplePods" -> "SimpleEndpoints" [style=dashed];`} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("expected %q in:\n%s", want, dot)
+		}
+	}
+}
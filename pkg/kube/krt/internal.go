@@ -72,6 +72,7 @@ type collectionOptions struct {
 	augmentation func(o any) any
 	stop         <-chan struct{}
 	debugger     *DebugHandler
+	snapshot     *SnapshotStore
 }
 
 // dependency is a specific thing that can be depended on
@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/monitoring"
+)
+
+var (
+	collectionTag = monitoring.CreateLabel("collection")
+	enableMetric  = monitoring.WithEnabled(func() bool {
+		return features.EnableKrtMetrics
+	})
+
+	recomputes = monitoring.NewSum("pilot_krt_recomputes",
+		"Total number of times a collection transformation was run", enableMetric)
+
+	events = monitoring.NewSum("pilot_krt_events",
+		"Total number of events produced by a collection", enableMetric)
+
+	handlerEvents = monitoring.NewSum("pilot_krt_handler_events",
+		"Total number of events delivered to handlers of a collection, which is the number of events times the number of handlers",
+		enableMetric)
+
+	transformationDuration = monitoring.NewDistribution("pilot_krt_transformation_duration",
+		"Time taken to run a collection transformation", []float64{.0001, .001, .01, .1, .5, 1, 5},
+		monitoring.WithUnit(monitoring.Seconds), enableMetric)
+)
+
+// collectionMetrics tracks the work done by a single collection.
+// Counts are kept locally as well, so they can be reported in the dependency graph regardless of whether metrics are enabled.
+type collectionMetrics struct {
+	recomputes             monitoring.Metric
+	events                 monitoring.Metric
+	handlerEvents          monitoring.Metric
+	transformationDuration monitoring.Metric
+
+	recomputeCount    *atomic.Uint64
+	eventCount        *atomic.Uint64
+	handlerEventCount *atomic.Uint64
+}
+
+func newCollectionMetrics(name string) *collectionMetrics {
+	return &collectionMetrics{
+		recomputes:             recomputes.With(collectionTag.Value(name)),
+		events:                 events.With(collectionTag.Value(name)),
+		handlerEvents:          handlerEvents.With(collectionTag.Value(name)),
+		transformationDuration: transformationDuration.With(collectionTag.Value(name)),
+		recomputeCount:         atomic.NewUint64(0),
+		eventCount:             atomic.NewUint64(0),
+		handlerEventCount:      atomic.NewUint64(0),
+	}
+}
+
+// recomputed records a single run of the transformation function, which started at t0.
+func (m *collectionMetrics) recomputed(t0 time.Time) {
+	m.recomputeCount.Inc()
+	m.recomputes.Increment()
+	m.transformationDuration.Record(time.Since(t0).Seconds())
+}
+
+// emitted records a batch of events sent to the handlers.
+func (m *collectionMetrics) emitted(events int, handlers int) {
+	m.eventCount.Add(uint64(events))
+	m.events.RecordInt(int64(events))
+	m.handlerEventCount.Add(uint64(events * handlers))
+	m.handlerEvents.RecordInt(int64(events * handlers))
+}
@@ -0,0 +1,168 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// collectionStatusSyncing means the collection has not yet processed the initial state of its inputs.
+	collectionStatusSyncing = "syncing"
+	// collectionStatusServingFromSnapshot means the collection is serving state restored from a snapshot, and has not
+	// yet been reconciled against the initial state of its inputs.
+	collectionStatusServingFromSnapshot = "serving-from-snapshot"
+	// collectionStatusSynced means the collection has processed the initial state of its inputs.
+	collectionStatusSynced = "synced"
+)
+
+// SnapshotStore persists the outputs of collections to a local directory, so they can be restored when the process restarts.
+//
+// Collections opt in with WithSnapshot. On creation, a collection with a snapshot on disk is populated from it and
+// immediately reports itself as synced, so consumers can start serving without waiting for the (potentially slow)
+// initial computation. Once the inputs have synced, the collection is reconciled against the live state: outputs that
+// changed trigger update events, and outputs that no longer exist trigger delete events.
+//
+// Outputs are stored as JSON, so the output type must round-trip through encoding/json.
+type SnapshotStore struct {
+	dir string
+
+	mu          sync.Mutex
+	collections map[string]snapshotCollection
+}
+
+type snapshotCollection struct {
+	// outputs returns the current outputs of the collection, and whether they are complete.
+	outputs func() (any, bool)
+}
+
+// snapshotFile is the on-disk format of a single collection snapshot.
+type snapshotFile[O any] struct {
+	Outputs map[Key[O]]O `json:"outputs"`
+}
+
+// NewSnapshotStore creates a SnapshotStore persisting snapshots under dir.
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{
+		dir:         dir,
+		collections: map[string]snapshotCollection{},
+	}
+}
+
+// Dir returns the directory snapshots are stored in.
+func (s *SnapshotStore) Dir() string {
+	return s.dir
+}
+
+// WithSnapshot enables persisting the collection to the provided store, and warm-starting it from a previous snapshot.
+// The collection must have a stable name, set by WithName, which is used to identify the snapshot.
+// Currently, this is only supported by collections built with NewCollection and NewManyCollection.
+func WithSnapshot(store *SnapshotStore) CollectionOption {
+	return func(c *collectionOptions) {
+		c.snapshot = store
+	}
+}
+
+// Save writes a snapshot of all registered collections. Collections that have not yet synced are skipped, so that a
+// partial state, or a snapshot that was never reconciled, is not persisted.
+func (s *SnapshotStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	var errs []error
+	for name, c := range s.collections {
+		outputs, complete := c.outputs()
+		if !complete {
+			log.WithLabels("collection", name).Infof("skipping snapshot, collection is not synced")
+			continue
+		}
+		if err := s.write(name, outputs); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot %v: %v", name, err))
+			continue
+		}
+		log.WithLabels("collection", name).Debugf("wrote snapshot")
+	}
+	return errors.Join(errs...)
+}
+
+// write atomically replaces the snapshot of a collection.
+func (s *SnapshotStore) write(name string, outputs any) error {
+	b, err := json.Marshal(outputs)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, snapshotFileName(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(s.dir, snapshotFileName(name)))
+}
+
+func (s *SnapshotStore) register(name string, c snapshotCollection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, f := s.collections[name]; f {
+		log.WithLabels("collection", name).Warnf("duplicate snapshot registration; snapshots will conflict")
+	}
+	s.collections[name] = c
+}
+
+// snapshotFileName converts a collection name into a safe file name.
+func snapshotFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name) + ".json"
+}
+
+// loadSnapshot reads the snapshot for the named collection. If there is no snapshot, or it cannot be read, false is returned
+// and the collection should start from scratch.
+func loadSnapshot[O any](s *SnapshotStore, name string) (map[Key[O]]O, bool) {
+	b, err := os.ReadFile(filepath.Join(s.dir, snapshotFileName(name)))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithLabels("collection", name).Warnf("failed to read snapshot: %v", err)
+		}
+		return nil, false
+	}
+	snap := snapshotFile[O]{}
+	if err := json.Unmarshal(b, &snap); err != nil {
+		log.WithLabels("collection", name).Warnf("failed to decode snapshot: %v", err)
+		return nil, false
+	}
+	if snap.Outputs == nil {
+		snap.Outputs = map[Key[O]]O{}
+	}
+	return snap.Outputs, true
+}
@@ -0,0 +1,117 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package krt_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func snapshotPodCollection(pods krt.Collection[*corev1.Pod], opts ...krt.CollectionOption) krt.Collection[SimplePod] {
+	return krt.NewCollection(pods, func(ctx krt.HandlerContext, i *corev1.Pod) *SimplePod {
+		if i.Status.PodIP == "" {
+			return nil
+		}
+		return &SimplePod{
+			Named:   NewNamed(i),
+			Labeled: NewLabeled(i.Labels),
+			IP:      i.Status.PodIP,
+		}
+	}, append(opts, krt.WithName("SimplePods"))...)
+}
+
+func snapshotTestPod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace"},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func collectionStatus(t *testing.T, d *krt.DebugHandler) string {
+	b, err := json.Marshal(d)
+	assert.NoError(t, err)
+	for _, s := range []string{"serving-from-snapshot", "syncing", "synced"} {
+		if strings.Contains(string(b), `"status":"`+s+`"`) {
+			return s
+		}
+	}
+	return ""
+}
+
+func TestCollectionSnapshot(t *testing.T) {
+	store := krt.NewSnapshotStore(t.TempDir())
+
+	// First run: compute the state from scratch, then persist it.
+	stop := test.NewStop(t)
+	c := kube.NewFakeClient(snapshotTestPod("a", "1.1.1.1"), snapshotTestPod("b", "2.2.2.2"))
+	pods := krt.NewInformer[*corev1.Pod](c, krt.WithStop(stop))
+	c.RunAndWait(stop)
+	SimplePods := snapshotPodCollection(pods, krt.WithSnapshot(store), krt.WithStop(stop))
+	assert.Equal(t, SimplePods.Synced().WaitUntilSynced(stop), true)
+	assert.NoError(t, store.Save())
+
+	// Second run: pod a changed, pod b was removed, and pod c was added while we were down.
+	// The informer is not started yet, so we can only serve the snapshot.
+	stop2 := test.NewStop(t)
+	c2 := kube.NewFakeClient(snapshotTestPod("a", "1.1.1.2"), snapshotTestPod("c", "3.3.3.3"))
+	pods2 := krt.NewInformer[*corev1.Pod](c2, krt.WithStop(stop2))
+	debugger := &krt.DebugHandler{}
+	restored := snapshotPodCollection(pods2, krt.WithSnapshot(krt.NewSnapshotStore(store.Dir())), krt.WithStop(stop2), krt.WithDebugging(debugger))
+	assert.Equal(t, restored.Synced().HasSynced(), true)
+	assert.Equal(t, collectionStatus(t, debugger), "serving-from-snapshot")
+	assert.Equal(t, fetcherSorted(restored)(), []SimplePod{
+		{Named{"namespace", "a"}, Labeled{}, "1.1.1.1"},
+		{Named{"namespace", "b"}, Labeled{}, "2.2.2.2"},
+	})
+
+	tt := assert.NewTracker[string](t)
+	restored.Register(TrackerHandler[SimplePod](tt))
+	tt.WaitUnordered("add/namespace/a", "add/namespace/b")
+
+	// Once synced, the snapshot is reconciled against the live state
+	c2.RunAndWait(stop2)
+	tt.WaitUnordered("update/namespace/a", "add/namespace/c", "delete/namespace/b")
+	assert.EventuallyEqual(t, func() string { return collectionStatus(t, debugger) }, "synced")
+	assert.Equal(t, fetcherSorted(restored)(), []SimplePod{
+		{Named{"namespace", "a"}, Labeled{}, "1.1.1.2"},
+		{Named{"namespace", "c"}, Labeled{}, "3.3.3.3"},
+	})
+	tt.Empty()
+}
+
+func TestCollectionSnapshotNotSynced(t *testing.T) {
+	store := krt.NewSnapshotStore(t.TempDir())
+	stop := test.NewStop(t)
+	c := kube.NewFakeClient(snapshotTestPod("a", "1.1.1.1"))
+	pods := krt.NewInformer[*corev1.Pod](c, krt.WithStop(stop))
+	SimplePods := snapshotPodCollection(pods, krt.WithSnapshot(store), krt.WithStop(stop))
+	// Nothing is written for a collection that has not synced
+	assert.NoError(t, store.Save())
+
+	restored := snapshotPodCollection(pods, krt.WithSnapshot(krt.NewSnapshotStore(store.Dir())), krt.WithStop(stop))
+	assert.Equal(t, restored.Synced().HasSynced(), false)
+	c.RunAndWait(stop)
+	assert.Equal(t, SimplePods.Synced().WaitUntilSynced(stop), true)
+	assert.Equal(t, restored.Synced().WaitUntilSynced(stop), true)
+}
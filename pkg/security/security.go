@@ -101,6 +101,12 @@ const (
 	// GCE is Credential fetcher type of Google plugin
 	GCE = "GoogleComputeEngine"
 
+	// AWS is Credential fetcher type of AWS plugin, which uses the EC2 instance identity document
+	AWS = "AWS"
+
+	// Azure is Credential fetcher type of Azure plugin, which uses the VM managed identity token
+	Azure = "Azure"
+
 	// JWT is a Credential fetcher type that reads from a JWT token file
 	JWT = "JWT"
 
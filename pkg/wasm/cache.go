@@ -19,6 +19,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -58,9 +59,13 @@ type LocalFileCache struct {
 	checksums map[string]*checksumEntry
 	// http fetcher fetches Wasm module with HTTP get.
 	httpFetcher *HTTPFetcher
+	// verifier, if set, requires fetched modules to be signed.
+	verifier *SignatureVerifier
 
 	// directory path used to store Wasm module.
 	dir string
+	// total size in bytes of the cached modules.
+	totalSize int64
 
 	// mux is needed because stale Wasm module files will be purged periodically.
 	mux sync.Mutex
@@ -105,6 +110,14 @@ type cacheEntry struct {
 	last time.Time
 	// set of URLs referencing this entry
 	referencingURLs sets.String
+	// Size of the module file in bytes.
+	size int64
+	// Hex-Encoded sha256 checksum of the module file. Unlike the checksum in moduleKey, which is the
+	// image digest for OCI images, this is always computed over the Wasm binary.
+	contentChecksum string
+	// verifiedBy is the fingerprint of the keys the signature of the module was verified with when it was fetched,
+	// if it was.
+	verifiedBy string
 }
 
 type cacheOptions struct {
@@ -133,6 +146,8 @@ func (o cacheOptions) sanitize() cacheOptions {
 	if o.HTTPRequestMaxRetries != 0 {
 		ret.HTTPRequestMaxRetries = o.HTTPRequestMaxRetries
 	}
+	ret.SignaturePublicKeysFile = o.SignaturePublicKeysFile
+	ret.MaxCacheSize = o.MaxCacheSize
 
 	return ret
 }
@@ -154,6 +169,13 @@ func NewLocalFileCache(dir string, options Options) *LocalFileCache {
 		cacheOptions: cacheOptions.sanitize(),
 		stopChan:     make(chan struct{}),
 	}
+	if options.SignaturePublicKeysFile != "" {
+		cache.verifier = loadSignatureVerifier(options.SignaturePublicKeysFile)
+		if cache.verifier.err != nil {
+			wasmLog.Errorf("all Wasm module fetches will fail: %v", cache.verifier.err)
+		}
+	}
+	cache.restore()
 
 	go func() {
 		cache.purge()
@@ -238,6 +260,10 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 	defer cancel()
 	switch u.Scheme {
 	case "http", "https":
+		if c.verifier != nil {
+			wasmRemoteFetchCount.With(resultTag.Value(signatureFailure)).Increment()
+			return nil, fmt.Errorf("%w: Wasm module %s is not an OCI image", errSignatureVerification, key.downloadURL)
+		}
 		// Download the Wasm module with http fetcher.
 		b, err = c.httpFetcher.Fetch(ctx, key.downloadURL, insecure)
 		if err != nil {
@@ -251,6 +277,7 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 	case "oci":
 		imgFetcherOps := ImageFetcherOption{
 			Insecure: insecure,
+			Verifier: c.verifier,
 		}
 		if opts.PullSecret != nil {
 			imgFetcherOps.PullSecret = opts.PullSecret
@@ -259,8 +286,12 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 		fetcher := NewImageFetcher(ctx, imgFetcherOps)
 		binaryFetcher, dChecksum, err = fetcher.PrepareFetch(u.Host + u.Path)
 		if err != nil {
-			wasmRemoteFetchCount.With(resultTag.Value(manifestFailure)).Increment()
-			return nil, fmt.Errorf("could not fetch Wasm OCI image: %v", err)
+			if errors.Is(err, errSignatureVerification) {
+				wasmRemoteFetchCount.With(resultTag.Value(signatureFailure)).Increment()
+			} else {
+				wasmRemoteFetchCount.With(resultTag.Value(manifestFailure)).Increment()
+			}
+			return nil, fmt.Errorf("could not fetch Wasm OCI image: %w", err)
 		}
 	default:
 		return nil, fmt.Errorf("unsupported Wasm module downloading URL scheme: %v", u.Scheme)
@@ -328,8 +359,11 @@ func (c *LocalFileCache) addEntry(key cacheKey, wasmModule []byte) (*cacheEntry,
 	if ce, ok := c.modules[key.moduleKey]; ok {
 		// Update last touched time.
 		ce.last = time.Now()
-		if needChecksumUpdate {
+		if needChecksumUpdate && !ce.referencingURLs.Contains(key.downloadURL) {
 			ce.referencingURLs.Insert(key.downloadURL)
+			if err := writeModuleMetadata(key.moduleKey, ce); err != nil {
+				wasmLog.Warnf("failed to update metadata of Wasm module %v: %v", ce.modulePath, err)
+			}
 		}
 		return ce, nil
 	}
@@ -343,15 +377,27 @@ func (c *LocalFileCache) addEntry(key cacheKey, wasmModule []byte) (*cacheEntry,
 		return nil, err
 	}
 
+	sha := sha256.Sum256(wasmModule)
 	ce := cacheEntry{
 		modulePath:      modulePath,
 		last:            time.Now(),
 		referencingURLs: sets.New[string](),
+		size:            int64(len(wasmModule)),
+		contentChecksum: hex.EncodeToString(sha[:]),
+	}
+	if c.verifier != nil {
+		ce.verifiedBy = c.verifier.fingerprint
 	}
 	if needChecksumUpdate {
 		ce.referencingURLs.Insert(key.downloadURL)
 	}
+	// Without metadata the module cannot be restored after a restart, but can still be served.
+	if err := writeModuleMetadata(key.moduleKey, &ce); err != nil {
+		wasmLog.Warnf("failed to write metadata of Wasm module %v: %v", modulePath, err)
+	}
 	c.modules[key.moduleKey] = &ce
+	c.totalSize += ce.size
+	c.evict(key.moduleKey)
 	wasmCacheEntries.Record(float64(len(c.modules)))
 	return &ce, nil
 }
@@ -413,13 +459,9 @@ func (c *LocalFileCache) purge() {
 					continue
 				}
 				// The module has not be touched for expiry duration, delete it from the map as well as the local dir.
-				if err := os.Remove(m.modulePath); err != nil {
+				if err := c.removeEntry(k, m); err != nil {
 					wasmLog.Errorf("failed to purge Wasm module %v: %v", m.modulePath, err)
 				} else {
-					for downloadURL := range m.referencingURLs {
-						delete(c.checksums, downloadURL)
-					}
-					delete(c.modules, k)
 					wasmLog.Debugf("successfully removed stale Wasm module %v", m.modulePath)
 				}
 			}
@@ -432,6 +474,48 @@ func (c *LocalFileCache) purge() {
 	}
 }
 
+// evict removes the least recently used modules until the cache fits in MaxCacheSize. The module identified by
+// keep, which was just added, is never evicted. The caller must hold the lock.
+func (c *LocalFileCache) evict(keep moduleKey) {
+	if c.MaxCacheSize <= 0 {
+		return
+	}
+	for c.totalSize > c.MaxCacheSize {
+		var oldestKey moduleKey
+		var oldest *cacheEntry
+		for k, m := range c.modules {
+			if k != keep && (oldest == nil || m.last.Before(oldest.last)) {
+				oldestKey, oldest = k, m
+			}
+		}
+		if oldest == nil {
+			wasmLog.Warnf("Wasm module %v is larger than the maximum cache size %d", keep.name, c.MaxCacheSize)
+			return
+		}
+		if err := c.removeEntry(oldestKey, oldest); err != nil {
+			wasmLog.Errorf("failed to evict Wasm module %v: %v", oldest.modulePath, err)
+			return
+		}
+		wasmLog.Debugf("evicted least recently used Wasm module %v", oldest.modulePath)
+	}
+}
+
+// removeEntry deletes a module from the map as well as the local dir. The caller must hold the lock.
+func (c *LocalFileCache) removeEntry(k moduleKey, m *cacheEntry) error {
+	if err := os.Remove(m.modulePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(moduleMetadataPath(m.modulePath)); err != nil && !os.IsNotExist(err) {
+		wasmLog.Warnf("failed to remove metadata of Wasm module %v: %v", m.modulePath, err)
+	}
+	for downloadURL := range m.referencingURLs {
+		delete(c.checksums, downloadURL)
+	}
+	delete(c.modules, k)
+	c.totalSize -= m.size
+	return nil
+}
+
 // Expired returns true if the module has not been touched for Wasm module Expiry.
 func (ce *cacheEntry) expired(expiry time.Duration) bool {
 	now := time.Now()
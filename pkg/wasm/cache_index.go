@@ -0,0 +1,172 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+// moduleMetadata is stored next to each module file, so that the cache index can be rebuilt when the agent restarts.
+type moduleMetadata struct {
+	// Name is the module name of the moduleKey. The directory of the module only holds its hash.
+	Name string `json:"name"`
+	// URLs are the download URLs resolving to this module, used to serve tagged URLs without contacting the registry.
+	URLs []string `json:"urls,omitempty"`
+	// SHA256 is the Hex-Encoded sha256 checksum of the module file.
+	SHA256 string `json:"sha256"`
+	// VerifiedBy is the fingerprint of the keys the signature of the module was verified with when it was fetched, if
+	// it was. The module is only trusted while the same keys are.
+	VerifiedBy string `json:"verifiedBy,omitempty"`
+}
+
+// moduleMetadataPath returns the metadata file path for a module file path.
+func moduleMetadataPath(modulePath string) string {
+	return strings.TrimSuffix(modulePath, ".wasm") + ".json"
+}
+
+func writeModuleMetadata(key moduleKey, ce *cacheEntry) error {
+	b, err := json.Marshal(moduleMetadata{
+		Name:       key.name,
+		URLs:       sets.SortedList(ce.referencingURLs),
+		SHA256:     ce.contentChecksum,
+		VerifiedBy: ce.verifiedBy,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(moduleMetadataPath(ce.modulePath), b, 0o644)
+}
+
+// restore rebuilds the cache index from the module files in the cache directory. Modules that cannot be
+// validated against their metadata are removed, and will be fetched again when needed.
+func (c *LocalFileCache) restore() {
+	dirs, err := os.ReadDir(c.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			wasmLog.Warnf("failed to read Wasm module cache directory %v: %v", c.dir, err)
+		}
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for _, d := range dirs {
+		// Module directories are named after the sha256 of the module name; skip anything else in the directory.
+		if !d.IsDir() || !isHexSHA256(d.Name()) {
+			continue
+		}
+		moduleDir := filepath.Join(c.dir, d.Name())
+		files, err := os.ReadDir(moduleDir)
+		if err != nil {
+			wasmLog.Warnf("failed to read Wasm module directory %v: %v", moduleDir, err)
+			continue
+		}
+		for _, f := range files {
+			checksum, ok := strings.CutSuffix(f.Name(), ".wasm")
+			if f.IsDir() || !ok {
+				continue
+			}
+			modulePath := filepath.Join(moduleDir, f.Name())
+			if err := c.restoreEntry(d.Name(), checksum, modulePath); err != nil {
+				wasmLog.Infof("removing cached Wasm module %v: %v", modulePath, err)
+				_ = os.Remove(modulePath)
+				_ = os.Remove(moduleMetadataPath(modulePath))
+			}
+		}
+	}
+	// The limit may have been lowered since the modules were written.
+	c.evict(moduleKey{})
+	wasmCacheEntries.Record(float64(len(c.modules)))
+	if len(c.modules) > 0 {
+		wasmLog.Infof("restored %d Wasm modules (%d bytes) from %v", len(c.modules), c.totalSize, c.dir)
+	}
+}
+
+// restoreEntry validates a module file against its metadata, and adds it to the index. The caller must hold the lock.
+func (c *LocalFileCache) restoreEntry(hashedName, checksum, modulePath string) error {
+	b, err := os.ReadFile(moduleMetadataPath(modulePath))
+	if err != nil {
+		return err
+	}
+	meta := moduleMetadata{}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return err
+	}
+	if sha := sha256.Sum256([]byte(meta.Name)); hex.EncodeToString(sha[:]) != hashedName {
+		return fmt.Errorf("module name %q does not match the directory", meta.Name)
+	}
+	if c.verifier != nil && (c.verifier.err != nil || meta.VerifiedBy != c.verifier.fingerprint) {
+		return fmt.Errorf("signature was not verified with the trusted keys")
+	}
+	module, err := os.ReadFile(modulePath)
+	if err != nil {
+		return err
+	}
+	if sha := sha256.Sum256(module); hex.EncodeToString(sha[:]) != meta.SHA256 {
+		return fmt.Errorf("checksum mismatch")
+	}
+	if !isValidWasmBinary(module) {
+		return fmt.Errorf("invalid Wasm binary")
+	}
+	info, err := os.Stat(modulePath)
+	if err != nil {
+		return err
+	}
+	key := moduleKey{name: meta.Name, checksum: checksum}
+	ce := &cacheEntry{
+		modulePath: modulePath,
+		// Without a record of the last access, treat the module as last used when it was written.
+		last:            info.ModTime(),
+		referencingURLs: sets.New(meta.URLs...),
+		size:            int64(len(module)),
+		contentChecksum: meta.SHA256,
+		verifiedBy:      meta.VerifiedBy,
+	}
+	c.modules[key] = ce
+	c.totalSize += ce.size
+	for _, u := range meta.URLs {
+		// A URL may have been resolved to several modules over time; the most recently written one is current.
+		if prev, f := c.checksums[u]; f && c.newerModuleFor(u, prev.checksum, ce) {
+			continue
+		}
+		c.checksums[u] = &checksumEntry{checksum: checksum, resourceVersionByResource: map[string]string{}}
+	}
+	return nil
+}
+
+// newerModuleFor returns true if the module with the given checksum, already restored for url, is newer than ce.
+func (c *LocalFileCache) newerModuleFor(url, checksum string, ce *cacheEntry) bool {
+	for k, m := range c.modules {
+		if k.checksum == checksum && m.referencingURLs.Contains(url) {
+			return m.last.After(ce.last)
+		}
+	}
+	return false
+}
+
+func isHexSHA256(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
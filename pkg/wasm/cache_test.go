@@ -18,6 +18,7 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -747,7 +748,7 @@ func TestWasmCache(t *testing.T) {
 			}
 
 			if diff := cmp.Diff(c.wantCachedModules, cache.modules,
-				cmpopts.IgnoreFields(cacheEntry{}, "last", "referencingURLs"),
+				cmpopts.IgnoreFields(cacheEntry{}, "last", "referencingURLs", "size", "contentChecksum", "verifiedBy"),
 				cmp.AllowUnexported(cacheEntry{}),
 			); diff != "" {
 				t.Errorf("unexpected module cache: (-want, +got)\n%v", diff)
@@ -957,3 +958,166 @@ func generateModulePath(t *testing.T, baseDir, resourceName, filename string) st
 	}
 	return filepath.Join(moduleDir, filename)
 }
+
+func TestWasmCacheLRUEviction(t *testing.T) {
+	tmpDir := t.TempDir()
+	binaries := map[string][]byte{}
+	for _, p := range []string{"/a", "/b", "/c"} {
+		binaries[p] = append(wasmHeader, []byte(strings.Repeat(p, 50))...)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binaries[r.URL.Path])
+	}))
+	defer ts.Close()
+
+	options := defaultOptions()
+	// Room for two modules only.
+	options.MaxCacheSize = int64(2*len(binaries["/a"]) + 10)
+	cache := NewLocalFileCache(tmpDir, options)
+	defer close(cache.stopChan)
+	get := func(path string) string {
+		t.Helper()
+		f, err := cache.Get(ts.URL+path, GetOptions{ResourceName: "namespace.resource", RequestTimeout: time.Second * 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	fileA := get("/a")
+	fileB := get("/b")
+	// Touch a, so b becomes the least recently used.
+	get("/a")
+	fileC := get("/c")
+
+	for f, want := range map[string]bool{fileA: true, fileB: false, fileC: true} {
+		if _, err := os.Stat(f); (err == nil) != want {
+			t.Errorf("module %v exists: got %v, want %v", f, err == nil, want)
+		}
+	}
+	if _, err := os.Stat(moduleMetadataPath(fileB)); !os.IsNotExist(err) {
+		t.Errorf("expected metadata of evicted module to be removed, got %v", err)
+	}
+	cache.mux.Lock()
+	defer cache.mux.Unlock()
+	if len(cache.modules) != 2 {
+		t.Errorf("expected 2 cached modules, got %d", len(cache.modules))
+	}
+	if _, f := cache.checksums[ts.URL+"/b"]; f {
+		t.Errorf("expected checksum of evicted module to be removed")
+	}
+	if want := int64(2 * len(binaries["/a"])); cache.totalSize != want {
+		t.Errorf("total size got %d, want %d", cache.totalSize, want)
+	}
+}
+
+func TestWasmCacheRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	numRequest := int32(0)
+	binary := append(wasmHeader, []byte("restored")...)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numRequest, 1)
+		w.Write(binary)
+	}))
+	defer ts.Close()
+	opts := GetOptions{ResourceName: "namespace.resource", RequestTimeout: time.Second * 10}
+
+	cache := NewLocalFileCache(tmpDir, defaultOptions())
+	want, err := cache.Get(ts.URL+"/good", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted, err := cache.Get(ts.URL+"/corrupted", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(cache.stopChan)
+	if err := os.WriteFile(corrupted, append(wasmHeader, []byte("tampered")...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Unrelated files in the directory must be left alone.
+	unrelated := filepath.Join(tmpDir, "unrelated")
+	if err := os.WriteFile(unrelated, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate an agent restart.
+	restored := NewLocalFileCache(tmpDir, defaultOptions())
+	defer close(restored.stopChan)
+	got, err := restored.Get(ts.URL+"/good", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("restored module path got %v, want %v", got, want)
+	}
+	if n := atomic.LoadInt32(&numRequest); n != 2 {
+		t.Errorf("expected restored module to be served without fetching, got %d requests", n)
+	}
+	if _, err := os.Stat(corrupted); !os.IsNotExist(err) {
+		t.Errorf("expected module failing checksum validation to be removed, got %v", err)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("expected unrelated file to be kept: %v", err)
+	}
+	if _, err := restored.Get(ts.URL+"/corrupted", opts); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&numRequest); n != 3 {
+		t.Errorf("expected removed module to be fetched again, got %d requests", n)
+	}
+
+	// Modules fetched without verification are not trusted once signatures are required.
+	keysFile := filepath.Join(t.TempDir(), "keys.pem")
+	_, pub := newSigningKey(t)
+	if err := os.WriteFile(keysFile, pub, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	options := defaultOptions()
+	options.SignaturePublicKeysFile = keysFile
+	verifying := NewLocalFileCache(tmpDir, options)
+	defer close(verifying.stopChan)
+	if len(verifying.modules) != 0 {
+		t.Errorf("expected unverified modules not to be restored, got %d", len(verifying.modules))
+	}
+
+	// Modules verified with the trusted keys are restored, and are not trusted anymore once the keys change.
+	verifier, err := NewSignatureVerifier(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The module was removed by the verifying cache, so it is fetched again.
+	refetching := NewLocalFileCache(tmpDir, defaultOptions())
+	defer close(refetching.stopChan)
+	good, err := refetching.Get(ts.URL+"/good", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha := sha256.Sum256(binary)
+	meta, err := json.Marshal(moduleMetadata{
+		Name:       ts.URL + "/good",
+		URLs:       []string{ts.URL + "/good"},
+		SHA256:     hex.EncodeToString(sha[:]),
+		VerifiedBy: verifier.fingerprint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(moduleMetadataPath(good), meta, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	verified := NewLocalFileCache(tmpDir, options)
+	defer close(verified.stopChan)
+	if len(verified.modules) != 1 {
+		t.Errorf("expected the verified module to be restored, got %d modules", len(verified.modules))
+	}
+	_, otherPub := newSigningKey(t)
+	if err := os.WriteFile(keysFile, otherPub, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rotated := NewLocalFileCache(tmpDir, options)
+	defer close(rotated.stopChan)
+	if len(rotated.modules) != 0 {
+		t.Errorf("expected modules verified with other keys not to be restored, got %d", len(rotated.modules))
+	}
+}
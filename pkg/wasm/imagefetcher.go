@@ -42,9 +42,10 @@ import (
 // Basically, this supports fetching and unpackaging three types of container images containing a Wasm binary.
 
 type ImageFetcherOption struct {
-	// TODO(mathetake) Add signature verification stuff.
 	PullSecret []byte
 	Insecure   bool
+	// Verifier, if set, requires images to have a valid signature.
+	Verifier *SignatureVerifier
 }
 
 func (o *ImageFetcherOption) useDefaultKeyChain() bool {
@@ -53,13 +54,14 @@ func (o *ImageFetcherOption) useDefaultKeyChain() bool {
 
 func (o ImageFetcherOption) String() string {
 	if o.PullSecret == nil {
-		return fmt.Sprintf("{Insecure: %v}", o.Insecure)
+		return fmt.Sprintf("{Insecure: %v, VerifySignature: %v}", o.Insecure, o.Verifier != nil)
 	}
-	return fmt.Sprintf("{Insecure: %v, PullSecret: <redacted>}", o.Insecure)
+	return fmt.Sprintf("{Insecure: %v, VerifySignature: %v, PullSecret: <redacted>}", o.Insecure, o.Verifier != nil)
 }
 
 type ImageFetcher struct {
 	fetchOpts []remote.Option
+	verifier  *SignatureVerifier
 }
 
 func NewImageFetcher(ctx context.Context, opt ImageFetcherOption) *ImageFetcher {
@@ -85,6 +87,7 @@ func NewImageFetcher(ctx context.Context, opt ImageFetcherOption) *ImageFetcher
 
 	return &ImageFetcher{
 		fetchOpts: append(fetchOpts, remote.WithContext(ctx)),
+		verifier:  opt.Verifier,
 	}
 }
 
@@ -125,6 +128,12 @@ func (o *ImageFetcher) PrepareFetch(url string) (binaryFetcher func() ([]byte, e
 
 	// Check Manifest's digest if expManifestDigest is not empty.
 	d, _ := img.Digest()
+	if o.verifier != nil {
+		// Verify before returning the digest, so that an unsigned image is never served from the cache either.
+		if err = o.verifier.Verify(ref, d, o.fetchOpts...); err != nil {
+			return
+		}
+	}
 	actualDigest = d.Hex
 	binaryFetcher = func() ([]byte, error) {
 		manifest, err := img.Manifest()
@@ -23,6 +23,7 @@ const (
 	downloadFailure  = "download_failure"
 	manifestFailure  = "manifest_failure"
 	checksumMismatch = "checksum_mismatched"
+	signatureFailure = "signature_failure"
 
 	// For Wasm conversion metric.
 	conversionSuccess   = "success"
@@ -49,7 +50,7 @@ var (
 
 	wasmRemoteFetchCount = monitoring.NewSum(
 		"wasm_remote_fetch_count",
-		"number of Wasm remote fetches and results, including success, download failure, and checksum mismatch.",
+		"number of Wasm remote fetches and results, including success, download failure, checksum mismatch, and signature failure.",
 	)
 
 	wasmConfigConversionCount = monitoring.NewSum(
@@ -34,6 +34,13 @@ type Options struct {
 	InsecureRegistries    sets.String
 	HTTPRequestTimeout    time.Duration
 	HTTPRequestMaxRetries int
+	// SignaturePublicKeysFile is the path to a PEM file with the public keys trusted to sign Wasm modules.
+	// If set, OCI images must have a valid cosign signature from one of the keys, and modules cannot be
+	// fetched over http/https as they cannot be verified.
+	SignaturePublicKeysFile string
+	// MaxCacheSize is the maximum total size in bytes of the cached modules. When exceeded, the least recently
+	// used modules are evicted. Zero means no limit.
+	MaxCacheSize int64
 }
 
 func defaultOptions() Options {
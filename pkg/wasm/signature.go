@@ -0,0 +1,213 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/slices"
+)
+
+// This file implements verification of cosign signatures attached to Wasm images.
+// Cosign stores the signatures of an image as a separate image in the same repository, tagged with
+// `<algorithm>-<digest>.sig`. Each layer of that image is a "simple signing" payload naming the digest of the signed
+// manifest, and carries the base64 encoded signature of the payload as an annotation.
+// See https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md.
+
+const (
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// maxSignaturePayloadSize bounds the size of a simple signing payload; real payloads are a few hundred bytes.
+	maxSignaturePayloadSize = 1024 * 1024
+)
+
+// errSignatureVerification is wrapped by all errors caused by a module failing signature verification.
+var errSignatureVerification = errors.New("signature verification failed")
+
+// SignatureVerifier verifies that Wasm images are signed by one of a set of trusted public keys.
+type SignatureVerifier struct {
+	keys []crypto.PublicKey
+	// fingerprint identifies the set of trusted keys, so that modules verified with other keys are not trusted.
+	fingerprint string
+	// err is set if the trusted keys could not be loaded. Verification then always fails, so that a
+	// misconfiguration does not silently disable verification.
+	err error
+}
+
+// simpleSigningPayload is the subset of the cosign simple signing format that needs to be checked.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// NewSignatureVerifier creates a SignatureVerifier trusting the PEM encoded public keys.
+// ECDSA, RSA and Ed25519 keys are supported.
+func NewSignatureVerifier(keysPEM []byte) (*SignatureVerifier, error) {
+	v := &SignatureVerifier{}
+	var ders []string
+	for {
+		var block *pem.Block
+		block, keysPEM = pem.Decode(keysPEM)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v", err)
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+		v.keys = append(v.keys, key)
+		ders = append(ders, string(block.Bytes))
+	}
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("no public keys found")
+	}
+	// The order of the keys in the file does not matter.
+	h := sha256.New()
+	for _, der := range slices.Sort(ders) {
+		h.Write([]byte(der))
+	}
+	v.fingerprint = hex.EncodeToString(h.Sum(nil))
+	return v, nil
+}
+
+// loadSignatureVerifier reads the trusted keys from a PEM file. If the file cannot be used, the returned verifier
+// rejects every module.
+func loadSignatureVerifier(file string) *SignatureVerifier {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return &SignatureVerifier{err: fmt.Errorf("failed to read signature verification keys: %v", err)}
+	}
+	v, err := NewSignatureVerifier(b)
+	if err != nil {
+		return &SignatureVerifier{err: fmt.Errorf("failed to load signature verification keys from %s: %v", file, err)}
+	}
+	return v
+}
+
+// Verify checks that the image with the given digest in the repository of ref has at least one valid signature.
+func (v *SignatureVerifier) Verify(ref name.Reference, digest v1.Hash, opts ...remote.Option) error {
+	if v.err != nil {
+		return fmt.Errorf("%w: %v", errSignatureVerification, v.err)
+	}
+	sigRef := ref.Context().Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+	sigImg, err := remote.Image(sigRef, opts...)
+	if err != nil {
+		return fmt.Errorf("%w: could not fetch signatures %s: %v", errSignatureVerification, sigRef, err)
+	}
+	manifest, err := sigImg.Manifest()
+	if err != nil {
+		return fmt.Errorf("%w: could not retrieve signature manifest: %v", errSignatureVerification, err)
+	}
+	var errs error
+	for _, desc := range manifest.Layers {
+		sig, found := desc.Annotations[cosignSignatureAnnotation]
+		if !found {
+			continue
+		}
+		err := v.verifyLayer(sigImg, desc.Digest, sig, digest)
+		if err == nil {
+			return nil
+		}
+		errs = multierror.Append(errs, err)
+	}
+	if errs == nil {
+		return fmt.Errorf("%w: no signatures found in %s", errSignatureVerification, sigRef)
+	}
+	return fmt.Errorf("%w: no valid signature for %s: %v", errSignatureVerification, digest, errs)
+}
+
+// verifyLayer verifies a single signature, and that the signed payload refers to the expected image.
+func (v *SignatureVerifier) verifyLayer(sigImg v1.Image, layerDigest v1.Hash, sig string, expected v1.Hash) error {
+	rawSig, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("could not decode signature: %v", err)
+	}
+	layer, err := sigImg.LayerByDigest(layerDigest)
+	if err != nil {
+		return fmt.Errorf("could not fetch signature payload: %v", err)
+	}
+	r, err := layer.Compressed()
+	if err != nil {
+		return fmt.Errorf("could not fetch signature payload: %v", err)
+	}
+	defer r.Close()
+	payload, err := io.ReadAll(io.LimitReader(r, maxSignaturePayloadSize))
+	if err != nil {
+		return fmt.Errorf("could not read signature payload: %v", err)
+	}
+	if !v.verifySignature(payload, rawSig) {
+		return fmt.Errorf("signature does not match any trusted key")
+	}
+	// Only trust the content of the payload once the signature is verified.
+	p := simpleSigningPayload{}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("could not parse signature payload: %v", err)
+	}
+	if p.Critical.Image.DockerManifestDigest != expected.String() {
+		return fmt.Errorf("signature is for image %s", p.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+func (v *SignatureVerifier) verifySignature(payload, sig []byte) bool {
+	h := sha256.Sum256(payload)
+	for _, key := range v.keys {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, h[:], sig) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, payload, sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"istio.io/istio/pkg/util/sets"
+)
+
+const cosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+func newSigningKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// pushSignature pushes a cosign signature for the image with the given digest, claiming that signedDigest was signed.
+func pushSignature(t *testing.T, repo string, digest, signedDigest v1.Hash, key *ecdsa.PrivateKey) {
+	t.Helper()
+	payload := fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},`+
+		`"type":"cosign container image signature"},"optional":null}`, repo, signedDigest.String())
+	h := sha256.Sum256([]byte(payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1), mutate.Addendum{
+		Layer:       static.NewLayer([]byte(payload), cosignSimpleSigningMediaType),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(img, fmt.Sprintf("%s:%s-%s.sig", repo, digest.Algorithm, digest.Hex)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pushWasmImage pushes an OCI artifact variant Wasm image, and returns its digest.
+func pushWasmImage(t *testing.T, ref string, binary []byte) v1.Hash {
+	t.Helper()
+	img, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+		mutate.Addendum{Layer: static.NewLayer(binary, "application/vnd.module.wasm.content.layer.v1+wasm")},
+		mutate.Addendum{Layer: static.NewLayer([]byte("{}"), "application/vnd.module.wasm.config.v1+json")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(img, ref); err != nil {
+		t.Fatal(err)
+	}
+	d, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestNewSignatureVerifier(t *testing.T) {
+	_, pub := newSigningKey(t)
+	_, pub2 := newSigningKey(t)
+	v, err := NewSignatureVerifier(append(pub, pub2...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.keys) != 2 {
+		t.Errorf("expected 2 keys, got %d", len(v.keys))
+	}
+	// The fingerprint identifies the set of keys, whatever their order.
+	reordered, err := NewSignatureVerifier(append(pub2, pub...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reordered.fingerprint != v.fingerprint {
+		t.Errorf("expected the fingerprint not to depend on the order of the keys")
+	}
+	single, err := NewSignatureVerifier(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.fingerprint == v.fingerprint {
+		t.Errorf("expected different keys to have different fingerprints")
+	}
+	if _, err := NewSignatureVerifier([]byte("not a key")); err == nil {
+		t.Errorf("expected error for input without keys")
+	}
+	if _, err := NewSignatureVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")})); err == nil {
+		t.Errorf("expected error for malformed key")
+	}
+}
+
+func TestImageFetcher_SignatureVerification(t *testing.T) {
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, pub := newSigningKey(t)
+	otherKey, _ := newSigningKey(t)
+	verifier, err := NewSignatureVerifier(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := ImageFetcher{fetchOpts: []remote.Option{remote.WithAuth(authn.Anonymous)}, verifier: verifier}
+
+	cases := []struct {
+		name    string
+		sign    func(repo string, digest v1.Hash)
+		wantErr string
+	}{
+		{
+			name: "valid signature",
+			sign: func(repo string, digest v1.Hash) {
+				pushSignature(t, repo, digest, digest, key)
+			},
+		},
+		{
+			name:    "unsigned",
+			sign:    func(repo string, digest v1.Hash) {},
+			wantErr: "could not fetch signatures",
+		},
+		{
+			name: "untrusted key",
+			sign: func(repo string, digest v1.Hash) {
+				pushSignature(t, repo, digest, digest, otherKey)
+			},
+			wantErr: "signature does not match any trusted key",
+		},
+		{
+			name: "signature for another image",
+			sign: func(repo string, digest v1.Hash) {
+				other := digest
+				other.Hex = strings.Repeat("0", len(digest.Hex))
+				pushSignature(t, repo, digest, other, key)
+			},
+			wantErr: "signature is for image sha256:000",
+		},
+	}
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := fmt.Sprintf("%s/test/signed%d", u.Host, i)
+			exp := []byte("\x00asm signed module")
+			digest := pushWasmImage(t, repo+":v1", exp)
+			tc.sign(repo, digest)
+
+			binaryFetcher, actualDigest, err := fetcher.PrepareFetch(repo + ":v1")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				if !errors.Is(err, errSignatureVerification) {
+					t.Errorf("expected a signature verification error, got %v", err)
+				}
+				if actualDigest != "" || binaryFetcher != nil {
+					t.Errorf("expected nothing to be returned for an unverified image")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := binaryFetcher()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != string(exp) {
+				t.Errorf("got %q, want %q", actual, exp)
+			}
+			if actualDigest != digest.Hex {
+				t.Errorf("got digest %s, want %s", actualDigest, digest.Hex)
+			}
+		})
+	}
+}
+
+func TestWasmCacheSignatureVerification(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "keys.pem")
+	_, pub := newSigningKey(t)
+	if err := os.WriteFile(keysFile, pub, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewServer(registry.New())
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := fmt.Sprintf("%s/test/unsigned:v1", u.Host)
+	pushWasmImage(t, ref, []byte("\x00asm unsigned module"))
+
+	cache := NewLocalFileCache(filepath.Join(dir, "cache"), Options{
+		SignaturePublicKeysFile: keysFile,
+		InsecureRegistries:      sets.New("*"),
+	})
+	defer close(cache.stopChan)
+	opts := GetOptions{RequestTimeout: 10 * time.Second}
+	if _, err := cache.Get("oci://"+ref, opts); !errors.Is(err, errSignatureVerification) {
+		t.Errorf("expected unsigned image to be rejected, got %v", err)
+	}
+	if _, err := cache.Get(s.URL+"/module.wasm", opts); !errors.Is(err, errSignatureVerification) {
+		t.Errorf("expected http module to be rejected, got %v", err)
+	}
+
+	// A verifier that failed to load rejects everything
+	broken := NewLocalFileCache(filepath.Join(dir, "broken"), Options{SignaturePublicKeysFile: filepath.Join(dir, "missing.pem")})
+	defer close(broken.stopChan)
+	if err := broken.verifier.Verify(nil, v1.Hash{}); !errors.Is(err, errSignatureVerification) {
+		t.Errorf("expected missing keys to fail verification, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strings"
+
+	"istio.io/istio/pkg/slices"
+)
+
+// listedNACKPrefixes are the prefixes of the NACKs of Envoy listing the listeners or clusters which failed to be added
+// or updated, each followed by its rejection reason, such as "Error adding/updating listener(s) a: reason, b: reason".
+var listedNACKPrefixes = []string{
+	"Error adding/updating listener(s) ",
+	"Error adding/updating cluster(s) ",
+}
+
+// RejectedResourceNames returns the names of the resources rejected by a NACK, as far as they can be identified from
+// its error message. The listeners and clusters are listed by Envoy; other resources are identified by looking up
+// the watched resource names in the message.
+func RejectedResourceNames(message string, watched []string) []string {
+	for _, prefix := range listedNACKPrefixes {
+		if listed, ok := strings.CutPrefix(message, prefix); ok {
+			return listedResourceNames(listed)
+		}
+	}
+	return slices.Filter(watched, func(name string) bool {
+		return containsName(message, name)
+	})
+}
+
+// listedResourceNames returns the names of a list of "name: reason" items. Reasons may themselves contain separators,
+// so only the items starting with a single word followed by a colon are considered names.
+func listedResourceNames(listed string) []string {
+	var names []string
+	for _, item := range strings.Split(listed, ", ") {
+		name, _, ok := strings.Cut(item, ": ")
+		if ok && name != "" && !strings.ContainsAny(name, " \t\n") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// containsName returns whether the message contains the name as a whole word.
+func containsName(message, name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(message); {
+		j := strings.Index(message[i:], name)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(name)
+		if (start == 0 || isNameBoundary(message[start-1])) && (end == len(message) || isNameBoundary(message[end])) {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+func isNameBoundary(c byte) bool {
+	return strings.IndexByte(" \t\n'\"`,:;()[]{}", c) >= 0
+}
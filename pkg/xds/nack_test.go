@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/genproto/googleapis/rpc/status"
+
+	"istio.io/istio/pkg/model"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestRejectedResourceNames(t *testing.T) {
+	cases := []struct {
+		name    string
+		message string
+		watched []string
+		want    []string
+	}{
+		{
+			name:    "listeners",
+			message: "Error adding/updating listener(s) 0.0.0.0_80: duplicate filter chain, virtualInbound: invalid, match: x",
+			want:    []string{"0.0.0.0_80", "virtualInbound", "match"},
+		},
+		{
+			name:    "clusters",
+			message: "Error adding/updating cluster(s) outbound|80||a.default.svc.cluster.local: bad timeout",
+			want:    []string{"outbound|80||a.default.svc.cluster.local"},
+		},
+		{
+			name:    "watched",
+			message: "Only unique values for domains are permitted. Duplicate entry of domain a.com in route 8080",
+			watched: []string{"80", "8080", "a.com:80"},
+			want:    []string{"8080"},
+		},
+		{
+			name:    "unknown",
+			message: "Proto constraint validation failed",
+			watched: []string{"80"},
+			want:    nil,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, RejectedResourceNames(tt.message, tt.watched), tt.want)
+		})
+	}
+}
+
+func TestShouldRespondRejectedResources(t *testing.T) {
+	proxy := &TestProxy{
+		WatchedResources: map[string]*WatchedResource{
+			model.RouteType: {NonceSent: "nonce", ResourceNames: []string{"80", "8080"}},
+		},
+	}
+	ShouldRespond(proxy, "test", &discovery.DiscoveryRequest{
+		TypeUrl:       model.RouteType,
+		ResponseNonce: "nonce",
+		ResourceNames: []string{"80", "8080"},
+		ErrorDetail:   &status.Status{Message: "Duplicate entry of domain a.com in route 80"},
+	})
+	assert.Equal(t, proxy.WatchedResources[model.RouteType].RejectedResources, []string{"80"})
+
+	ShouldRespond(proxy, "test", &discovery.DiscoveryRequest{
+		TypeUrl:       model.RouteType,
+		ResponseNonce: "nonce",
+		ResourceNames: []string{"80", "8080"},
+	})
+	assert.Equal(t, proxy.WatchedResources[model.RouteType].RejectedResources, nil)
+	assert.Equal(t, proxy.WatchedResources[model.RouteType].LastError, "")
+}
@@ -90,6 +90,14 @@ type WatchedResource struct {
 	// LastError records the last error returned, if any. This is cleared on any successful ACK.
 	LastError string
 
+	// RejectedResources records the names of the resources rejected with the last error, when they could be
+	// identified. This is cleared on any successful ACK.
+	RejectedResources []string
+
+	// InvalidResources records the validation error of each generated resource which was not sent because it failed
+	// validation. It is replaced rather than modified, and is updated on each push.
+	InvalidResources map[string]string
+
 	// LastResources tracks the contents of the last push.
 	// This field is extremely expensive to maintain and is typically disabled
 	LastResources Resources
@@ -361,6 +369,7 @@ func ShouldRespond(w Watcher, id string, request *discovery.DiscoveryRequest) (b
 		IncrementXDSRejects(request.TypeUrl, w.GetID(), errCode.String())
 		w.UpdateWatchedResource(request.TypeUrl, func(wr *WatchedResource) *WatchedResource {
 			wr.LastError = request.ErrorDetail.GetMessage()
+			wr.RejectedResources = RejectedResourceNames(wr.LastError, wr.ResourceNames)
 			return wr
 		})
 		return false, emptyResourceDelta
@@ -405,6 +414,7 @@ func ShouldRespond(w Watcher, id string, request *discovery.DiscoveryRequest) (b
 	w.UpdateWatchedResource(request.TypeUrl, func(wr *WatchedResource) *WatchedResource {
 		// Clear last error, we got an ACK.
 		wr.LastError = ""
+		wr.RejectedResources = nil
 		previousResources = wr.ResourceNames
 		wr.NonceAcked = request.ResponseNonce
 		wr.ResourceNames = request.ResourceNames
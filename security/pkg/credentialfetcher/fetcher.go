@@ -22,10 +22,20 @@ import (
 	"istio.io/istio/security/pkg/credentialfetcher/plugin"
 )
 
-func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider string) (security.CredFetcher, error) {
+// NewCredFetcher creates a credential fetcher of the given type. audience is the resource the Azure
+// managed identity token is requested for, and is required by the Azure plugin. metadataEndpoint overrides the
+// instance metadata service address for the AWS and Azure plugins; if empty, the platform default is used.
+func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider, audience, metadataEndpoint string) (security.CredFetcher, error) {
 	switch credtype {
 	case security.GCE:
 		return plugin.CreateGCEPlugin(trustdomain, jwtPath, identityProvider), nil
+	case security.AWS:
+		return plugin.CreateAWSPlugin(metadataEndpoint, identityProvider), nil
+	case security.Azure:
+		if audience == "" {
+			return nil, fmt.Errorf("credential fetcher type %s requires an audience", credtype)
+		}
+		return plugin.CreateAzurePlugin(metadataEndpoint, audience, jwtPath, identityProvider), nil
 	case security.JWT, "":
 		// If unset, also default to JWT for backwards compatibility
 		if jwtPath == "" {
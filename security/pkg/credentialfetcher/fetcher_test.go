@@ -27,6 +27,7 @@ func TestNewCredFetcher(t *testing.T) {
 		trustdomain      string
 		jwtPath          string
 		identityProvider string
+		audience         string
 		expectedErr      string
 		expectedToken    string
 		expectedIdp      string
@@ -40,6 +41,62 @@ func TestNewCredFetcher(t *testing.T) {
 			expectedToken:    "",
 			expectedIdp:      "GoogleComputeEngine",
 		},
+		"gce without identity provider test": {
+			fetcherType:      security.GCE,
+			trustdomain:      "abc.svc.id.goog",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: "",
+			expectedErr:      "",
+			expectedToken:    "",
+			expectedIdp:      "GoogleComputeEngine",
+		},
+		"aws test": {
+			fetcherType:      security.AWS,
+			trustdomain:      "cluster.local",
+			jwtPath:          "",
+			identityProvider: "AWS",
+			expectedErr:      "",
+			expectedToken:    "",
+			expectedIdp:      "AWS",
+		},
+		"azure test": {
+			fetcherType:      security.Azure,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: "Azure",
+			audience:         "api://istio",
+			expectedErr:      "",
+			expectedToken:    "",
+			expectedIdp:      "Azure",
+		},
+		"aws without identity provider test": {
+			fetcherType:      security.AWS,
+			trustdomain:      "cluster.local",
+			jwtPath:          "",
+			identityProvider: "",
+			expectedErr:      "",
+			expectedToken:    "",
+			expectedIdp:      "AWS",
+		},
+		"azure without identity provider test": {
+			fetcherType:      security.Azure,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: "",
+			audience:         "api://istio",
+			expectedErr:      "",
+			expectedToken:    "",
+			expectedIdp:      "Azure",
+		},
+		"azure without audience test": {
+			fetcherType:      security.Azure,
+			trustdomain:      "cluster.local",
+			jwtPath:          "/var/run/secrets/tokens/istio-token",
+			identityProvider: "Azure",
+			expectedErr:      "credential fetcher type Azure requires an audience",
+			expectedToken:    "",
+			expectedIdp:      "",
+		},
 		"mock test": {
 			fetcherType:      security.Mock,
 			trustdomain:      "",
@@ -66,7 +123,7 @@ func TestNewCredFetcher(t *testing.T) {
 		t.Run(id, func(t *testing.T) {
 			t.Parallel()
 			cf, err := NewCredFetcher(
-				tc.fetcherType, tc.trustdomain, tc.jwtPath, tc.identityProvider)
+				tc.fetcherType, tc.trustdomain, tc.jwtPath, tc.identityProvider, tc.audience, "")
 			if cf != nil {
 				defer cf.Stop()
 			}
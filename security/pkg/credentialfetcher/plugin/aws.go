@@ -0,0 +1,129 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is AWS plugin of credentialfetcher.
+
+package plugin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/http"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+)
+
+var awscredLog = log.RegisterScope("awscred", "AWS credential fetcher for istio agent")
+
+const (
+	// DefaultAWSMetadataEndpoint is the link-local address of the EC2 instance metadata service.
+	DefaultAWSMetadataEndpoint = "http://169.254.169.254"
+
+	awsTokenPath     = "/latest/api/token"
+	awsDocumentPath  = "/latest/dynamic/instance-identity/document"
+	awsSignaturePath = "/latest/dynamic/instance-identity/signature"
+
+	awsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	awsTokenHeader    = "X-aws-ec2-metadata-token"
+)
+
+// awsMetadataTimeout bounds each request to the instance metadata service.
+var awsMetadataTimeout = 2 * time.Second
+
+// AWSPlugin is the plugin object.
+// The istiod CA verifies instance identity documents when it is configured with AWS_IDENTITY_RULE.
+type AWSPlugin struct {
+	// endpoint is the base URL of the instance metadata service.
+	endpoint string
+
+	// identity provider
+	identityProvider string
+
+	// The signed instance identity document does not change for the lifetime of the instance, so it
+	// is fetched once and cached.
+	credential string
+	mutex      sync.Mutex
+}
+
+var _ security.CredFetcher = &AWSPlugin{}
+
+// CreateAWSPlugin creates an AWS credential fetcher plugin. If endpoint is empty, the default EC2
+// instance metadata service endpoint is used. If identityProvider is empty, it defaults to "AWS".
+// Return the pointer to the created plugin.
+func CreateAWSPlugin(endpoint, identityProvider string) *AWSPlugin {
+	if endpoint == "" {
+		endpoint = DefaultAWSMetadataEndpoint
+	}
+	if identityProvider == "" {
+		identityProvider = security.AWS
+	}
+	return &AWSPlugin{
+		endpoint:         strings.TrimSuffix(endpoint, "/"),
+		identityProvider: identityProvider,
+	}
+}
+
+// GetPlatformCredential fetches the EC2 instance identity document and its signature from the instance
+// metadata service, using an IMDSv2 session token.
+// The returned credential is the base64 encoded document and the base64 encoded SHA256 RSA signature of the
+// document, joined by a ".". The signature can be verified against the AWS public certificate for the
+// instance's region.
+// Note: this function only works in an EC2 environment, or against a metadata server stand-in.
+func (p *AWSPlugin) GetPlatformCredential() (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.credential != "" {
+		return p.credential, nil
+	}
+	token, err := http.PUT(p.endpoint+awsTokenPath, awsMetadataTimeout, map[string]string{
+		awsTokenTTLHeader: "60",
+	})
+	if err != nil {
+		awscredLog.Errorf("Failed to get session token from metadata server: %v", err)
+		return "", fmt.Errorf("failed to get IMDSv2 session token: %v", err)
+	}
+	headers := map[string]string{
+		awsTokenHeader: token.String(),
+	}
+	doc, err := http.GET(p.endpoint+awsDocumentPath, awsMetadataTimeout, headers)
+	if err != nil {
+		awscredLog.Errorf("Failed to get instance identity document from metadata server: %v", err)
+		return "", fmt.Errorf("failed to get instance identity document: %v", err)
+	}
+	sig, err := http.GET(p.endpoint+awsSignaturePath, awsMetadataTimeout, headers)
+	if err != nil {
+		awscredLog.Errorf("Failed to get instance identity signature from metadata server: %v", err)
+		return "", fmt.Errorf("failed to get instance identity signature: %v", err)
+	}
+	// The signature is returned as wrapped base64; unwrap it so it can be sent as a header value.
+	signature := strings.Join(strings.Fields(sig.String()), "")
+	if doc.Len() == 0 || signature == "" {
+		return "", fmt.Errorf("metadata server returned an empty instance identity document")
+	}
+	p.credential = base64.StdEncoding.EncodeToString(doc.Bytes()) + "." + signature
+	awscredLog.Debugf("Got AWS instance identity document: %d", len(p.credential))
+	return p.credential, nil
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *AWSPlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
+
+func (p *AWSPlugin) Stop() {}
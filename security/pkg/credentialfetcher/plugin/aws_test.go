@@ -0,0 +1,111 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/atomic"
+)
+
+// fakeAWSMetadataServer serves the subset of the EC2 instance metadata service used by AWSPlugin.
+func fakeAWSMetadataServer(t *testing.T, document, signature string, identityCalls *atomic.Int32) *httptest.Server {
+	const sessionToken = "session-token"
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == awsTokenPath:
+			if r.Header.Get(awsTokenTTLHeader) == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, sessionToken)
+		case r.Method == http.MethodGet && (r.URL.Path == awsDocumentPath || r.URL.Path == awsSignaturePath):
+			if r.Header.Get(awsTokenHeader) != sessionToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body := document
+			if r.URL.Path == awsSignaturePath {
+				body = signature
+			} else {
+				identityCalls.Inc()
+			}
+			if body == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestAWSPlugin(t *testing.T) {
+	testCases := map[string]struct {
+		document      string
+		signature     string
+		expectedToken string
+		expectedErr   string
+	}{
+		"get instance identity document": {
+			document:      `{"accountId":"123456789012"}`,
+			signature:     "dGhlIHNpZ25hdHVyZSBv\nZiB0aGUgZG9jdW1lbnQ=\n",
+			expectedToken: "eyJhY2NvdW50SWQiOiIxMjM0NTY3ODkwMTIifQ==.dGhlIHNpZ25hdHVyZSBvZiB0aGUgZG9jdW1lbnQ=",
+		},
+		"metadata server error": {
+			expectedErr: "failed to get instance identity document: unexpected status 404",
+		},
+		"missing signature": {
+			document:    `{"accountId":"123456789012"}`,
+			expectedErr: "failed to get instance identity signature: unexpected status 404",
+		},
+	}
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			calls := atomic.NewInt32(0)
+			ms := fakeAWSMetadataServer(t, tc.document, tc.signature, calls)
+			p := CreateAWSPlugin(ms.URL, "AWS")
+			defer p.Stop()
+			for i := 0; i < 3; i++ {
+				token, err := p.GetPlatformCredential()
+				if tc.expectedErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+						t.Fatalf("GetPlatformCredential() returns err: %v, want: %v", err, tc.expectedErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("GetPlatformCredential() returns unexpected err: %v", err)
+				}
+				if token != tc.expectedToken {
+					t.Fatalf("GetPlatformCredential() returns token: %s, want: %s", token, tc.expectedToken)
+				}
+			}
+			if tc.expectedErr == "" && calls.Load() != 1 {
+				t.Fatalf("metadata server receives %d identity calls, want 1", calls.Load())
+			}
+			if idp := p.GetIdentityProvider(); idp != "AWS" {
+				t.Fatalf("GetIdentityProvider() returns %s, want AWS", idp)
+			}
+		})
+	}
+}
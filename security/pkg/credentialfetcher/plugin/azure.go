@@ -0,0 +1,146 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is Azure plugin of credentialfetcher.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/http"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+)
+
+var azurecredLog = log.RegisterScope("azurecred", "Azure credential fetcher for istio agent")
+
+const (
+	// DefaultAzureMetadataEndpoint is the link-local address of the Azure instance metadata service.
+	DefaultAzureMetadataEndpoint = "http://169.254.169.254"
+
+	azureTokenPath       = "/metadata/identity/oauth2/token"
+	azureTokenAPIVersion = "2018-02-01"
+)
+
+// azureMetadataTimeout bounds each request to the instance metadata service.
+var azureMetadataTimeout = 2 * time.Second
+
+// AzurePlugin is the plugin object.
+// The istiod CA verifies managed identity tokens when it is configured with AZURE_IDENTITY_RULE.
+type AzurePlugin struct {
+	// endpoint is the base URL of the instance metadata service.
+	endpoint string
+
+	// resource is the audience of the managed identity token.
+	resource string
+
+	// The location to save the identity token. Optional.
+	jwtPath string
+
+	// identity provider
+	identityProvider string
+
+	tokenCache string
+	expiry     time.Time
+	// mutex lock is required to avoid race condition when updating token file and token cache.
+	tokenMutex sync.Mutex
+}
+
+var _ security.CredFetcher = &AzurePlugin{}
+
+// azureTokenResponse is the subset of the managed identity token response that we use.
+type azureTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	// ExpiresOn is the token expiry, in seconds since epoch. Azure encodes it as a string.
+	ExpiresOn string `json:"expires_on"`
+}
+
+// CreateAzurePlugin creates an Azure credential fetcher plugin, which obtains managed identity tokens
+// for the given resource (audience). If endpoint is empty, the default Azure instance metadata service
+// endpoint is used. If identityProvider is empty, it defaults to "Azure". Return the pointer to the created plugin.
+func CreateAzurePlugin(endpoint, resource, jwtPath, identityProvider string) *AzurePlugin {
+	if endpoint == "" {
+		endpoint = DefaultAzureMetadataEndpoint
+	}
+	if identityProvider == "" {
+		identityProvider = security.Azure
+	}
+	return &AzurePlugin{
+		endpoint:         strings.TrimSuffix(endpoint, "/"),
+		resource:         resource,
+		jwtPath:          jwtPath,
+		identityProvider: identityProvider,
+	}
+}
+
+// GetPlatformCredential returns the managed identity token of the VM, fetching a new one from the
+// instance metadata service when the cached token is missing or close to expiry.
+// If jwtPath is set, the token is also written there so it can be used by the Envoy STS client.
+// Note: this function only works in an Azure VM environment, or against a metadata server stand-in.
+func (p *AzurePlugin) GetPlatformCredential() (string, error) {
+	p.tokenMutex.Lock()
+	defer p.tokenMutex.Unlock()
+
+	if p.tokenCache != "" && time.Now().Before(p.expiry.Add(-gracePeriod)) {
+		return p.tokenCache, nil
+	}
+	q := url.Values{}
+	q.Set("api-version", azureTokenAPIVersion)
+	q.Set("resource", p.resource)
+	resp, err := http.GET(p.endpoint+azureTokenPath+"?"+q.Encode(), azureMetadataTimeout, map[string]string{
+		"Metadata": "true",
+	})
+	if err != nil {
+		azurecredLog.Errorf("Failed to get managed identity token from metadata server: %v", err)
+		return "", fmt.Errorf("failed to get managed identity token: %v", err)
+	}
+	tr := azureTokenResponse{}
+	if err := json.Unmarshal(resp.Bytes(), &tr); err != nil {
+		return "", fmt.Errorf("failed to parse managed identity token response: %v", err)
+	}
+	if tr.AccessToken == "" {
+		return "", fmt.Errorf("metadata server returned an empty managed identity token")
+	}
+	expiresOn, err := strconv.ParseInt(tr.ExpiresOn, 10, 64)
+	if err != nil {
+		// Without a known expiry, fetch a new token on every call.
+		azurecredLog.Warnf("Failed to parse managed identity token expiry %q: %v", tr.ExpiresOn, err)
+		expiresOn = 0
+	}
+	p.tokenCache = tr.AccessToken
+	p.expiry = time.Unix(expiresOn, 0)
+	azurecredLog.Debugf("Got Azure managed identity token: %d, expires at %v", len(tr.AccessToken), p.expiry)
+	if p.jwtPath != "" {
+		if err := os.WriteFile(p.jwtPath, []byte(tr.AccessToken), 0o640); err != nil {
+			azurecredLog.Errorf("Encountered error when writing managed identity token: %v", err)
+			return "", err
+		}
+	}
+	return p.tokenCache, nil
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *AzurePlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
+
+func (p *AzurePlugin) Stop() {}
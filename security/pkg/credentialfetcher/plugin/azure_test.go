@@ -0,0 +1,123 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// fakeAzureMetadataServer serves the managed identity endpoint of the Azure instance metadata service.
+// Each token is valid for the given lifetime.
+func fakeAzureMetadataServer(t *testing.T, resource string, lifetime time.Duration, calls *atomic.Int32) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != azureTokenPath || r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("resource") != resource {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n := calls.Inc()
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": fmt.Sprintf("%s%d", fakeTokenPrefix, n),
+			"expires_on":   strconv.FormatInt(time.Now().Add(lifetime).Unix(), 10),
+			"resource":     resource,
+			"token_type":   "Bearer",
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestAzurePlugin(t *testing.T) {
+	testCases := map[string]struct {
+		resource      string
+		lifetime      time.Duration
+		jwtPath       bool
+		expectedToken string
+		expectedCalls int32
+		expectedErr   string
+	}{
+		"cached token": {
+			resource:      "api://istio",
+			lifetime:      time.Hour,
+			jwtPath:       true,
+			expectedToken: fakeTokenPrefix + "1",
+			expectedCalls: 1,
+		},
+		"token in grace period is refreshed": {
+			resource:      "api://istio",
+			lifetime:      gracePeriod / 2,
+			expectedToken: fakeTokenPrefix + "3",
+			expectedCalls: 3,
+		},
+		"wrong resource": {
+			resource:    "",
+			expectedErr: "failed to get managed identity token: unexpected status 400",
+		},
+	}
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			calls := atomic.NewInt32(0)
+			ms := fakeAzureMetadataServer(t, "api://istio", tc.lifetime, calls)
+			jwtPath := ""
+			if tc.jwtPath {
+				jwtPath = filepath.Join(t.TempDir(), "istio-token")
+			}
+			p := CreateAzurePlugin(ms.URL, tc.resource, jwtPath, "Azure")
+			defer p.Stop()
+			var token string
+			var err error
+			for i := 0; i < 3; i++ {
+				token, err = p.GetPlatformCredential()
+			}
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("GetPlatformCredential() returns err: %v, want: %v", err, tc.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPlatformCredential() returns unexpected err: %v", err)
+			}
+			if token != tc.expectedToken {
+				t.Fatalf("GetPlatformCredential() returns token: %s, want: %s", token, tc.expectedToken)
+			}
+			if calls.Load() != tc.expectedCalls {
+				t.Fatalf("metadata server receives %d calls, want %d", calls.Load(), tc.expectedCalls)
+			}
+			if jwtPath != "" {
+				got, err := getJWTFromFile(jwtPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tc.expectedToken {
+					t.Fatalf("%s has token %s, want %s", jwtPath, got, tc.expectedToken)
+				}
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"cloud.google.com/go/compute/metadata"
 
 	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/util"
 )
 
@@ -68,8 +69,12 @@ type GCEPlugin struct {
 	tokenMutex sync.RWMutex
 }
 
-// CreateGCEPlugin creates a Google credential fetcher plugin. Return the pointer to the created plugin.
+// CreateGCEPlugin creates a Google credential fetcher plugin. If identityProvider is empty, it defaults to
+// "GoogleComputeEngine". Return the pointer to the created plugin.
 func CreateGCEPlugin(audience, jwtPath, identityProvider string) *GCEPlugin {
+	if identityProvider == "" {
+		identityProvider = security.GCE
+	}
 	p := &GCEPlugin{
 		aud:              audience,
 		jwtPath:          jwtPath,
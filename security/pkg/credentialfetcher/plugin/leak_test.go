@@ -23,4 +23,5 @@ func TestMain(m *testing.M) {
 	// metadata server code against a fake metadata server. We do not control the client, and cannot
 	// configure it to exit early, retry faster, etc - its all fixed. As a result, we don't have a good
 	// way to shut it down if it is still retrying in the background.
+	m.Run()
 }
@@ -19,6 +19,7 @@ import (
 	"encoding/asn1"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	clientset "k8s.io/client-go/kubernetes"
@@ -39,6 +40,57 @@ type RegistrationAuthority interface {
 	GetRootCertFromMeshConfig(signerName string) ([]byte, error)
 }
 
+// meshConfigCACertificates holds the root certificates of external CA signers, as configured by
+// caCertificates in mesh config. It implements the mesh config part of RegistrationAuthority.
+type meshConfigCACertificates struct {
+	// caCertificates maps a comma separated list of signers to a PEM encoded root certificate.
+	caCertificates map[string]string
+	// mutex protects the R/W to caCertificates.
+	mutex sync.RWMutex
+}
+
+// SetCACertificatesFromMeshConfig sets the CACertificates using the ones from mesh config
+func (m *meshConfigCACertificates) SetCACertificatesFromMeshConfig(caCertificates []*meshconfig.MeshConfig_CertificateData) {
+	m.mutex.Lock()
+	if m.caCertificates == nil {
+		m.caCertificates = make(map[string]string)
+	}
+	for _, pemCert := range caCertificates {
+		// TODO:  take care of spiffe bundle format as well
+		cert := pemCert.GetPem()
+		certSigners := pemCert.CertSigners
+		if len(certSigners) != 0 {
+			certSigner := strings.Join(certSigners, ",")
+			if cert != "" {
+				m.caCertificates[certSigner] = cert
+			}
+		}
+	}
+	m.mutex.Unlock()
+}
+
+// GetRootCertFromMeshConfig returns the root cert for the specific signer in mesh config
+func (m *meshConfigCACertificates) GetRootCertFromMeshConfig(signerName string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	caCertificates := m.caCertificates
+	if len(caCertificates) == 0 {
+		return nil, fmt.Errorf("no caCertificates defined in mesh config")
+	}
+	for signers, caCertificate := range caCertificates {
+		signerList := strings.Split(signers, ",")
+		if len(signerList) == 0 {
+			continue
+		}
+		for _, signer := range signerList {
+			if signer == signerName {
+				return []byte(caCertificate), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("failed to find root cert for signer: %v in mesh config", signerName)
+}
+
 // CaExternalType : Type of External CA integration
 type CaExternalType string
 
@@ -62,12 +114,25 @@ type IstioRAOptions struct {
 	TrustDomain string
 	// CertSignerDomain info
 	CertSignerDomain string
+	// ESTServerURL : Base URL of the EST server, including the optional CA label, such as
+	// https://est.example.com/.well-known/est/istio
+	ESTServerURL string
+	// ESTServerCAFile : File containing the PEM encoded CA certificate used to verify the EST server.
+	// If unset, the system roots are used
+	ESTServerCAFile string
+	// ESTClientCertFile : File containing the PEM encoded client certificate used to authenticate to the EST server
+	ESTClientCertFile string
+	// ESTClientKeyFile : File containing the PEM encoded private key of ESTClientCertFile
+	ESTClientKeyFile string
 }
 
 const (
 	// ExtCAK8s : Integrate with external CA using k8s CSR API
 	ExtCAK8s CaExternalType = "ISTIOD_RA_KUBERNETES_API"
 
+	// ExtCAEST : Integrate with external CA using the EST protocol (RFC 7030)
+	ExtCAEST CaExternalType = "ISTIOD_RA_EST"
+
 	// DefaultExtCACertDir : Location of external CA certificate
 	DefaultExtCACertDir string = "./etc/external-ca-cert"
 )
@@ -166,12 +231,19 @@ func compareCSRs(orgCSR, genCSR *x509.CertificateRequest) bool {
 // NewIstioRA is a factory method that returns an RA that implements the RegistrationAuthority functionality.
 // the caOptions defines the external provider
 func NewIstioRA(opts *IstioRAOptions) (RegistrationAuthority, error) {
-	if opts.ExternalCAType == ExtCAK8s {
+	switch opts.ExternalCAType {
+	case ExtCAK8s:
 		istioRA, err := NewKubernetesRA(opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create an K8s CA: %v", err)
 		}
 		return istioRA, err
+	case ExtCAEST:
+		istioRA, err := NewESTRA(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create an EST CA: %v", err)
+		}
+		return istioRA, err
 	}
 	return nil, fmt.Errorf("invalid CA Name %s", opts.ExternalCAType)
 }
@@ -0,0 +1,268 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/ca"
+	raerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	estCACertsPath      = "/cacerts"
+	estSimpleEnrollPath = "/simpleenroll"
+
+	// estRequestTimeout bounds each request to the EST server.
+	estRequestTimeout = 30 * time.Second
+)
+
+// ESTRA integrates with an external CA using the Enrollment over Secure Transport protocol (RFC 7030).
+type ESTRA struct {
+	meshConfigCACertificates
+	client        *http.Client
+	serverURL     string
+	keyCertBundle *util.KeyCertBundle
+	raOpts        *IstioRAOptions
+}
+
+// NewESTRA : Create a RA that interfaces with an EST server. The root and intermediate certificates of
+// the external CA are read from the CaCertFile if set, and otherwise fetched from the EST /cacerts endpoint.
+func NewESTRA(raOpts *IstioRAOptions) (*ESTRA, error) {
+	if raOpts.ESTServerURL == "" {
+		return nil, raerror.NewError(raerror.CAIllegalConfig, fmt.Errorf("EST server URL is required for EST RA"))
+	}
+	client, err := newESTClient(raOpts)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAInitFail, err)
+	}
+	r := &ESTRA{
+		client:    client,
+		serverURL: strings.TrimSuffix(raOpts.ESTServerURL, "/"),
+		raOpts:    raOpts,
+	}
+	if raOpts.CaCertFile != "" {
+		r.keyCertBundle, err = util.NewKeyCertBundleWithRootCertFromFile(raOpts.CaCertFile)
+		if err != nil {
+			return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("error processing Certificate Bundle for EST RA"))
+		}
+		return r, nil
+	}
+	certs, err := r.caCerts()
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("failed to fetch CA certificates from EST server: %v", err))
+	}
+	var chain, root []byte
+	for _, c := range certs {
+		if isSelfSigned(c) {
+			root = append(root, certToPem(c)...)
+		} else {
+			chain = append(chain, certToPem(c)...)
+		}
+	}
+	r.keyCertBundle = util.NewKeyCertBundleFromPem(nil, nil, chain, root)
+	return r, nil
+}
+
+func newESTClient(raOpts *IstioRAOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if raOpts.ESTServerCAFile != "" {
+		caCert, err := os.ReadFile(raOpts.ESTServerCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read EST server CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse EST server CA certificate %s", raOpts.ESTServerCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if raOpts.ESTClientCertFile != "" || raOpts.ESTClientKeyFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(raOpts.ESTClientCertFile, raOpts.ESTClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load EST client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return &http.Client{
+		Timeout:   estRequestTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
+
+// caCerts fetches the current CA certificates from the EST server (RFC 7030 section 4.1).
+func (r *ESTRA) caCerts() ([]*x509.Certificate, error) {
+	resp, err := r.client.Get(r.serverURL + estCACertsPath)
+	if err != nil {
+		return nil, err
+	}
+	return readESTCertificates(resp)
+}
+
+// simpleEnroll sends the CSR to the EST server and returns the issued certificates (RFC 7030 section 4.2).
+func (r *ESTRA) simpleEnroll(csrPEM []byte) ([]*x509.Certificate, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode CSR")
+	}
+	req, err := http.NewRequest(http.MethodPost, r.serverURL+estSimpleEnrollPath,
+		strings.NewReader(base64.StdEncoding.EncodeToString(block.Bytes)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/pkcs10")
+	req.Header.Set("Content-Transfer-Encoding", "base64")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return readESTCertificates(resp)
+}
+
+// readESTCertificates decodes a base64 encoded certs-only PKCS#7 EST response.
+func readESTCertificates(resp *http.Response) ([]*x509.Certificate, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(body)), ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode EST response: %v", err)
+	}
+	certs, err := parseCertsOnlyPKCS7(der)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("EST response contains no certificates")
+	}
+	return certs, nil
+}
+
+// pkcs7ContentInfo and pkcs7SignedData are the subset of RFC 2315 needed to read a certs-only
+// ("degenerate") SignedData message, as used by EST.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+var oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+func parseCertsOnlyPKCS7(der []byte) ([]*x509.Certificate, error) {
+	ci := pkcs7ContentInfo{}
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 content info: %v", err)
+	}
+	if !ci.ContentType.Equal(oidPKCS7SignedData) {
+		return nil, fmt.Errorf("unexpected PKCS#7 content type %v", ci.ContentType)
+	}
+	sd := pkcs7SignedData{}
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 signed data: %v", err)
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#7 certificates: %v", err)
+	}
+	return certs, nil
+}
+
+func isSelfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawIssuer, c.RawSubject) && c.CheckSignatureFrom(c) == nil
+}
+
+func certToPem(c *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+}
+
+// Sign takes a PEM-encoded CSR and cert opts, and returns a certificate signed by the EST server.
+// The lifetime of the certificate is decided by the external CA; EST does not allow requesting one.
+func (r *ESTRA) Sign(csrPEM []byte, certOpts ca.CertOpts) ([]byte, error) {
+	if _, err := preSign(r.raOpts, csrPEM, certOpts.SubjectIDs, certOpts.TTL, certOpts.ForCA); err != nil {
+		return nil, err
+	}
+	certs, err := r.simpleEnroll(csrPEM)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, fmt.Errorf("EST enrollment failed: %v", err))
+	}
+	var certChain []byte
+	for _, c := range certs {
+		certChain = append(certChain, certToPem(c)...)
+	}
+	return certChain, nil
+}
+
+// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
+// If the EST server only returns the leaf certificate, the intermediate certificates from /cacerts are
+// appended. The chain is verified against the external CA root cert, which is appended if not present.
+func (r *ESTRA) SignWithCertChain(csrPEM []byte, certOpts ca.CertOpts) ([]string, error) {
+	cert, err := r.Sign(csrPEM, certOpts)
+	if err != nil {
+		return nil, err
+	}
+	chain, _, err := util.ParsePemEncodedCertificateChain(cert)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, err)
+	}
+	if len(chain) == 1 {
+		cert = append(cert, r.GetCAKeyCertBundle().GetCertChainPem()...)
+	}
+	respCertChain := []string{string(cert)}
+	rootCert := r.GetCAKeyCertBundle().GetRootCertPem()
+	if len(rootCert) == 0 {
+		return nil, raerror.NewError(raerror.CSRError, fmt.Errorf("failed to find root cert of the EST CA"))
+	}
+	if verifyErr := util.VerifyCertificate(nil, cert, rootCert, nil); verifyErr != nil {
+		return nil, raerror.NewError(raerror.CSRError, fmt.Errorf("signed cert-chain is invalid (%v)", verifyErr))
+	}
+	root, err := util.ParsePemEncodedCertificate(rootCert)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CSRError, err)
+	}
+	if !chain[len(chain)-1].Equal(root) {
+		respCertChain = append(respCertChain, string(rootCert))
+	}
+	return respCertChain, nil
+}
+
+// GetCAKeyCertBundle returns the KeyCertBundle for the CA.
+func (r *ESTRA) GetCAKeyCertBundle() *util.KeyCertBundle {
+	return r.keyCertBundle
+}
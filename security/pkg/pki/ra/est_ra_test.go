@@ -0,0 +1,227 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/security/pkg/pki/ca"
+	raerror "istio.io/istio/security/pkg/pki/error"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+// fakeESTServer is a minimal EST server backed by a root and an intermediate CA.
+type fakeESTServer struct {
+	rootPEM  []byte
+	root     *x509.Certificate
+	inter    *x509.Certificate
+	interKey any
+	enrolls  *atomic.Int32
+	failing  bool
+}
+
+func newFakeESTServer(t *testing.T) (*fakeESTServer, *httptest.Server) {
+	t.Helper()
+	rootPEM, rootKeyPEM, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Org:          "EST Root",
+		TTL:          time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := pkiutil.ParsePemEncodedCertificate(rootPEM)
+	rootKey, _ := pkiutil.ParsePemEncodedKey(rootKeyPEM)
+	interPEM, interKeyPEM, err := pkiutil.GenCertKeyFromOptions(pkiutil.CertOptions{
+		Org:        "EST Intermediate",
+		TTL:        time.Hour,
+		IsCA:       true,
+		SignerCert: root,
+		SignerPriv: rootKey,
+		RSAKeySize: 2048,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inter, _ := pkiutil.ParsePemEncodedCertificate(interPEM)
+	interKey, _ := pkiutil.ParsePemEncodedKey(interKeyPEM)
+	s := &fakeESTServer{rootPEM: rootPEM, root: root, inter: inter, interKey: interKey, enrolls: atomic.NewInt32(0)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(estCACertsPath, func(w http.ResponseWriter, r *http.Request) {
+		writePKCS7(t, w, inter, root)
+	})
+	mux.HandleFunc(estSimpleEnrollPath, func(w http.ResponseWriter, r *http.Request) {
+		s.enrolls.Inc()
+		if s.failing {
+			http.Error(w, "enrollment rejected", http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		der, err := base64.StdEncoding.DecodeString(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		leaf, err := pkiutil.GenCertFromCSR(csr, inter, csr.PublicKey, interKey, []string{testCsrHostName}, time.Hour, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		leafCert, _ := x509.ParseCertificate(leaf)
+		writePKCS7(t, w, leafCert)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// writePKCS7 writes a base64 encoded certs-only PKCS#7 message holding the certificates.
+func writePKCS7(t *testing.T, w http.ResponseWriter, certs ...*x509.Certificate) {
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	dataContent, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+	}{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      asn1.RawValue{FullBytes: dataContent},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      emptySet,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Header().Set("Content-Type", "application/pkcs7-mime")
+	_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(der)))
+}
+
+func createFakeESTRA(url, caCertFile string) (*ESTRA, error) {
+	return NewESTRA(&IstioRAOptions{
+		ExternalCAType: ExtCAEST,
+		DefaultCertTTL: 30 * time.Minute,
+		MaxCertTTL:     time.Hour,
+		CaCertFile:     caCertFile,
+		ESTServerURL:   url,
+	})
+}
+
+func TestNewESTRA(t *testing.T) {
+	if _, err := createFakeESTRA("", ""); err == nil {
+		t.Fatalf("expected error for missing EST server URL")
+	}
+
+	s, srv := newFakeESTServer(t)
+	r, err := createFakeESTRA(srv.URL+"/", "")
+	if err != nil {
+		t.Fatalf("failed to create EST RA: %v", err)
+	}
+	if got := string(r.GetCAKeyCertBundle().GetRootCertPem()); got != string(s.rootPEM) {
+		t.Errorf("root cert from /cacerts mismatch, got %s", got)
+	}
+	if got := string(r.GetCAKeyCertBundle().GetCertChainPem()); got != string(certToPem(s.inter)) {
+		t.Errorf("cert chain from /cacerts mismatch, got %s", got)
+	}
+}
+
+func TestESTSignWithCertChain(t *testing.T) {
+	s, srv := newFakeESTServer(t)
+	r, err := createFakeESTRA(srv.URL, "")
+	if err != nil {
+		t.Fatalf("failed to create EST RA: %v", err)
+	}
+	opts := ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Minute}
+
+	chain, err := r.SignWithCertChain(createDefaultFakeCsr(t), opts)
+	if err != nil {
+		t.Fatalf("EST signing failed: %v", err)
+	}
+	if len(chain) != 2 {
+		t.Fatalf("expected leaf chain and root, got %d entries", len(chain))
+	}
+	certs, _, err := pkiutil.ParsePemEncodedCertificateChain([]byte(chain[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 || !certs[1].Equal(s.inter) {
+		t.Errorf("expected leaf followed by intermediate, got %d certs", len(certs))
+	}
+	if chain[1] != string(s.rootPEM) {
+		t.Errorf("expected root cert to be appended")
+	}
+
+	// Requests that fail local validation must not reach the EST server.
+	enrolls := s.enrolls.Load()
+	if _, err := r.SignWithCertChain(createDefaultFakeCsr(t), ca.CertOpts{SubjectIDs: opts.SubjectIDs, ForCA: true}); err == nil {
+		t.Errorf("expected CA certificate request to be rejected")
+	}
+	if _, err := r.SignWithCertChain(createFakeCsr(t, "Invalid-Org"), opts); err == nil {
+		t.Errorf("expected CSR with invalid org to be rejected")
+	}
+	if got := s.enrolls.Load(); got != enrolls {
+		t.Errorf("expected no enrollment for rejected CSRs, got %d", got-enrolls)
+	}
+
+	s.failing = true
+	_, err = r.SignWithCertChain(createDefaultFakeCsr(t), opts)
+	if err == nil || err.(*raerror.Error).ErrorType() != "CERT_GEN_ERROR" {
+		t.Errorf("expected CertGenError for rejected enrollment, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "enrollment rejected") {
+		t.Errorf("expected server error to be surfaced, got %v", err)
+	}
+}
+
+func TestESTSignWithMismatchedRoot(t *testing.T) {
+	_, srv := newFakeESTServer(t)
+	r, err := createFakeESTRA(srv.URL, TestCACertFile)
+	if err != nil {
+		t.Fatalf("failed to create EST RA: %v", err)
+	}
+	if _, err := r.SignWithCertChain(createDefaultFakeCsr(t), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Minute}); err == nil {
+		t.Errorf("expected verification against a mismatched root cert to fail")
+	}
+}
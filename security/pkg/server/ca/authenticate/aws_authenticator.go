@@ -0,0 +1,139 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const (
+	AWSAuthenticatorType = "AWSAuthenticator"
+)
+
+// AWSIdentityRule configures the authentication of EC2 instance identity documents.
+type AWSIdentityRule struct {
+	// CertificatesFile is the PEM file of the AWS public certificates that sign the instance identity documents
+	// in the regions of the instances.
+	CertificatesFile string `json:"certificates_file"`
+	// Accounts maps the IDs of the AWS accounts allowed to authenticate to the "<namespace>/<service account>"
+	// identity of their instances.
+	Accounts map[string]string `json:"accounts"`
+}
+
+// AWSAuthenticator authenticates EC2 instances with their instance identity document, which the AWS credential
+// fetcher of the proxy sends as "<base64 document>.<base64 signature>".
+// The document does not expire, so it authenticates the instance only as long as it is kept secret.
+type AWSAuthenticator struct {
+	// holder of a mesh configuration for dynamically updating trust domain
+	meshHolder   mesh.Holder
+	certificates []*x509.Certificate
+	accounts     map[string]serviceAccount
+}
+
+var _ security.Authenticator = &AWSAuthenticator{}
+
+// awsIdentityDocument is the subset of the instance identity document that we use.
+type awsIdentityDocument struct {
+	AccountID  string `json:"accountId"`
+	InstanceID string `json:"instanceId"`
+}
+
+// NewAWSAuthenticator creates an authenticator of the instance identity documents signed by the certificates of
+// the rule.
+func NewAWSAuthenticator(rule *AWSIdentityRule, meshHolder mesh.Holder) (*AWSAuthenticator, error) {
+	certsPEM, err := os.ReadFile(rule.CertificatesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the AWS certificates: %v", err)
+	}
+	var certificates []*x509.Certificate
+	for block, rest := pem.Decode(certsPEM); block != nil; block, rest = pem.Decode(rest) {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the AWS certificates: %v", err)
+		}
+		certificates = append(certificates, cert)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no AWS certificate found in %s", rule.CertificatesFile)
+	}
+	accounts, err := parseServiceAccounts(rule.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSAuthenticator{
+		meshHolder:   meshHolder,
+		certificates: certificates,
+		accounts:     accounts,
+	}, nil
+}
+
+// Authenticate verifies the instance identity document of the caller, and returns the identity of its account.
+func (a *AWSAuthenticator) Authenticate(authRequest security.AuthContext) (*security.Caller, error) {
+	_, credential, err := extractBearerToken(authRequest)
+	if err != nil {
+		return nil, fmt.Errorf("instance identity document extraction error: %v", err)
+	}
+	encodedDoc, encodedSig, ok := strings.Cut(credential, ".")
+	if !ok {
+		return nil, fmt.Errorf("credential is not an instance identity document")
+	}
+	doc, err := base64.StdEncoding.DecodeString(encodedDoc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the instance identity document: %v", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the instance identity signature: %v", err)
+	}
+	if !a.verify(doc, sig) {
+		return nil, fmt.Errorf("failed to verify the instance identity document")
+	}
+	identity := awsIdentityDocument{}
+	if err := json.Unmarshal(doc, &identity); err != nil {
+		return nil, fmt.Errorf("failed to parse the instance identity document: %v", err)
+	}
+	sa, ok := a.accounts[identity.AccountID]
+	if !ok {
+		return nil, fmt.Errorf("instance %s of AWS account %q is not allowed to authenticate", identity.InstanceID, identity.AccountID)
+	}
+	return &security.Caller{
+		AuthSource: security.AuthSourceIDToken,
+		Identities: []string{spiffe.MustGenSpiffeURI(a.meshHolder.Mesh(), sa.namespace, sa.name)},
+	}, nil
+}
+
+// verify returns true if the document is signed by one of the AWS certificates.
+func (a *AWSAuthenticator) verify(doc, sig []byte) bool {
+	for _, cert := range a.certificates {
+		if cert.CheckSignature(x509.SHA256WithRSA, doc, sig) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AWSAuthenticator) AuthenticatorType() string {
+	return AWSAuthenticatorType
+}
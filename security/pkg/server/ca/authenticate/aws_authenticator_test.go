@@ -0,0 +1,192 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+)
+
+// generateAWSCertificate returns a self-signed certificate standing in for the AWS public certificate of a region.
+func generateAWSCertificate(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate a private key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Amazon Web Services LLC"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create a certificate: %v", err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// signIdentityDocument returns the credential sent by the AWS credential fetcher for the document.
+func signIdentityDocument(t *testing.T, key *rsa.PrivateKey, doc string) string {
+	digest := sha256.Sum256([]byte(doc))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign the document: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(doc)) + "." + base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestNewAWSAuthenticator(t *testing.T) {
+	_, certPEM := generateAWSCertificate(t)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "certs.pem")
+	if err := os.WriteFile(certFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(emptyFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		rule      AWSIdentityRule
+		expectErr bool
+	}{
+		{
+			name: "valid rule",
+			rule: AWSIdentityRule{CertificatesFile: certFile, Accounts: map[string]string{"123456789012": "vm/sa"}},
+		},
+		{
+			name:      "missing certificates file",
+			rule:      AWSIdentityRule{CertificatesFile: filepath.Join(dir, "missing.pem")},
+			expectErr: true,
+		},
+		{
+			name:      "no certificate",
+			rule:      AWSIdentityRule{CertificatesFile: emptyFile},
+			expectErr: true,
+		},
+		{
+			name:      "invalid identity",
+			rule:      AWSIdentityRule{CertificatesFile: certFile, Accounts: map[string]string{"123456789012": "sa"}},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAWSAuthenticator(&tt.rule, nil)
+			gotErr := err != nil
+			if gotErr != tt.expectErr {
+				t.Errorf("expect error is %v while actual error is %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestAWSAuthenticate(t *testing.T) {
+	key, certPEM := generateAWSCertificate(t)
+	otherKey, _ := generateAWSCertificate(t)
+	certFile := filepath.Join(t.TempDir(), "certs.pem")
+	if err := os.WriteFile(certFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := NewAWSAuthenticator(&AWSIdentityRule{
+		CertificatesFile: certFile,
+		Accounts:         map[string]string{"123456789012": "vm/sa"},
+	}, mesh.NewFixedWatcher(&meshconfig.MeshConfig{TrustDomain: "cluster.local"}))
+	if err != nil {
+		t.Fatalf("failed to create the AWS authenticator: %v", err)
+	}
+
+	doc := `{"accountId": "123456789012", "instanceId": "i-1234567890abcdef0", "region": "us-west-2"}`
+	otherAccountDoc := `{"accountId": "210987654321", "instanceId": "i-1234567890abcdef0", "region": "us-west-2"}`
+	_, sig, _ := strings.Cut(signIdentityDocument(t, key, doc), ".")
+	tests := map[string]struct {
+		credential string
+		expectErr  bool
+		expectedID string
+	}{
+		"No credential": {
+			expectErr: true,
+		},
+		"Valid document": {
+			credential: signIdentityDocument(t, key, doc),
+			expectedID: spiffe.MustGenSpiffeURIForTrustDomain("cluster.local", "vm", "sa"),
+		},
+		"Document signed by another certificate": {
+			credential: signIdentityDocument(t, otherKey, doc),
+			expectErr:  true,
+		},
+		"Tampered document": {
+			credential: base64.StdEncoding.EncodeToString([]byte(otherAccountDoc)) + "." + sig,
+			expectErr:  true,
+		},
+		"Document of another account": {
+			credential: signIdentityDocument(t, key, otherAccountDoc),
+			expectErr:  true,
+		},
+		"Not a document": {
+			credential: "header.payload.signature",
+			expectErr:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tc.credential != "" {
+				md.Append("authorization", bearerTokenPrefix+tc.credential)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			actualCaller, err := authenticator.Authenticate(security.AuthContext{GrpcContext: ctx})
+			gotErr := err != nil
+			if gotErr != tc.expectErr {
+				t.Fatalf("gotErr (%v) whereas expectErr (%v)", err, tc.expectErr)
+			}
+			if gotErr {
+				return
+			}
+			expectedCaller := &security.Caller{
+				AuthSource: security.AuthSourceIDToken,
+				Identities: []string{tc.expectedID},
+			}
+			if !reflect.DeepEqual(actualCaller, expectedCaller) {
+				t.Errorf("unexpected caller (want %v but got %v)", expectedCaller, actualCaller)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"fmt"
+
+	oidc "github.com/coreos/go-oidc/v3/oidc"
+
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const (
+	AzureAuthenticatorType = "AzureAuthenticator"
+)
+
+// AzureIdentityRule configures the authentication of Azure managed identity tokens.
+type AzureIdentityRule struct {
+	// Issuer is the issuer of the tokens, "https://sts.windows.net/<tenant ID>/".
+	Issuer string `json:"issuer"`
+	// JwksURI is the URI of the keys of the issuer. If unset, it is discovered from the issuer.
+	JwksURI string `json:"jwks_uri"`
+	// Audiences are the accepted audiences of the tokens, which the proxies set in CREDENTIAL_AUDIENCE.
+	Audiences []string `json:"audiences"`
+	// Identities maps the object IDs of the managed identities allowed to authenticate to the
+	// "<namespace>/<service account>" identity of their workloads.
+	Identities map[string]string `json:"identities"`
+}
+
+// AzureAuthenticator authenticates Azure VMs with the token of their managed identity.
+type AzureAuthenticator struct {
+	// holder of a mesh configuration for dynamically updating trust domain
+	meshHolder mesh.Holder
+	audiences  []string
+	verifier   *oidc.IDTokenVerifier
+	identities map[string]serviceAccount
+}
+
+var _ security.Authenticator = &AzureAuthenticator{}
+
+// azureClaims is the subset of the managed identity token claims that we use.
+type azureClaims struct {
+	// ObjectID is the object ID of the managed identity.
+	ObjectID string `json:"oid"`
+}
+
+// NewAzureAuthenticator creates an authenticator of the managed identity tokens of the issuer of the rule.
+func NewAzureAuthenticator(rule *AzureIdentityRule, meshHolder mesh.Holder) (*AzureAuthenticator, error) {
+	if rule.Issuer == "" || len(rule.Audiences) == 0 {
+		return nil, fmt.Errorf("the Azure identity rule requires an issuer and audiences")
+	}
+	identities, err := parseServiceAccounts(rule.Identities)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := newIDTokenVerifier(rule.Issuer, rule.JwksURI)
+	if err != nil {
+		return nil, err
+	}
+	return &AzureAuthenticator{
+		meshHolder: meshHolder,
+		audiences:  rule.Audiences,
+		verifier:   verifier,
+		identities: identities,
+	}, nil
+}
+
+// Authenticate verifies the managed identity token of the caller, and returns the identity of its managed identity.
+func (a *AzureAuthenticator) Authenticate(authRequest security.AuthContext) (*security.Caller, error) {
+	ctx, bearerToken, err := extractBearerToken(authRequest)
+	if err != nil {
+		return nil, fmt.Errorf("managed identity token extraction error: %v", err)
+	}
+	idToken, err := a.verifier.Verify(ctx, bearerToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the managed identity token (error %v)", err)
+	}
+	if !checkAudience(idToken.Audience, a.audiences) {
+		return nil, fmt.Errorf("invalid audiences %v", idToken.Audience)
+	}
+	claims := azureClaims{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to extract claims from managed identity token: %v", err)
+	}
+	sa, ok := a.identities[claims.ObjectID]
+	if !ok {
+		return nil, fmt.Errorf("managed identity %q is not allowed to authenticate", claims.ObjectID)
+	}
+	return &security.Caller{
+		AuthSource: security.AuthSourceIDToken,
+		Identities: []string{spiffe.MustGenSpiffeURI(a.meshHolder.Mesh(), sa.namespace, sa.name)},
+	}, nil
+}
+
+func (a *AzureAuthenticator) AuthenticatorType() string {
+	return AzureAuthenticatorType
+}
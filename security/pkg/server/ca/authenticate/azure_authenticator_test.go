@@ -0,0 +1,161 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authenticate
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"google.golang.org/grpc/metadata"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+)
+
+func TestNewAzureAuthenticator(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      AzureIdentityRule
+		expectErr bool
+	}{
+		{
+			name: "valid rule",
+			rule: AzureIdentityRule{
+				Issuer:     "https://sts.windows.net/tenant/",
+				JwksURI:    "https://login.microsoftonline.com/tenant/discovery/keys",
+				Audiences:  []string{"api://istio"},
+				Identities: map[string]string{"object-id": "vm/sa"},
+			},
+		},
+		{
+			name:      "missing audiences",
+			rule:      AzureIdentityRule{Issuer: "https://sts.windows.net/tenant/", JwksURI: "https://login.microsoftonline.com/tenant/discovery/keys"},
+			expectErr: true,
+		},
+		{
+			name: "invalid identity",
+			rule: AzureIdentityRule{
+				Issuer:     "https://sts.windows.net/tenant/",
+				JwksURI:    "https://login.microsoftonline.com/tenant/discovery/keys",
+				Audiences:  []string{"api://istio"},
+				Identities: map[string]string{"object-id": "vm/"},
+			},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAzureAuthenticator(&tt.rule, nil)
+			gotErr := err != nil
+			if gotErr != tt.expectErr {
+				t.Errorf("expect error is %v while actual error is %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestAzureAuthenticate(t *testing.T) {
+	// Create a JWKS server standing in for the Azure AD keys
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate a private key: %v", err)
+	}
+	key := jose.JSONWebKey{Algorithm: string(jose.RS256), Key: rsaKey}
+	keySet := jose.JSONWebKeySet{}
+	keySet.Keys = append(keySet.Keys, key.Public())
+	server := httptest.NewServer(&jwksServer{key: keySet, t: t})
+	defer server.Close()
+
+	authenticator, err := NewAzureAuthenticator(&AzureIdentityRule{
+		Issuer:     server.URL,
+		JwksURI:    server.URL,
+		Audiences:  []string{"api://istio"},
+		Identities: map[string]string{"object-id": "vm/sa"},
+	}, mesh.NewFixedWatcher(&meshconfig.MeshConfig{TrustDomain: "cluster.local"}))
+	if err != nil {
+		t.Fatalf("failed to create the Azure authenticator: %v", err)
+	}
+
+	expStr := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	newToken := func(aud, oid, exp string) string {
+		// Managed identity tokens have a single audience.
+		claims := `{"iss": "` + server.URL + `", "aud": "` + aud + `", "oid": "` + oid + `", "exp": ` + exp + `}`
+		token, err := generateJWT(&key, []byte(claims))
+		if err != nil {
+			t.Fatalf("failed to generate JWT: %v", err)
+		}
+		return token
+	}
+
+	tests := map[string]struct {
+		token      string
+		expectErr  bool
+		expectedID string
+	}{
+		"No bearer token": {
+			expectErr: true,
+		},
+		"Valid token": {
+			token:      newToken("api://istio", "object-id", expStr),
+			expectedID: spiffe.MustGenSpiffeURIForTrustDomain("cluster.local", "vm", "sa"),
+		},
+		"Expired token": {
+			token:     newToken("api://istio", "object-id", strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)),
+			expectErr: true,
+		},
+		"Token with wrong audience": {
+			token:     newToken("wrong-audience", "object-id", expStr),
+			expectErr: true,
+		},
+		"Token of another managed identity": {
+			token:     newToken("api://istio", "other-object-id", expStr),
+			expectErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tc.token != "" {
+				md.Append("authorization", bearerTokenPrefix+tc.token)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			actualCaller, err := authenticator.Authenticate(security.AuthContext{GrpcContext: ctx})
+			gotErr := err != nil
+			if gotErr != tc.expectErr {
+				t.Fatalf("gotErr (%v) whereas expectErr (%v)", err, tc.expectErr)
+			}
+			if gotErr {
+				return
+			}
+			expectedCaller := &security.Caller{
+				AuthSource: security.AuthSourceIDToken,
+				Identities: []string{tc.expectedID},
+			}
+			if !reflect.DeepEqual(actualCaller, expectedCaller) {
+				t.Errorf("unexpected caller (want %v but got %v)", expectedCaller, actualCaller)
+			}
+		})
+	}
+}
@@ -44,13 +44,24 @@ var _ security.Authenticator = &JwtAuthenticator{}
 // K8S is created with --service-account-issuer, service-account-signing-key-file and service-account-api-audiences
 // which enable OIDC.
 func NewJwtAuthenticator(jwtRule *v1beta1.JWTRule, meshWatcher mesh.Watcher) (*JwtAuthenticator, error) {
-	issuer := jwtRule.GetIssuer()
-	jwksURL := jwtRule.GetJwksUri()
+	verifier, err := newIDTokenVerifier(jwtRule.GetIssuer(), jwtRule.GetJwksUri())
+	if err != nil {
+		return nil, err
+	}
+	return &JwtAuthenticator{
+		meshHolder: meshWatcher,
+		verifier:   verifier,
+		audiences:  jwtRule.Audiences,
+	}, nil
+}
+
+// newIDTokenVerifier returns a verifier of the tokens of the issuer. The keys of the issuer are read from jwksURL,
+// or discovered from the issuer if jwksURL is empty.
+func newIDTokenVerifier(issuer, jwksURL string) (*oidc.IDTokenVerifier, error) {
 	// The key of a JWT issuer may change, so the key may need to be updated.
 	// Based on https://pkg.go.dev/github.com/coreos/go-oidc/v3/oidc#NewRemoteKeySet
 	// the oidc library handles caching and cache invalidation. Thus, the verifier
 	// is only created once in the constructor.
-	var verifier *oidc.IDTokenVerifier
 	if len(jwksURL) == 0 {
 		// OIDC discovery is used if jwksURL is not set.
 		provider, err := oidc.NewProvider(context.Background(), issuer)
@@ -58,16 +69,10 @@ func NewJwtAuthenticator(jwtRule *v1beta1.JWTRule, meshWatcher mesh.Watcher) (*J
 		if err != nil {
 			return nil, fmt.Errorf("failed at creating an OIDC provider for %v: %v", issuer, err)
 		}
-		verifier = provider.Verifier(&oidc.Config{SkipClientIDCheck: true})
-	} else {
-		keySet := oidc.NewRemoteKeySet(context.Background(), jwksURL)
-		verifier = oidc.NewVerifier(issuer, keySet, &oidc.Config{SkipClientIDCheck: true})
+		return provider.Verifier(&oidc.Config{SkipClientIDCheck: true}), nil
 	}
-	return &JwtAuthenticator{
-		meshHolder: meshWatcher,
-		verifier:   verifier,
-		audiences:  jwtRule.Audiences,
-	}, nil
+	keySet := oidc.NewRemoteKeySet(context.Background(), jwksURL)
+	return oidc.NewVerifier(issuer, keySet, &oidc.Config{SkipClientIDCheck: true}), nil
 }
 
 // Authenticate - based on the old OIDC authenticator for mesh expansion.
@@ -129,6 +134,38 @@ func checkAudience(audToCheck []string, audExpected []string) bool {
 	return false
 }
 
+// extractBearerToken returns the bearer token of the gRPC or HTTP request, and the context of the request.
+func extractBearerToken(authRequest security.AuthContext) (context.Context, string, error) {
+	if authRequest.GrpcContext != nil {
+		token, err := security.ExtractBearerToken(authRequest.GrpcContext)
+		return authRequest.GrpcContext, token, err
+	}
+	if authRequest.Request != nil {
+		token, err := security.ExtractRequestToken(authRequest.Request)
+		return authRequest.Request.Context(), token, err
+	}
+	return nil, "", fmt.Errorf("no request to authenticate")
+}
+
+// serviceAccount is the namespace and name of a Kubernetes service account.
+type serviceAccount struct {
+	namespace string
+	name      string
+}
+
+// parseServiceAccounts parses the "<namespace>/<service account>" values of the identities.
+func parseServiceAccounts(identities map[string]string) (map[string]serviceAccount, error) {
+	out := make(map[string]serviceAccount, len(identities))
+	for k, v := range identities {
+		ns, name, ok := strings.Cut(v, "/")
+		if !ok || ns == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid identity %q for %s, expected <namespace>/<service account>", v, k)
+		}
+		out[k] = serviceAccount{namespace: ns, name: name}
+	}
+	return out, nil
+}
+
 type JwtPayload struct {
 	// Aud is the expected audience, defaults to istio-ca - but is based on istiod.yaml configuration.
 	// If set to a different value - use the value defined by istiod.yaml. Env variable can